@@ -0,0 +1,43 @@
+package main
+
+import (
+	"exporter/exporter"
+	"flag"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DECRYPT_USAGE = `Usage: daylio-to-day-one decrypt [OPTIONS] ENCRYPTED_EXPORT
+Decrypts an export made with "-encrypt" so that it can be imported into Day One.
+
+The passphrase is read from EXPORT_PASSPHRASE, or prompted for if it isn't set.
+
+OPTIONS
+
+	ENCRYPTED_EXPORT	The encrypted file, i.e. "exports/export-20231217.zip.enc".
+	-output FILE		Where to write the decrypted file. Defaults to the
+						encrypted file's name without ".enc". "-2", "-3",
+						and so on are added to the name when it's taken.
+`
+)
+
+func runDecrypt(args []string) {
+	flags := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), DECRYPT_USAGE) }
+	output := flags.String("output", "", "")
+	parseFlags(flags, args)
+	if flags.NArg() != 1 {
+		exitWithUsage(flags)
+	}
+	passphrase, err := readPassphrase(false)
+	if err != nil {
+		fail("decrypting the export", err)
+	}
+	file, err := exporter.DecryptFile(flags.Arg(0), *output, passphrase)
+	if err != nil {
+		fail("decrypting the export", err)
+	}
+	log.Infof("Your export was decrypted: %s", file)
+}
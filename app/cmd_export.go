@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"exporter/converter"
+	"exporter/daylio"
+	"exporter/exporter"
+	"exporter/types"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func runExport(args []string) {
+	flags := flag.NewFlagSet("daylio-to-day-one", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), USAGE) }
+	sinkFlags := addSinkFlags(flags)
+	outputDir := flags.String("output-dir", "", "")
+	journal := flags.String("journal", "", "")
+	redactConfig := flags.String("redact", "", "")
+	deviceFlag := flags.String("device", "", "")
+	summaries := flags.Bool("summaries", false, "")
+	yearInReview := flags.Int("year-in-review", 0, "")
+	paletteColours := flags.String("palette", "", "")
+	workers := flags.Int("workers", 0, "")
+	onConflict := flags.String("on-conflict", string(daylio.ConflictKeepAll), "")
+	filterFlags := addFilterFlags(flags)
+	weather := addWeatherFlags(flags)
+	locationHistory := addLocationHistoryFlags(flags)
+	parseFlags(flags, args)
+	settings, err := exporter.Initialize(exporter.ExportSettings{Directory: *outputDir, JournalName: *journal})
+	if err != nil {
+		fail("initializing the exporter", err)
+	}
+	policy, err := daylio.ParseConflictPolicy(*onConflict)
+	if err != nil {
+		fail("performing the export", err)
+	}
+	filter, err := filterFlags.filter()
+	if err != nil {
+		fail("performing the export", err)
+	}
+	palette, err := parsePalette(*paletteColours)
+	if err != nil {
+		fail("performing the export", err)
+	}
+	var redactor *daylio.Redactor
+	if *redactConfig != "" {
+		if redactor, err = daylio.ReadRedactionConfigFile(*redactConfig); err != nil {
+			fail("performing the export", usage(err))
+		}
+	}
+	device, err := deviceProfile(*deviceFlag)
+	if err != nil {
+		fail("performing the export", err)
+	}
+	weatherHistory, err := weather.history()
+	if err != nil {
+		fail("performing the export", err)
+	}
+	locations, err := locationHistory.history()
+	if err != nil {
+		fail("performing the export", err)
+	}
+	sinks, err := sinkFlags.sinks(settings)
+	if err != nil {
+		fail("performing the export", err)
+	}
+	inputs, err := converter.FileInputs(flags.Args())
+	if err != nil {
+		fail("performing the export", err)
+	}
+	opts, err := withQuirksFromEnv(converter.Options{
+		Inputs:          inputs,
+		ConflictPolicy:  policy,
+		Filter:          filter,
+		Redactor:        redactor,
+		Device:          device,
+		Weather:         weatherHistory,
+		LocationHistory: locations,
+		JournalName:     settings.JournalName,
+		Summaries:       *summaries,
+		YearInReview:    *yearInReview,
+		Palette:         palette,
+		Workers:         *workers,
+		Progress:        newProgressReporter(),
+		Logger:          log.StandardLogger(),
+		Sinks:           sinks,
+	})
+	if err != nil {
+		fail("performing the export", err)
+	}
+	// Interrupting the export stops it before anything is written.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	result, err := converter.Convert(ctx, opts)
+	var sinkErr *converter.SinkError
+	switch {
+	case errors.Is(err, context.Canceled):
+		log.Error("The export was cancelled; nothing was written")
+		os.Exit(EXIT_CANCELLED)
+	case errors.As(err, &sinkErr):
+		fail("writing the exports", err)
+	case err != nil:
+		fail("performing the export", err)
+	}
+	summary, results := &result.Summary, result.Outputs
+	if summary.Merge != nil {
+		printMergeReport(summary.Merge)
+	}
+	if summary.Redactions != nil {
+		printRedactionReport(summary.Redactions)
+	}
+	for _, r := range results {
+		printSinkSuccessMessage(r)
+	}
+	writeManifest(flags, summary, results, settings.Directory)
+}
+
+// writeManifest describes an export along with the flags it was run with.
+// It's written into dir unless there's a Day One ZIP file to put it next to.
+func writeManifest(flags *flag.FlagSet, summary *exporter.RunSummary, results []exporter.SinkResult, dir string) {
+	options := map[string]string{}
+	flags.Visit(func(f *flag.Flag) {
+		options[f.Name] = f.Value.String()
+	})
+	manifest, err := exporter.NewManifest(summary, results, options)
+	if err != nil {
+		fail("writing the manifest", err)
+	}
+	manifestFile, err := exporter.WriteManifest(manifest, results, dir)
+	if err != nil {
+		fail("writing the manifest", err)
+	}
+	log.Infof("A manifest of this export was written to: %s", manifestFile)
+}
+
+func printSuccessMessage(r *types.DayOneExportResult) {
+	zf, err := filepath.Abs(r.ZipFile)
+	if err != nil {
+		panic(err)
+	}
+	if r.Encrypted {
+		plain := exporter.DecryptedFileName(zf)
+		log.Infof(`Your encrypted Day One JSON ZIP file is ready: %s
+
+Do the following on this computer to finish importing your Daylio entries into Day One:
+
+1. Decrypt it: daylio-to-day-one decrypt "%s"
+2. Open the Day One app.
+3. Click on 'File', then 'Import', then 'JSON ZIP File'.
+4. Browse to this folder: %s
+5. Click on this file, then on Open: %s
+6. Delete the decrypted file once the import is done.
+
+Your journal entries will appear in a new Day One journal called "%s". You can leave them there
+or move them into your desired journal.
+`, zf, zf, path.Dir(plain), filepath.Base(plain), r.JournalName)
+		return
+	}
+	log.Infof(`Your Day One JSON ZIP file is ready! Do the following on this computer to finish \
+importing your Daylio entries into Day One:
+
+1. Open the Day One app.
+2. Click on 'File', then 'Import', then 'JSON ZIP File'.
+3. Browse to this folder: %s
+4. Click on this file, then on Open: %s
+
+Your journal entries will appear in a new Day One journal called "%s". You can leave them there
+or move them into your desired journal.
+`, path.Dir(zf), filepath.Base(zf), r.JournalName)
+}
+
+func printMarkdownSuccessMessage(r *exporter.MarkdownExportResult) {
+	dir, err := filepath.Abs(r.Directory)
+	if err != nil {
+		panic(err)
+	}
+	log.Infof(`Your Markdown vault is ready! %d files were written to this folder: %s
+
+Open this folder (or copy it into an existing vault) in Obsidian or any other Markdown editor.
+`, len(r.Files()), dir)
+}
+
+func printMergeReport(r *daylio.MergeReport) {
+	for _, src := range r.Sources {
+		log.Infof("%s: read %d entries, kept %d, dropped %d duplicates and %d conflicts",
+			src.Path, src.Read, src.Kept, src.Duplicates, src.Conflicts)
+	}
+	for _, c := range r.Conflicts {
+		log.Infof("Conflict at %s %s: kept %s, dropped %s", c.FullDate, c.Time,
+			strings.Join(c.KeptFrom, ", "), strings.Join(c.DroppedFrom, ", "))
+	}
+}
+
+// printRedactionReport only prints counts so that redacted text never ends
+// up in logs.
+func printRedactionReport(r *daylio.RedactionReport) {
+	for _, c := range r.Rules {
+		log.Infof("Redaction rule '%s' (%s): %d redactions", c.Rule, c.Kind, c.Redactions)
+	}
+	log.Infof("Redacted %d entries with %d redactions in total", r.Entries, r.Total())
+}
+
+// withQuirksFromEnv adds the quirks configured by environment variables, like
+// HOME_ADDRESS_JSON and NO_ALONE_TIME_SCORING, to opts.
+func withQuirksFromEnv(opts converter.Options) (converter.Options, error) {
+	quirks, err := exporter.ConvertOptionsFromEnv()
+	if err != nil {
+		return opts, err
+	}
+	opts.TimeZone = quirks.TimeZone
+	opts.HomeLocation = quirks.HomeLocation
+	opts.AloneTimeScoring = daylio.ReadOptionsFromEnv().AloneTimeScoring
+	return opts, nil
+}
+
+// newProgressReporter reports conversion progress on stderr, but only when
+// it's a terminal so that logs captured by scripts stay clean.
+func newProgressReporter() *exporter.ProgressReporter {
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return exporter.NewProgressReporter(os.Stderr)
+}
+
+func printSinkSuccessMessage(r exporter.SinkResult) {
+	switch result := r.(type) {
+	case *types.DayOneExportResult:
+		printSuccessMessage(result)
+	case *exporter.MarkdownExportResult:
+		printMarkdownSuccessMessage(result)
+	default:
+		log.Infof("Your export is ready: %s", strings.Join(r.Files(), ", "))
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"exporter/daylio"
+	"exporter/exporter"
+	"exporter/stats"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	PIXELS_USAGE = `Usage: daylio-to-day-one pixels [OPTIONS] [FILE...]
+Draws a "Year in Pixels" image, with one row per month and one cell per day
+coloured by that day's average mood.
+
+OPTIONS
+
+	FILE			The path to the Daylio backup file. Optional if
+						iCloud Backup is enabled within Daylio. Provide
+						several backups or CSV exports to merge them.
+	-year YEAR		The year to draw. Defaults to the year of the latest
+						entry.
+	-palette COLOURS	Hex colours for rad, good, meh, bad, and awful
+						moods, separated by commas, optionally followed
+						by a colour for days without entries, i.e.
+						"#1abc9c,#9ccc65,#42a5f5,#ffa726,#ef5350,#eeeeee".
+	-output FILE		Where to write the image. Files ending in ".svg" are
+						written as SVGs, anything else as PNGs. Defaults to
+						"year-in-pixels-YEAR.png" within the export
+						directory.
+	-on-conflict POLICY	What to do when merged files have different entries
+						at the same time: "keep-all" (default), "first",
+						"last", or "longest".
+` + FILTER_USAGE + OUTPUT_DIR_USAGE
+)
+
+func runPixels(args []string) {
+	flags := flag.NewFlagSet("pixels", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), PIXELS_USAGE) }
+	year := flags.Int("year", 0, "")
+	paletteColours := flags.String("palette", "", "")
+	output := flags.String("output", "", "")
+	outputDir := flags.String("output-dir", "", "")
+	onConflict := flags.String("on-conflict", string(daylio.ConflictKeepAll), "")
+	filterFlags := addFilterFlags(flags)
+	parseFlags(flags, args)
+	palette, err := parsePalette(*paletteColours)
+	if err != nil {
+		fail("drawing the image", err)
+	}
+	policy, err := daylio.ParseConflictPolicy(*onConflict)
+	if err != nil {
+		fail("drawing the image", err)
+	}
+	filter, err := filterFlags.filter()
+	if err != nil {
+		fail("drawing the image", err)
+	}
+	entries, err := readEntries(flags.Args(), policy, filter)
+	if err != nil {
+		fail("reading entries", err)
+	}
+	if *year == 0 {
+		*year = latestYear(entries)
+	}
+	file := *output
+	if file == "" {
+		settings, err := exporter.Initialize(exporter.ExportSettings{Directory: *outputDir})
+		if err != nil {
+			fail("initializing the exporter", err)
+		}
+		file = filepath.Join(settings.Directory, fmt.Sprintf("year-in-pixels-%d.png", *year))
+	}
+	if err := writePixels(file, stats.ComputeYearInPixels(entries, *year), palette); err != nil {
+		fail("drawing the image", err)
+	}
+	log.Infof("Your Year in Pixels is ready: %s", file)
+}
+
+// parsePalette falls back to the default palette when no colours are provided.
+func parsePalette(s string) (stats.Palette, error) {
+	if s == "" {
+		return stats.DefaultPalette, nil
+	}
+	p, err := stats.ParsePalette(s)
+	return p, usage(err)
+}
+
+// latestYear is the year of the newest entry, or the current year if there
+// are none.
+func latestYear(entries []daylio.Entry) int {
+	latest := 0
+	for idx := range entries {
+		y, err := strconv.Atoi(strings.SplitN(entries[idx].LocalDate(), "-", 2)[0])
+		if err == nil && y > latest {
+			latest = y
+		}
+	}
+	if latest == 0 {
+		return time.Now().Year()
+	}
+	return latest
+}
+
+func writePixels(file string, p *stats.YearInPixels, palette stats.Palette) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	write := stats.WritePNG
+	if strings.EqualFold(filepath.Ext(file), ".svg") {
+		write = stats.WriteSVG
+	}
+	if err := write(f, p, palette); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
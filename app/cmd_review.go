@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"exporter/converter"
+	"exporter/daylio"
+	"exporter/exporter"
+	"exporter/review"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	REVIEW_USAGE = `Usage: daylio-to-day-one review [OPTIONS] [FILE...]
+Steps through converted entries before writing the export. Entries are listed
+by date with their mood and tags. Open an entry to see its final text and rich
+text, exclude or include entries, change their titles, and star them, then
+write the export in every format chosen with -format. Type "h" within the
+review for every command.
+
+The review reads one command per line from standard input, so it can also be
+scripted, i.e. "printf 'x 2\nw\n' | daylio-to-day-one review". Set
+EXPORT_PASSPHRASE when scripting it with -encrypt. Summaries and "Year in
+Review" entries aren't added to reviewed exports.
+
+Decisions are saved as they're made, so rerunning a review picks up where it
+left off and reapplies them.
+
+OPTIONS
+
+	FILE			The path to the Daylio backup file. Optional if
+						iCloud Backup is enabled within Daylio. Provide
+						several backups or CSV exports to merge them.
+	-decisions FILE		Where decisions are saved. Defaults to
+						"review-decisions.json" within the export
+						directory.
+	-journal NAME		The Day One journal to import entries into.
+						Defaults to JOURNAL_NAME, or "From Daylio".
+	-on-conflict POLICY	What to do when merged files have different entries
+						at the same time: "keep-all" (default), "first",
+						"last", or "longest".
+` + SINK_USAGE + DEVICE_USAGE + LOCATION_HISTORY_USAGE + WEATHER_USAGE + FILTER_USAGE + OUTPUT_DIR_USAGE
+)
+
+// REVIEW_DECISIONS_FILE is where review decisions are saved by default,
+// within the export directory.
+const REVIEW_DECISIONS_FILE = "review-decisions.json"
+
+func runReview(args []string) {
+	flags := flag.NewFlagSet("review", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), REVIEW_USAGE) }
+	decisionsFile := flags.String("decisions", "", "")
+	journal := flags.String("journal", "", "")
+	outputDir := flags.String("output-dir", "", "")
+	onConflict := flags.String("on-conflict", string(daylio.ConflictKeepAll), "")
+	deviceFlag := flags.String("device", "", "")
+	sinkFlags := addSinkFlags(flags)
+	filterFlags := addFilterFlags(flags)
+	weather := addWeatherFlags(flags)
+	locationHistory := addLocationHistoryFlags(flags)
+	parseFlags(flags, args)
+	settings, err := exporter.Initialize(exporter.ExportSettings{Directory: *outputDir, JournalName: *journal})
+	if err != nil {
+		fail("initializing the exporter", err)
+	}
+	policy, err := daylio.ParseConflictPolicy(*onConflict)
+	if err != nil {
+		fail("reviewing the export", err)
+	}
+	filter, err := filterFlags.filter()
+	if err != nil {
+		fail("reviewing the export", err)
+	}
+	device, err := deviceProfile(*deviceFlag)
+	if err != nil {
+		fail("reviewing the export", err)
+	}
+	weatherHistory, err := weather.history()
+	if err != nil {
+		fail("reviewing the export", err)
+	}
+	locations, err := locationHistory.history()
+	if err != nil {
+		fail("reviewing the export", err)
+	}
+	sinks, err := sinkFlags.sinks(settings)
+	if err != nil {
+		fail("reviewing the export", err)
+	}
+	inputs, err := converter.FileInputs(flags.Args())
+	if err != nil {
+		fail("reading entries", err)
+	}
+	opts, err := withQuirksFromEnv(converter.Options{
+		Inputs:          inputs,
+		ConflictPolicy:  policy,
+		Filter:          filter,
+		Device:          device,
+		Weather:         weatherHistory,
+		LocationHistory: locations,
+		JournalName:     settings.JournalName,
+		Logger:          log.StandardLogger(),
+	})
+	if err != nil {
+		fail("reviewing the export", err)
+	}
+	result, err := converter.Convert(context.Background(), opts)
+	if err != nil {
+		fail("reading entries", err)
+	}
+	entries, summary := result.Entries, &result.Summary
+	if *decisionsFile == "" {
+		*decisionsFile = filepath.Join(settings.Directory, REVIEW_DECISIONS_FILE)
+	}
+	decisions, err := review.ReadDecisionsFile(*decisionsFile)
+	if err != nil {
+		fail("reading review decisions", usage(err))
+	}
+	save := func(d *review.Decisions) error {
+		if err := d.WriteFile(*decisionsFile); err != nil {
+			return &exporter.WriteError{Path: *decisionsFile, Err: err}
+		}
+		return nil
+	}
+	write, err := review.NewSession(entries, decisions, save, os.Stdin, os.Stdout).Run()
+	if err != nil {
+		fail("reviewing the export", err)
+	}
+	log.Infof("Your review decisions were saved to: %s", *decisionsFile)
+	if !write {
+		return
+	}
+	reviewed, err := review.Apply(entries, decisions)
+	if err != nil {
+		fail("applying review decisions", err)
+	}
+	summary.Recount(reviewed)
+	results, err := exporter.WriteToSinks(reviewed, summary, sinks)
+	if err != nil {
+		fail("writing the export", err)
+	}
+	for _, r := range results {
+		printSinkSuccessMessage(r)
+	}
+	writeManifest(flags, summary, results, settings.Directory)
+}
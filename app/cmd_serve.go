@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"exporter/web"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	SERVE_USAGE = `Usage: daylio-to-day-one serve [OPTIONS]
+Starts a web page for converting Daylio backups without using a terminal.
+Upload a Daylio backup or CSV export, choose options, preview the converted
+entries, and download the Day One JSON ZIP file.
+
+The page is only reachable from this computer, and uploads never leave it;
+they're converted in memory and nothing is written to disk.
+
+OPTIONS
+
+	-addr ADDRESS		Where to listen. Must be a local address. Defaults
+						to "127.0.0.1:8080".
+`
+)
+
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), SERVE_USAGE) }
+	addr := flags.String("addr", "127.0.0.1:8080", "")
+	parseFlags(flags, args)
+	if flags.NArg() != 0 {
+		exitWithUsage(flags)
+	}
+	if !web.IsLoopbackAddress(*addr) {
+		fail("starting the server", usage(fmt.Errorf("Not a local address: %s", *addr)))
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fail("starting the server", err)
+	}
+	server := &http.Server{Handler: web.NewHandler(), ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	log.Infof("Open this page in your browser to convert your Daylio backup: http://%s/", listener.Addr())
+	log.Info("Press Ctrl-C to stop.")
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fail("serving the web page", err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"exporter/converter"
+	"exporter/daylio"
+	"exporter/stats"
+	"flag"
+	"fmt"
+	"os"
+)
+
+const (
+	STATS_USAGE = `Usage: daylio-to-day-one stats [OPTIONS] [FILE...]
+Prints mood and activity statistics for the entries in a Daylio backup.
+
+Moods are scored from 1 (awful) to 5 (rad). The report has average moods by
+week, month, year, and day of the week; how often each activity was logged and
+the average mood with and without it; and the longest logging streak.
+
+OPTIONS
+
+	FILE			The path to the Daylio backup file. Optional if
+						iCloud Backup is enabled within Daylio. Provide
+						several backups or CSV exports to merge them.
+	-output FORMAT		"table" (default), "json", or "csv".
+	-on-conflict POLICY	What to do when merged files have different entries
+						at the same time: "keep-all" (default), "first",
+						"last", or "longest".
+` + FILTER_USAGE
+)
+
+func runStats(args []string) {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), STATS_USAGE) }
+	output := flags.String("output", string(stats.FormatTable), "")
+	onConflict := flags.String("on-conflict", string(daylio.ConflictKeepAll), "")
+	filterFlags := addFilterFlags(flags)
+	parseFlags(flags, args)
+	format, err := stats.ParseFormat(*output)
+	if err != nil {
+		fail("computing statistics", usage(err))
+	}
+	policy, err := daylio.ParseConflictPolicy(*onConflict)
+	if err != nil {
+		fail("computing statistics", err)
+	}
+	filter, err := filterFlags.filter()
+	if err != nil {
+		fail("computing statistics", err)
+	}
+	entries, err := readEntries(flags.Args(), policy, filter)
+	if err != nil {
+		fail("reading entries", err)
+	}
+	report, err := stats.Compute(entries)
+	if err != nil {
+		fail("computing statistics", err)
+	}
+	if err := stats.Write(os.Stdout, report, format); err != nil {
+		fail("printing statistics", err)
+	}
+}
+
+// readEntries reads and merges entries from files, or the latest Daylio
+// backup when there are none, without converting them.
+func readEntries(files []string, policy daylio.ConflictPolicy, filter daylio.Filter) ([]daylio.Entry, error) {
+	inputs, err := converter.FileInputs(files)
+	if err != nil {
+		return nil, err
+	}
+	entries, _, err := converter.Read(context.Background(), converter.Options{
+		Inputs:           inputs,
+		ConflictPolicy:   policy,
+		Filter:           filter,
+		AloneTimeScoring: daylio.ReadOptionsFromEnv().AloneTimeScoring,
+	})
+	return entries, err
+}
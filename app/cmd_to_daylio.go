@@ -0,0 +1,70 @@
+package main
+
+import (
+	"exporter/dayone"
+	"exporter/exporter"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	TO_DAYLIO_USAGE = `Usage: daylio-to-day-one to-daylio [OPTIONS] DAY_ONE_ZIP [CSV_FILE]
+Converts a Day One JSON ZIP file into a CSV file that Daylio can import.
+
+Moods are restored from "mood: " tags. Entries without one are given the
+"meh" mood.
+
+OPTIONS
+
+	DAY_ONE_ZIP		The path to a Day One JSON ZIP file, made by this
+						tool or by Day One.
+	CSV_FILE		Where to write the CSV. Defaults to
+						"daylio-YYYYMMDD.csv" within the export directory.
+						"-2", "-3", and so on are added to the name when
+						it's taken.
+	-utc			Keep times in UTC instead of each entry's time
+						zone. Use this for entries this tool made from a
+						Daylio CSV export, which keep Daylio's time as UTC.
+` + OUTPUT_DIR_USAGE
+)
+
+func runToDaylio(args []string) {
+	flags := flag.NewFlagSet("to-daylio", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), TO_DAYLIO_USAGE) }
+	utc := flags.Bool("utc", false, "")
+	outputDir := flags.String("output-dir", "", "")
+	parseFlags(flags, args)
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		exitWithUsage(flags)
+	}
+	settings, err := exporter.Initialize(exporter.ExportSettings{Directory: *outputDir})
+	if err != nil {
+		fail("initializing the exporter", err)
+	}
+	csvFile := filepath.Join(settings.Directory,
+		fmt.Sprintf("daylio-%s.csv", time.Now().Format("20060102")))
+	if flags.NArg() == 2 {
+		csvFile = flags.Arg(1)
+	}
+	exports, err := dayone.ReadExportZip(flags.Arg(0))
+	if err != nil {
+		fail("reading the Day One export", err)
+	}
+	entries := dayone.ToDaylioEntries(exports, *utc)
+	written, err := exporter.WriteDaylioCSV(csvFile, entries)
+	if err != nil {
+		fail("writing the CSV", err)
+	}
+	abs, err := filepath.Abs(written)
+	if err != nil {
+		panic(err)
+	}
+	log.Infof(`Your Daylio CSV file is ready! %d entries were written to: %s
+
+It has the same columns as the CSV files that Daylio exports.
+`, len(entries), abs)
+}
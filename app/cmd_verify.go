@@ -0,0 +1,56 @@
+package main
+
+import (
+	"exporter/exporter"
+	"flag"
+	"fmt"
+	"os"
+)
+
+const (
+	VERIFY_USAGE = `Usage: daylio-to-day-one verify EXPORT_ZIP [FILE]
+Checks that every entry in a Daylio backup made it into a Day One JSON ZIP file.
+
+Entries are matched by the time they were created. Missing, duplicated, and
+unexpected entries are reported along with entries whose text, tags, mood, or
+time zone changed.
+
+OPTIONS
+
+	EXPORT_ZIP		The Day One JSON ZIP file to verify.
+	FILE			The path to the Daylio backup file. Optional if
+						iCloud Backup is enabled within Daylio.
+
+EXIT CODES
+
+	0			Everything matched.
+	2			Discrepancies were found.
+
+Other failures exit like the export does; see "daylio-to-day-one -h".
+`
+)
+
+func runVerify(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), VERIFY_USAGE) }
+	parseFlags(flags, args)
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		exitWithUsage(flags)
+	}
+	providedBackupFile := ""
+	if flags.NArg() == 2 {
+		providedBackupFile = flags.Arg(1)
+	}
+	report, err := exporter.VerifyDayOneExport(providedBackupFile, flags.Arg(0))
+	if err != nil {
+		fail("verifying the export", err)
+	}
+	for _, d := range report.Discrepancies {
+		fmt.Println(d.String())
+	}
+	fmt.Printf("%d Daylio entries, %d exported entries, %d matched, %d discrepancies\n",
+		report.SourceEntries, report.ExportedEntries, report.Matched, len(report.Discrepancies))
+	if !report.OK() {
+		os.Exit(EXIT_DISCREPANCIES)
+	}
+}
@@ -0,0 +1,514 @@
+// Package converter converts Daylio backups and CSV exports into Day One
+// journals. It's meant for embedding the exporter within other programs:
+// unlike the command line tool, it never reads environment variables or
+// global settings, only logs to Options.Logger, and only touches the file
+// system for inputs and sinks that ask it to.
+//
+//	result, err := converter.Convert(ctx, converter.Options{
+//		Inputs: []converter.Input{converter.BytesInput("backup.daylio", data)},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	return result.WriteDayOneZip(w)
+package converter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"exporter/daylio"
+	"exporter/exporter"
+	"exporter/stats"
+	"exporter/types"
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Input is a Daylio backup or CSV export to convert.
+type Input struct {
+	// Name identifies the input within reports and errors. Names ending with
+	// ".csv" are read as Daylio CSV exports; anything else is read as a
+	// Daylio backup.
+	Name string
+	// Data is the input's contents. The input is read from Path when it's
+	// nil.
+	Data []byte
+	// Path is the file to read the input from when Data is nil.
+	Path string
+}
+
+// BytesInput is an input that's already in memory.
+func BytesInput(name string, data []byte) Input {
+	return Input{Name: name, Data: data}
+}
+
+// FileInput is an input that's read from a file. Backups are decoded as
+// they're read, so they needn't fit in memory.
+func FileInput(path string) Input {
+	return Input{Name: path, Path: path}
+}
+
+// FileInputs are inputs read from files, or from the latest Daylio backup
+// within iCloud when there are none.
+func FileInputs(paths []string) ([]Input, error) {
+	if len(paths) == 0 {
+		backup, err := daylio.ResolveBackupLocation("")
+		if err != nil {
+			return nil, err
+		}
+		paths = []string{backup}
+	}
+	inputs := []Input{}
+	for _, path := range paths {
+		inputs = append(inputs, FileInput(path))
+	}
+	return inputs, nil
+}
+
+// Options configures a conversion. Only Inputs is required.
+type Options struct {
+	// Inputs are merged when there are several.
+	Inputs []Input
+	// ConflictPolicy resolves conflicting entries when several inputs are
+	// merged. Defaults to keeping every entry.
+	ConflictPolicy daylio.ConflictPolicy
+	// Filter decides which entries are converted.
+	Filter daylio.Filter
+	// Redactor removes private details from entries before they're
+	// converted, if provided. See daylio.NewRedactor.
+	Redactor *daylio.Redactor
+	// Generators create IDs and modification times for Day One entries.
+	// Defaults to types.DefaultDayOneGenerators.
+	Generators types.DayOneGenerators
+	// TimeZone is recorded on every Day One entry, i.e. "America/Chicago".
+	// Defaults to "UTC".
+	TimeZone string
+	// LocationHistory gives entries where they were written, if provided.
+	// Entries given HomeLocation by their activities keep it. See
+	// exporter.ReadLocationHistoryFiles.
+	LocationHistory *exporter.LocationHistory
+	// Weather is given to entries on days it has weather for, if provided.
+	// See exporter.ReadWeatherHistory.
+	Weather *exporter.WeatherHistory
+	// Device is what entries look like they were created on. Defaults to
+	// types.MacDeviceProfile.
+	Device types.DeviceProfile
+	// JournalName names the Day One journal within WriteDayOneZip and any
+	// exporter.DayOneSink that doesn't name one itself. Defaults to "From
+	// Daylio".
+	JournalName string
+	// HomeLocation is given to entries with the "home" activity, if set.
+	HomeLocation *types.DayOneEntryLocation
+	// AloneTimeScoring replaces the "No", "A Little Bit", and "Yes!"
+	// activities within backups with "alone score: N" tags.
+	AloneTimeScoring bool
+	// Summaries adds an entry summarizing every month and year.
+	Summaries bool
+	// YearInReview adds a "Year in Review" entry for that year when it isn't
+	// zero.
+	YearInReview int
+	// Palette colours the Year in Pixels image within "Year in Review"
+	// entries. Defaults to stats.DefaultPalette.
+	Palette stats.Palette
+	// Workers is how many entries are converted at once. Defaults to the
+	// number of CPUs.
+	Workers int
+	// Progress reports how far along the conversion is, if provided.
+	Progress *exporter.ProgressReporter
+	// Logger reports what's happening, like how many entries were skipped by
+	// Filter. Nothing is logged when it's nil.
+	Logger logrus.FieldLogger
+	// Sinks write the converted entries, in order, once conversion is done.
+	// Sinks that are exporter.StreamingSinks write each entry as soon as it's
+	// converted instead. When every sink streams and there's a single input
+	// without Summaries or YearInReview, entries are read, converted, and
+	// written one at a time, so that none are held in memory; Result.Entries
+	// is empty then. Nothing is written when there are no sinks. See
+	// exporter.NewSinks.
+	Sinks []exporter.Sink
+}
+
+// Result is a finished conversion.
+type Result struct {
+	// Entries are the converted entries, in order, followed by generated
+	// entries like summaries. It's empty when entries were streamed to
+	// Options.Sinks.
+	Entries []exporter.ConvertedEntry
+	// Summary describes the run, including merge and redaction reports.
+	Summary exporter.RunSummary
+	// JournalName is the Day One journal that entries belong to.
+	JournalName string
+	// Outputs describe what each sink wrote, in the order of Options.Sinks.
+	Outputs []exporter.SinkResult
+}
+
+// DayOneExport is the Day One JSON export of the converted entries.
+func (r *Result) DayOneExport() *types.DayOneExport {
+	entries := []types.DayOneEntry{}
+	for _, e := range r.Entries {
+		entries = append(entries, e.DayOne)
+	}
+	return types.NewDayOneExport(entries)
+}
+
+// WriteDayOneZip writes the converted entries as a Day One JSON ZIP file,
+// ready to import.
+func (r *Result) WriteDayOneZip(w io.Writer) error {
+	return exporter.WriteDayOneZip(w, r.JournalName, r.Entries)
+}
+
+// ErrNoInputs is returned when Options has no inputs.
+var ErrNoInputs = errors.New("At least one Daylio backup or CSV export is required")
+
+// InputError is returned when an input can't be read.
+type InputError struct {
+	// Name is the name of the input.
+	Name string
+	Err  error
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, e.Err)
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// OptionsError is returned when an option isn't valid.
+type OptionsError struct {
+	// Option is the name of the field within Options.
+	Option string
+	Err    error
+}
+
+func (e *OptionsError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Option, e.Err)
+}
+
+func (e *OptionsError) Unwrap() error {
+	return e.Err
+}
+
+// ConversionError is returned when entries can't be converted, including when
+// the conversion is cancelled.
+type ConversionError struct {
+	Err error
+}
+
+func (e *ConversionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// SinkError is returned when a sink fails to write.
+type SinkError struct {
+	// Sink is the name of the sink.
+	Sink string
+	Err  error
+}
+
+func (e *SinkError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Sink, e.Err)
+}
+
+func (e *SinkError) Unwrap() error {
+	return e.Err
+}
+
+// Convert reads, converts, and optionally writes entries. Cancelling ctx stops
+// it; nothing is written by sinks once it's been cancelled.
+func Convert(ctx context.Context, opts Options) (*Result, error) {
+	if len(opts.Inputs) == 0 {
+		return nil, ErrNoInputs
+	}
+	journalName, err := journalName(opts.JournalName)
+	if err != nil {
+		return nil, &OptionsError{Option: "JournalName", Err: err}
+	}
+	generators := defaultGenerators(opts.Generators)
+	readOpts := daylio.ReadOptions{AloneTimeScoring: opts.AloneTimeScoring}
+	sinks := withJournalName(opts.Sinks, journalName)
+	streaming := canStream(&opts, sinks)
+	var sources []daylio.Source
+	if !streaming {
+		if sources, err = readInputs(ctx, opts.Inputs, readOpts); err != nil {
+			return nil, err
+		}
+	}
+	writers, err := openSinks(sinks)
+	if err != nil {
+		return nil, err
+	}
+	aborted := true
+	defer func() {
+		if aborted {
+			for _, w := range writers {
+				if w != nil {
+					w.Abort()
+				}
+			}
+		}
+	}()
+	var entries []exporter.ConvertedEntry
+	var summary *exporter.RunSummary
+	if streaming {
+		summary, err = streamInput(ctx, opts.Inputs[0], readOpts, convertOptions(&opts, writers), generators)
+	} else {
+		entries, summary, err = exporter.ConvertSources(ctx, sources, convertOptions(&opts, writers), generators)
+	}
+	if err != nil {
+		return nil, conversionError(err)
+	}
+	result := Result{Entries: entries, Summary: *summary, JournalName: journalName}
+	if err := closeSinks(ctx, sinks, writers, &result); err != nil {
+		return nil, err
+	}
+	aborted = false
+	return &result, nil
+}
+
+// Read reads and merges entries like Convert, dropping those that don't match
+// Filter, but doesn't convert them, i.e. to compute statistics. Only Inputs,
+// ConflictPolicy, Filter, AloneTimeScoring, and Logger are used. The merge
+// report is nil unless there are several inputs.
+func Read(ctx context.Context, opts Options) ([]daylio.Entry, *daylio.MergeReport, error) {
+	if len(opts.Inputs) == 0 {
+		return nil, nil, ErrNoInputs
+	}
+	sources, err := readInputs(ctx, opts.Inputs, daylio.ReadOptions{AloneTimeScoring: opts.AloneTimeScoring})
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, report := exporter.MergeSources(sources, exporter.ConvertOptions{
+		ConflictPolicy: opts.ConflictPolicy,
+		Filter:         opts.Filter,
+		Logger:         opts.Logger,
+	})
+	return entries, report, nil
+}
+
+// canStream is true when entries can go straight from the input to sinks
+// without ever all being in memory: there's nothing to merge or summarize,
+// and every sink writes entries as they're converted.
+func canStream(opts *Options, sinks []exporter.Sink) bool {
+	if len(opts.Inputs) != 1 || opts.Summaries || opts.YearInReview != 0 || len(sinks) == 0 {
+		return false
+	}
+	for _, sink := range sinks {
+		if _, ok := sink.(exporter.StreamingSink); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// streamInput converts entries while they're read from in. See canStream.
+func streamInput(ctx context.Context, in Input, readOpts daylio.ReadOptions, opts exporter.ConvertOptions, generators types.DayOneGenerators) (*exporter.RunSummary, error) {
+	read := func(fn func(*daylio.Entry) error) error {
+		if err := readInput(in, readOpts, fn); err != nil {
+			return &InputError{Name: in.Name, Err: err}
+		}
+		return nil
+	}
+	return exporter.StreamEntries(ctx, in.Name, read, opts, generators)
+}
+
+func convertOptions(opts *Options, writers []exporter.EntryWriter) exporter.ConvertOptions {
+	return exporter.ConvertOptions{
+		ConflictPolicy:  opts.ConflictPolicy,
+		Filter:          opts.Filter,
+		Redactor:        opts.Redactor,
+		Summaries:       opts.Summaries,
+		YearInReview:    opts.YearInReview,
+		Palette:         opts.Palette,
+		HomeLocation:    opts.HomeLocation,
+		TimeZone:        opts.TimeZone,
+		Weather:         opts.Weather,
+		LocationHistory: opts.LocationHistory,
+		Device:          opts.Device,
+		Logger:          opts.Logger,
+		Pipeline: exporter.PipelineOptions{
+			Workers:  opts.Workers,
+			Progress: opts.Progress,
+			Writers:  nonNilWriters(writers),
+		},
+	}
+}
+
+// conversionError keeps errors from streaming sinks and streamed inputs as
+// they are.
+func conversionError(err error) error {
+	var sinkErr *SinkError
+	if errors.As(err, &sinkErr) {
+		return sinkErr
+	}
+	var inputErr *InputError
+	if errors.As(err, &inputErr) {
+		return inputErr
+	}
+	return &ConversionError{Err: err}
+}
+
+// closeSinks finishes streaming sinks and writes result's entries to the
+// others, in the order of sinks, adding what they wrote to result.
+func closeSinks(ctx context.Context, sinks []exporter.Sink, writers []exporter.EntryWriter, result *Result) error {
+	for idx, sink := range sinks {
+		if err := ctx.Err(); err != nil {
+			return &ConversionError{Err: err}
+		}
+		var out exporter.SinkResult
+		var err error
+		if writers[idx] != nil {
+			out, err = writers[idx].Close()
+			writers[idx] = nil
+		} else {
+			out, err = sink.Write(result.Entries, &result.Summary)
+		}
+		if err != nil {
+			return &SinkError{Sink: sink.Name(), Err: err}
+		}
+		result.Outputs = append(result.Outputs, out)
+	}
+	return nil
+}
+
+// withJournalName gives Day One sinks without a journal name the one that was
+// chosen for the conversion. opts.Sinks are left alone.
+func withJournalName(sinks []exporter.Sink, journalName string) []exporter.Sink {
+	out := []exporter.Sink{}
+	for _, sink := range sinks {
+		if s, ok := sink.(*exporter.DayOneSink); ok && s.JournalName == "" {
+			named := *s
+			named.JournalName = journalName
+			sink = &named
+		}
+		out = append(out, sink)
+	}
+	return out
+}
+
+// openSinks starts writing to streaming sinks, in the order of sinks. Other
+// sinks have no writer.
+func openSinks(sinks []exporter.Sink) ([]exporter.EntryWriter, error) {
+	writers := make([]exporter.EntryWriter, len(sinks))
+	for idx, sink := range sinks {
+		s, ok := sink.(exporter.StreamingSink)
+		if !ok {
+			continue
+		}
+		w, err := s.Open()
+		if err != nil {
+			for _, w := range writers[:idx] {
+				if w != nil {
+					w.Abort()
+				}
+			}
+			return nil, &SinkError{Sink: sink.Name(), Err: err}
+		}
+		writers[idx] = &sinkEntryWriter{EntryWriter: w, sink: sink.Name()}
+	}
+	return writers, nil
+}
+
+func nonNilWriters(writers []exporter.EntryWriter) []exporter.EntryWriter {
+	out := []exporter.EntryWriter{}
+	for _, w := range writers {
+		if w != nil {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// sinkEntryWriter says which sink failed to write an entry.
+type sinkEntryWriter struct {
+	exporter.EntryWriter
+	sink string
+}
+
+func (w *sinkEntryWriter) WriteEntry(entry *exporter.ConvertedEntry) error {
+	if err := w.EntryWriter.WriteEntry(entry); err != nil {
+		return &SinkError{Sink: w.sink, Err: err}
+	}
+	return nil
+}
+
+// defaultGenerators fills in whichever generators weren't provided.
+func defaultGenerators(g types.DayOneGenerators) types.DayOneGenerators {
+	defaults := types.DefaultDayOneGenerators()
+	if g.UUIDGenerator == nil {
+		g.UUIDGenerator = defaults.UUIDGenerator
+	}
+	if g.IDGenerator == nil {
+		g.IDGenerator = defaults.IDGenerator
+	}
+	if g.Timestamper == nil {
+		g.Timestamper = defaults.Timestamper
+	}
+	return g
+}
+
+func journalName(name string) (string, error) {
+	if name == "" {
+		return exporter.DEFAULT_DESTINATION_JOURNAL, nil
+	}
+	sanitized := exporter.SanitizeJournalName(name)
+	if sanitized == "" {
+		return "", fmt.Errorf("Not a valid journal name: '%s'", name)
+	}
+	return sanitized, nil
+}
+
+func readInputs(ctx context.Context, inputs []Input, readOpts daylio.ReadOptions) ([]daylio.Source, error) {
+	sources := []daylio.Source{}
+	for _, in := range inputs {
+		if err := ctx.Err(); err != nil {
+			return nil, &ConversionError{Err: err}
+		}
+		entries := []daylio.Entry{}
+		err := readInput(in, readOpts, func(e *daylio.Entry) error {
+			entries = append(entries, *e)
+			return nil
+		})
+		if err != nil {
+			return nil, &InputError{Name: in.Name, Err: err}
+		}
+		sources = append(sources, daylio.Source{Path: in.Name, Entries: entries})
+	}
+	return sources, nil
+}
+
+// readInput calls fn with every entry within an input, in order. Backups are
+// decoded as they're read; CSV exports are read all at once.
+func readInput(in Input, readOpts daylio.ReadOptions, fn func(*daylio.Entry) error) error {
+	switch {
+	case daylio.IsCSVFileName(in.Name):
+		var entries []daylio.Entry
+		var err error
+		if in.Data != nil {
+			entries, err = daylio.ReadEntries(in.Name, in.Data, readOpts)
+		} else {
+			entries, err = daylio.ReadEntriesFromFile(in.Path, readOpts)
+		}
+		if err != nil {
+			return err
+		}
+		for idx := range entries {
+			if err := fn(&entries[idx]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case in.Data != nil:
+		return daylio.ReadBackup(bytes.NewReader(in.Data), int64(len(in.Data)), readOpts, fn)
+	default:
+		return daylio.ReadEntriesFromBackupFile(in.Path, readOpts, fn)
+	}
+}
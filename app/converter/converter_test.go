@@ -0,0 +1,318 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"exporter/daylio"
+	"exporter/exporter"
+	"exporter/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCSV = `full_date,date,weekday,time,mood,activities,note_title,note
+2023-12-17,Dec 17,Sunday,08:00,good,home | reading,note title,note text 1
+2023-12-16,Dec 16,Saturday,09:30,rad,friends,,note text 2
+`
+
+// testBackup creates a Daylio backup in memory.
+func testBackup(t *testing.T) []byte {
+	backupJSON, err := json.Marshal(daylio.Backup{
+		Tags: []daylio.Tag{{ID: 1, Name: "No"}, {ID: 2, Name: "home"}},
+		DayEntries: []daylio.DayEntry{
+			{Note: "note text 1", TimeUNIX: 1702800000000, TagIDs: []int{1, 2}, Mood: 1},
+		},
+	})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("backup.daylio")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(base64.StdEncoding.EncodeToString(backupJSON)))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+type mockSink struct {
+	written []exporter.ConvertedEntry
+	err     error
+}
+
+func (s *mockSink) Name() string { return "mock" }
+
+func (s *mockSink) Write(entries []exporter.ConvertedEntry, summary *exporter.RunSummary) (exporter.SinkResult, error) {
+	s.written = entries
+	return &types.DayOneExportResult{}, s.err
+}
+
+func TestConvertingCSV(t *testing.T) {
+	sink := mockSink{}
+	got, err := Convert(context.Background(), Options{
+		Inputs:       []Input{BytesInput("daylio.csv", []byte(testCSV))},
+		TimeZone:     "America/Chicago",
+		HomeLocation: &types.DayOneEntryLocation{PlaceName: "Home"},
+		Device:       types.IPhoneDeviceProfile,
+		Sinks:        []exporter.Sink{&sink},
+	})
+	require.NoError(t, err)
+	require.Len(t, got.Entries, 2)
+	assert.Equal(t, "note title\n\nnote text 1", got.Entries[0].DayOne.Text)
+	assert.Equal(t, "Home", got.Entries[0].DayOne.Location.PlaceName)
+	assert.Equal(t, "America/Chicago", got.Entries[1].DayOne.TimeZone)
+	assert.Equal(t, "iPhone", got.Entries[1].DayOne.CreationDevice)
+	assert.Equal(t, []string{"daylio.csv"}, got.Summary.Sources)
+	assert.Equal(t, 2, got.Summary.EntryCount)
+	assert.Len(t, got.Outputs, 1)
+	assert.Equal(t, got.Entries, sink.written)
+}
+
+func TestConvertingBackup(t *testing.T) {
+	for _, scoring := range []bool{false, true} {
+		got, err := Convert(context.Background(), Options{
+			Inputs:           []Input{BytesInput("backup.daylio", testBackup(t))},
+			AloneTimeScoring: scoring,
+		})
+		require.NoError(t, err)
+		require.Len(t, got.Entries, 1)
+		if scoring {
+			assert.Contains(t, got.Entries[0].DayOne.Tags, "alone score: 0")
+		} else {
+			assert.Contains(t, got.Entries[0].DayOne.Tags, "No")
+		}
+	}
+}
+
+func TestConvertingFiles(t *testing.T) {
+	dir := t.TempDir()
+	backup := filepath.Join(dir, "backup.daylio")
+	csv := filepath.Join(dir, "daylio.csv")
+	require.NoError(t, os.WriteFile(backup, testBackup(t), 0o644))
+	require.NoError(t, os.WriteFile(csv, []byte(testCSV), 0o644))
+	got, err := Convert(context.Background(), Options{
+		Inputs: []Input{FileInput(backup), FileInput(csv)},
+	})
+	require.NoError(t, err)
+	assert.Len(t, got.Entries, 3)
+	require.NotNil(t, got.Summary.Merge)
+	assert.Equal(t, []string{backup, csv}, got.Summary.Sources)
+}
+
+func TestFileInputs(t *testing.T) {
+	got, err := FileInputs([]string{"backup.daylio", "daylio.csv"})
+	require.NoError(t, err)
+	assert.Equal(t, []Input{FileInput("backup.daylio"), FileInput("daylio.csv")}, got)
+}
+
+func TestReading(t *testing.T) {
+	from, err := time.Parse("2006-01-02", "2023-12-17")
+	require.NoError(t, err)
+	got, report, err := Read(context.Background(), Options{
+		Inputs: []Input{BytesInput("daylio.csv", []byte(testCSV))},
+		Filter: daylio.Filter{From: from},
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "note text 1", got[0].Note)
+	assert.Nil(t, report)
+
+	_, _, err = Read(context.Background(), Options{})
+	assert.ErrorIs(t, err, ErrNoInputs)
+}
+
+func TestConvertingIgnoresEnvironment(t *testing.T) {
+	t.Setenv("HOME_ADDRESS_JSON", `{"placeName": "Home"}`)
+	t.Setenv("TZ", "America/Chicago")
+	t.Setenv("JOURNAL_NAME", "Somewhere Else")
+	dir := t.TempDir()
+	t.Setenv("EXPORT_DIRECTORY", filepath.Join(dir, "exports"))
+	got, err := Convert(context.Background(), Options{
+		Inputs: []Input{BytesInput("daylio.csv", []byte(testCSV))},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, got.Entries[0].DayOne.Location)
+	assert.Equal(t, "UTC", got.Entries[0].DayOne.TimeZone)
+	assert.Equal(t, exporter.DEFAULT_DESTINATION_JOURNAL, got.JournalName)
+	assert.NoDirExists(t, filepath.Join(dir, "exports"))
+}
+
+func TestWritingDayOneZip(t *testing.T) {
+	got, err := Convert(context.Background(), Options{
+		Inputs:      []Input{BytesInput("daylio.csv", []byte(testCSV))},
+		JournalName: "Daylio/Old",
+	})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, got.WriteDayOneZip(&buf))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 1)
+	assert.Equal(t, "Daylio Old.json", zr.File[0].Name)
+	assert.Len(t, got.DayOneExport().Entries, 2)
+}
+
+func TestConvertingWritesDayOneSinksWithTheJournalName(t *testing.T) {
+	dir := t.TempDir()
+	sink := &exporter.DayOneSink{Directory: dir}
+	got, err := Convert(context.Background(), Options{
+		Inputs:      []Input{BytesInput("daylio.csv", []byte(testCSV))},
+		JournalName: "Old Phone",
+		Sinks:       []exporter.Sink{sink},
+	})
+	require.NoError(t, err)
+	require.Len(t, got.Outputs, 1)
+	result := got.Outputs[0].(*types.DayOneExportResult)
+	assert.Equal(t, "Old Phone", result.JournalName)
+	assert.Equal(t, dir, filepath.Dir(result.ZipFile))
+	assert.Empty(t, sink.JournalName)
+}
+
+func TestConvertingLogsOnlyToTheLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	var global bytes.Buffer
+	logrus.SetOutput(&global)
+	t.Cleanup(func() { logrus.SetOutput(os.Stderr) })
+	opts := Options{
+		Inputs: []Input{BytesInput("daylio.csv", []byte(testCSV))},
+		Filter: daylio.Filter{Activities: []string{"friends"}},
+	}
+	_, err := Convert(context.Background(), opts)
+	require.NoError(t, err)
+	opts.Logger = logger
+	_, err = Convert(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Skipping 1 entries that don't match filters")
+	assert.Empty(t, global.String())
+}
+
+func TestConvertingErrors(t *testing.T) {
+	_, err := Convert(context.Background(), Options{})
+	assert.ErrorIs(t, err, ErrNoInputs)
+
+	_, err = Convert(context.Background(), Options{
+		Inputs: []Input{FileInput(filepath.Join(t.TempDir(), "missing.daylio"))},
+	})
+	var inputErr *InputError
+	require.ErrorAs(t, err, &inputErr)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = Convert(context.Background(), Options{
+		Inputs:      []Input{BytesInput("daylio.csv", []byte(testCSV))},
+		JournalName: "..",
+	})
+	var optionsErr *OptionsError
+	require.ErrorAs(t, err, &optionsErr)
+	assert.Equal(t, "JournalName", optionsErr.Option)
+
+	_, err = Convert(context.Background(), Options{
+		Inputs: []Input{BytesInput("daylio.csv", []byte(testCSV))},
+		Sinks:  []exporter.Sink{&mockSink{err: errors.New("disk full")}},
+	})
+	var sinkErr *SinkError
+	require.ErrorAs(t, err, &sinkErr)
+	assert.Equal(t, "mock", sinkErr.Sink)
+	assert.EqualError(t, err, "mock: disk full")
+}
+
+func TestConvertingCanBeCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sink := mockSink{}
+	_, err := Convert(ctx, Options{
+		Inputs: []Input{BytesInput("daylio.csv", []byte(testCSV))},
+		Sinks:  []exporter.Sink{&sink},
+	})
+	var conversionErr *ConversionError
+	require.ErrorAs(t, err, &conversionErr)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, sink.written)
+}
+
+// mockStreamingSink fails once it's been given an entry, when told to.
+type mockStreamingSink struct {
+	mockSink
+	streamed []string
+	aborted  bool
+}
+
+func (s *mockStreamingSink) Open() (exporter.EntryWriter, error) { return s, nil }
+
+func (s *mockStreamingSink) WriteEntry(entry *exporter.ConvertedEntry) error {
+	s.streamed = append(s.streamed, entry.Source.FullDate)
+	return s.err
+}
+
+func (s *mockStreamingSink) Close() (exporter.SinkResult, error) {
+	return &types.DayOneExportResult{}, nil
+}
+
+func (s *mockStreamingSink) Abort() { s.aborted = true }
+
+func TestConvertingStreamsToStreamingSinks(t *testing.T) {
+	sink := mockStreamingSink{}
+	got, err := Convert(context.Background(), Options{
+		Inputs: []Input{BytesInput("daylio.csv", []byte(testCSV))},
+		Sinks:  []exporter.Sink{&sink},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2023-12-17", "2023-12-16"}, sink.streamed)
+	assert.Nil(t, sink.written)
+	assert.False(t, sink.aborted)
+	assert.Len(t, got.Outputs, 1)
+
+	failing := mockStreamingSink{mockSink: mockSink{err: errors.New("disk full")}}
+	other := mockStreamingSink{}
+	_, err = Convert(context.Background(), Options{
+		Inputs: []Input{BytesInput("daylio.csv", []byte(testCSV))},
+		Sinks:  []exporter.Sink{&other, &failing},
+	})
+	var sinkErr *SinkError
+	require.ErrorAs(t, err, &sinkErr)
+	assert.EqualError(t, err, "mock: disk full")
+	assert.True(t, failing.aborted)
+	assert.True(t, other.aborted)
+}
+
+func TestConvertingKeepsNoEntriesWhenEverySinkStreams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backup.daylio")
+	require.NoError(t, os.WriteFile(path, testBackup(t), 0o644))
+	sink := mockStreamingSink{}
+	got, err := Convert(context.Background(), Options{
+		Inputs: []Input{FileInput(path)},
+		Sinks:  []exporter.Sink{&sink},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2023-12-17"}, sink.streamed)
+	assert.Empty(t, got.Entries)
+	assert.Equal(t, 1, got.Summary.EntryCount)
+	assert.Equal(t, []string{path}, got.Summary.Sources)
+
+	// Summaries need every entry, so they're kept.
+	got, err = Convert(context.Background(), Options{
+		Inputs:    []Input{FileInput(path)},
+		Summaries: true,
+		Sinks:     []exporter.Sink{&mockStreamingSink{}},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, got.Entries)
+
+	_, err = Convert(context.Background(), Options{
+		Inputs: []Input{BytesInput("backup.daylio", []byte("not a backup"))},
+		Sinks:  []exporter.Sink{&mockStreamingSink{}},
+	})
+	var inputErr *InputError
+	require.ErrorAs(t, err, &inputErr)
+	assert.ErrorIs(t, err, daylio.ErrCorruptBackup)
+}
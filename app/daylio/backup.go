@@ -2,16 +2,12 @@ package daylio
 
 import (
 	"archive/zip"
-	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
-	"os"
 	"path/filepath"
-	"runtime"
 	"sort"
-	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -33,129 +29,74 @@ type BackupFileTraverser interface {
 	ListBackups() ([]fs.DirEntry, error)
 }
 
-type defaultBFT struct{}
-
-func (t *defaultBFT) Dir() string {
-	return filepath.Join(os.Getenv("HOME"), "Library", "Mobile Documents", "com~apple~CloudDocs", "Downloads")
+// ReadOptions configures how entries are read from Daylio backups.
+type ReadOptions struct {
+	// AloneTimeScoring replaces the "No", "A Little Bit", and "Yes!"
+	// activities with "alone score: N" tags.
+	AloneTimeScoring bool
 }
 
-func (t *defaultBFT) ListBackups() ([]fs.DirEntry, error) {
-	log.Debugf("Searching for Daylio backups here: %s", t.Dir())
-	fl, err := os.ReadDir(t.Dir())
-	if err != nil {
-		return nil, err
-	}
-	var daylioFiles []fs.DirEntry
-	for _, f := range fl {
-		if strings.Contains(f.Name(), "ios_backup") {
-			log.Debugf("Found backup file: %s", f.Name())
-			daylioFiles = append(daylioFiles, f)
-		}
-	}
-	return daylioFiles, nil
-}
+var errNoBackupJSON = classify(ErrCorruptBackup, errors.New("No Daylio backup JSONs found"))
 
-// GetEntriesFromBackupFile retrieves entries from a backup file.
-func GetEntriesFromBackupFile(providedFile string) ([]Entry, error) {
-	var fpath string
-	var err error
-	fpath, err = resolveDaylioBackupLocation(providedFile)
-	if err != nil {
-		return nil, err
-	}
-	json, err := extractJSONFromDaylioBackupFile(fpath)
-	if err != nil {
-		return nil, err
-	}
-	backup, err := parseBackupJSON(json)
-	if err != nil {
-		return nil, err
-	}
-	entries, err := simpleEntriesFromBackup(backup)
+// ReadBackup calls fn with every entry within a Daylio backup, which is a ZIP
+// file of size bytes.
+func ReadBackup(r io.ReaderAt, size int64, opts ReadOptions, fn func(*Entry) error) error {
+	reader, err := zip.NewReader(r, size)
 	if err != nil {
-		return nil, err
-	}
-	return entries, nil
-}
-
-func resolveDaylioBackupLocation(providedFile string) (string, error) {
-	if len(providedFile) > 0 {
-		return providedFile, nil
+		return classify(ErrCorruptBackup, err)
 	}
-	switch runtime.GOOS {
-	case "darwin":
-		return resolveDaylioBackupLocationMacOS(&defaultBFT{})
-	default:
-		return "", fmt.Errorf("This operating system type does not support finding Daylio backups automatically. Provide a path to a Daylio backup and try again.")
-	}
-}
-
-func simpleEntriesFromBackup(b *Backup) ([]Entry, error) {
-	el := []Entry{}
-	for _, d := range b.DayEntries {
-		e, err := dayEntryToEntry(&d, b.Tags)
-		if err != nil {
-			return nil, err
-		}
-		el = append(el, *e)
-	}
-	return el, nil
-}
-
-func extractJSONFromDaylioBackupFile(fpath string) ([]byte, error) {
-	reader, err := zip.OpenReader(fpath)
-	if err != nil {
-		return nil, err
-	}
-	defer reader.Close()
 	for _, f := range reader.File {
 		if f.FileHeader.Name == "backup.daylio" {
-			jsonEnc, err := getEncodedDaylioJSON(f)
-			if err != nil {
-				return nil, err
-			}
-			json, err := decodeDaylioJSON(jsonEnc)
-			if err != nil {
-				return nil, err
-			}
-			return json, nil
+			return ReadBackupEntries(func() (io.ReadCloser, error) {
+				fReader, err := f.Open()
+				if err != nil {
+					return nil, classify(ErrCorruptBackup, err)
+				}
+				return backupJSONFile{newBackupJSONReader(fReader), fReader}, nil
+			}, opts, fn)
 		}
 	}
-	return nil, fmt.Errorf("No Daylio backup JSONs found in file: %s", fpath)
+	return errNoBackupJSON
 }
 
-func decodeDaylioJSON(b []byte) ([]byte, error) {
-	return base64.StdEncoding.DecodeString(strings.ReplaceAll(string(b), "\r\n", ""))
+// backupJSONFile decodes "backup.daylio" as it's read from a Daylio backup.
+type backupJSONFile struct {
+	io.Reader
+	io.Closer
 }
 
-func getEncodedDaylioJSON(f *zip.File) ([]byte, error) {
-	fReader, err := f.Open()
-	if err != nil {
-		return nil, err
-	}
-	defer fReader.Close()
-	encJSON, err := io.ReadAll(fReader)
+func collectEntries(read func(fn func(*Entry) error) error) ([]Entry, error) {
+	entries := []Entry{}
+	err := read(func(e *Entry) error {
+		entries = append(entries, *e)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return encJSON, nil
+	return entries, nil
 }
 
-func parseBackupJSON(b []byte) (*Backup, error) {
-	var backup Backup
-	if err := json.Unmarshal(b, &backup); err != nil {
-		return nil, err
+// ResolveBackupLocation finds the latest Daylio backup when no file is
+// provided.
+func ResolveBackupLocation(providedFile string) (string, error) {
+	return resolveDaylioBackupLocation(providedFile)
+}
+
+func resolveDaylioBackupLocation(providedFile string) (string, error) {
+	if len(providedFile) > 0 {
+		return providedFile, nil
 	}
-	return &backup, nil
+	return findLatestBackup()
 }
 
 func resolveDaylioBackupLocationMacOS(t BackupFileTraverser) (string, error) {
 	backupList, err := t.ListBackups()
 	if err != nil {
-		return "", err
+		return "", classify(ErrNoBackupFound, err)
 	}
 	if len(backupList) == 0 {
-		return "", fmt.Errorf("No backups found in '%s'", t.Dir())
+		return "", classify(ErrNoBackupFound, fmt.Errorf("No backups found in '%s'", t.Dir()))
 	}
 	sort.Slice(backupList, func(i, j int) bool {
 		iInfo, err := backupList[i].Info()
@@ -171,26 +112,33 @@ func resolveDaylioBackupLocationMacOS(t BackupFileTraverser) (string, error) {
 	return filepath.Join(t.Dir(), backupList[0].Name()), nil
 }
 
-func dayEntryToEntry(d *DayEntry, tags []Tag) (*Entry, error) {
+func dayEntryToEntry(d *DayEntry, tags []Tag, groups []TagGroup, opts ReadOptions) (*Entry, error) {
 	log.Tracef("Simplifying Daylio day entry '%+v'", d)
-	activities, err := exportTagsFromIDs(d.TagIDs, tags)
+	details, err := exportActivitiesFromIDs(d.TagIDs, tags, groups, opts)
 	if err != nil {
 		return nil, err
 	}
+	activities := []string{}
+	for _, a := range details {
+		activities = append(activities, a.Name)
+	}
 	mood, err := resolveMood(d.Mood)
 	if err != nil {
 		return nil, err
 	}
 	eTime := time.UnixMilli(d.TimeUNIX).UTC()
 	entry := Entry{
-		FullDate:       eTime.Format("2006-01-02"),
-		Date:           eTime.Format("Jan 02"),
-		Weekday:        eTime.Format("Monday"),
-		Time:           eTime.Format("15:04"),
-		Mood:           mood,
-		ActivitiesList: append(activities, fmt.Sprintf("mood: %s", mood)),
-		NoteTitle:      d.Title,
-		Note:           d.Note,
+		FullDate:        eTime.Format("2006-01-02"),
+		Date:            eTime.Format("Jan 02"),
+		Weekday:         eTime.Format("Monday"),
+		Time:            eTime.Format("15:04"),
+		Mood:            mood,
+		ActivitiesList:  append(activities, fmt.Sprintf("mood: %s", mood)),
+		NoteTitle:       d.Title,
+		Note:            d.Note,
+		MoodID:          d.Mood,
+		ActivityDetails: details,
+		TimeZoneOffset:  d.TimeZoneOffset,
 	}
 	log.Tracef("generated entry: %+v", entry)
 	return &entry, nil
@@ -201,5 +149,5 @@ func resolveMood(mID int) (string, error) {
 	if ok {
 		return mName, nil
 	}
-	return "", fmt.Errorf("Not a valid Daylio mood ID: %d", mID)
+	return "", fmt.Errorf("%w: %d", ErrUnknownMood, mID)
 }
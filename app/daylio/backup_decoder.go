@@ -0,0 +1,174 @@
+package daylio
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// newBackupJSONReader decodes the base64 within a "backup.daylio" file as it's
+// read. Daylio wraps the base64 across lines, which the decoder skips over, so
+// the backup is never held in memory as a whole.
+func newBackupJSONReader(r io.Reader) io.Reader {
+	return base64.NewDecoder(base64.StdEncoding, r)
+}
+
+// BackupDecoder reads a Daylio backup's JSON one day entry at a time, so that
+// memory use doesn't grow with the size of the backup. Tags and tag groups are
+// kept, since they're small and needed to make sense of day entries.
+type BackupDecoder struct {
+	dec       *json.Decoder
+	Tags      []Tag
+	TagGroups []TagGroup
+}
+
+// NewBackupDecoder reads backup JSON from r.
+func NewBackupDecoder(r io.Reader) *BackupDecoder {
+	return &BackupDecoder{dec: json.NewDecoder(r)}
+}
+
+// Decode calls fn with every day entry in the backup, in order, and stops at
+// the first error. Day entries passed to fn aren't reused.
+func (d *BackupDecoder) Decode(fn func(*DayEntry) error) error {
+	return d.decode(fn)
+}
+
+// DecodeTags reads only the tags and tag groups, skipping over day entries.
+func (d *BackupDecoder) DecodeTags() error {
+	return d.decode(nil)
+}
+
+// decode skips day entries when fn is nil.
+func (d *BackupDecoder) decode(fn func(*DayEntry) error) error {
+	if err := d.expectDelim('{'); err != nil {
+		return err
+	}
+	for d.dec.More() {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return classify(ErrCorruptBackup, err)
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("%w: expected a key, got %v", ErrCorruptBackup, tok)
+		}
+		switch key {
+		case "tags":
+			err = d.decodeValue(&d.Tags)
+		case "tag_groups":
+			err = d.decodeValue(&d.TagGroups)
+		case "dayEntries":
+			if fn == nil {
+				err = d.skipValue()
+			} else {
+				err = d.decodeDayEntries(fn)
+			}
+		default:
+			err = d.skipValue()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return d.expectDelim('}')
+}
+
+func (d *BackupDecoder) decodeDayEntries(fn func(*DayEntry) error) error {
+	if err := d.expectDelim('['); err != nil {
+		return err
+	}
+	for d.dec.More() {
+		var entry DayEntry
+		if err := d.decodeValue(&entry); err != nil {
+			return err
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	return d.expectDelim(']')
+}
+
+func (d *BackupDecoder) decodeValue(v any) error {
+	if err := d.dec.Decode(v); err != nil {
+		return classify(ErrCorruptBackup, err)
+	}
+	return nil
+}
+
+// skipValue skips over values we don't use, like preferences and goals,
+// without decoding them.
+func (d *BackupDecoder) skipValue() error {
+	depth := 0
+	for {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return classify(ErrCorruptBackup, err)
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func (d *BackupDecoder) expectDelim(want json.Delim) error {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return classify(ErrCorruptBackup, err)
+	}
+	if tok != want {
+		return fmt.Errorf("%w: expected '%s', got %v", ErrCorruptBackup, want, tok)
+	}
+	return nil
+}
+
+// ReadBackupEntries streams the entries within backup JSON to fn, in order.
+// Day entries can't be converted until tags and tag groups are known, and
+// backups can list them in any order, so the JSON is read twice: once for
+// tags and tag groups, then again for day entries. open is called for each
+// read. Missing tags or tag groups are treated as empty.
+func ReadBackupEntries(open func() (io.ReadCloser, error), opts ReadOptions, fn func(*Entry) error) error {
+	tags, err := readBackupTags(open)
+	if err != nil {
+		return err
+	}
+	r, err := open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	read := 0
+	return NewBackupDecoder(r).Decode(func(d *DayEntry) error {
+		defer func() { read++ }()
+		e, err := dayEntryToEntry(d, tags.Tags, tags.TagGroups, opts)
+		if err != nil {
+			return newDayEntryError(read, d, err)
+		}
+		return fn(e)
+	})
+}
+
+func readBackupTags(open func() (io.ReadCloser, error)) (*BackupDecoder, error) {
+	r, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	dec := NewBackupDecoder(r)
+	if err := dec.DecodeTags(); err != nil {
+		return nil, err
+	}
+	return dec, nil
+}
+
+func newDayEntryError(idx int, d *DayEntry, err error) error {
+	return &EntryError{Index: idx, Date: time.UnixMilli(d.TimeUNIX).UTC().Format("2006-01-02"), Err: err}
+}
@@ -0,0 +1,218 @@
+package daylio
+
+import (
+	"archive/zip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestBackup writes backup JSON to a Daylio backup, wrapping its base64
+// across lines like Daylio does.
+func writeTestBackup(tb testing.TB, backupJSON []byte) string {
+	tb.Helper()
+	encoded := base64.StdEncoding.EncodeToString(backupJSON)
+	fpath := filepath.Join(tb.TempDir(), "backup.daylio.zip")
+	f, err := os.Create(fpath)
+	require.NoError(tb, err)
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("backup.daylio")
+	require.NoError(tb, err)
+	for len(encoded) > 0 {
+		n := min(76, len(encoded))
+		_, err = w.Write([]byte(encoded[:n] + "\r\n"))
+		require.NoError(tb, err)
+		encoded = encoded[n:]
+	}
+	require.NoError(tb, zw.Close())
+	return fpath
+}
+
+// openBackupJSON opens backup JSON that's already in memory.
+func openBackupJSON(backupJSON string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(backupJSON)), nil
+	}
+}
+
+// generateBackupJSON creates backup JSON with n day entries.
+func generateBackupJSON(tb testing.TB, n int) []byte {
+	tb.Helper()
+	data, err := json.Marshal(generateBackup(n))
+	require.NoError(tb, err)
+	return data
+}
+
+// generateBackupJSONWithTagsLast creates backup JSON with n day entries
+// followed by tags and tag groups, which Daylio sometimes does.
+func generateBackupJSONWithTagsLast(tb testing.TB, n int) []byte {
+	tb.Helper()
+	backup := generateBackup(n)
+	data, err := json.Marshal(struct {
+		DayEntries []DayEntry `json:"dayEntries"`
+		Tags       []Tag      `json:"tags"`
+		TagGroups  []TagGroup `json:"tag_groups"`
+	}{backup.DayEntries, backup.Tags, backup.TagGroups})
+	require.NoError(tb, err)
+	return data
+}
+
+func generateBackup(n int) Backup {
+	backup := Backup{
+		Tags:      []Tag{{ID: 1, Name: "friends", GroupID: 1}, {ID: 2, Name: "reading", GroupID: 2}},
+		TagGroups: []TagGroup{{ID: 1, Name: "Social"}, {ID: 2, Name: "Hobbies"}},
+	}
+	for idx := 0; idx < n; idx++ {
+		backup.DayEntries = append(backup.DayEntries, DayEntry{
+			Note:     fmt.Sprintf("note text %d: %s", idx, strings.Repeat("words ", 20)),
+			TimeUNIX: 1702800000000 - int64(idx)*86400000,
+			TagIDs:   []int{1, 2},
+			Mood:     idx%5 + 1,
+		})
+	}
+	return backup
+}
+
+func TestReadingEntriesFromBackupFile(t *testing.T) {
+	fpath := writeTestBackup(t, generateBackupJSON(t, 3))
+	got, err := GetEntriesFromBackupFile(fpath)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, "2023-12-17", got[0].FullDate)
+	assert.Equal(t, "2023-12-15", got[2].FullDate)
+	assert.Equal(t, []Activity{{Name: "friends", Group: "Social"}, {Name: "reading", Group: "Hobbies"}}, got[0].ActivityDetails)
+}
+
+func TestReadingBackupEntriesSkipsUnknownKeys(t *testing.T) {
+	backupJSON := `{
+  "version": 15,
+  "prefs": [{"key": "a", "value": [1, {"b": []}]}],
+  "tags": [{"id": 1, "name": "friends", "id_tag_group": 1}],
+  "dayEntries": [{"note": "note text 1", "datetime": 1702800000000, "mood": 1, "tags": [1]}],
+  "goals": {"nested": {"deeply": [[], {}]}},
+  "tag_groups": [{"id": 1, "name": "Social"}]
+}`
+	got := []Entry{}
+	err := ReadBackupEntries(openBackupJSON(backupJSON), ReadOptions{}, func(e *Entry) error {
+		got = append(got, *e)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "note text 1", got[0].Note)
+	// Tag groups come after the day entries here, which makes no difference.
+	assert.Equal(t, []Activity{{Name: "friends", Group: "Social"}}, got[0].ActivityDetails)
+}
+
+func TestReadingBackupEntriesBeforeTags(t *testing.T) {
+	backupJSON := `{
+  "dayEntries": [
+    {"note": "note text 1", "datetime": 1702800000000, "mood": 1, "tags": [1]},
+    {"note": "note text 2", "datetime": 1702713600000, "mood": 2, "tags": [1]}
+  ],
+  "tags": [{"id": 1, "name": "friends"}]
+}`
+	got := []Entry{}
+	err := ReadBackupEntries(openBackupJSON(backupJSON), ReadOptions{}, func(e *Entry) error {
+		got = append(got, *e)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "note text 1", got[0].Note)
+	assert.Equal(t, "note text 2", got[1].Note)
+	// There are no tag groups, so activities have none.
+	assert.Equal(t, []Activity{{Name: "friends"}}, got[1].ActivityDetails)
+}
+
+func TestReadingBackupEntriesStopsAtFirstError(t *testing.T) {
+	backupJSON := generateBackupJSON(t, 10)
+	count := 0
+	err := ReadBackupEntries(openBackupJSON(string(backupJSON)), ReadOptions{}, func(e *Entry) error {
+		count++
+		if count == 2 {
+			return fmt.Errorf("stop")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Equal(t, 2, count)
+}
+
+func TestReadingInvalidBackupJSON(t *testing.T) {
+	for name, backupJSON := range map[string]string{
+		"not an object":      `[]`,
+		"day entries object": `{"dayEntries": {}}`,
+		"truncated":          `{"tags": [], "dayEntries": [{"note": "a"`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := ReadBackupEntries(openBackupJSON(backupJSON), ReadOptions{}, func(e *Entry) error { return nil })
+			assert.ErrorIs(t, err, ErrCorruptBackup)
+		})
+	}
+}
+
+func TestReadingBackupFileWithoutBackupJSON(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "empty.zip")
+	f, err := os.Create(fpath)
+	require.NoError(t, err)
+	require.NoError(t, zip.NewWriter(f).Close())
+	require.NoError(t, f.Close())
+	_, err = GetEntriesFromBackupFile(fpath)
+	assert.ErrorContains(t, err, "No Daylio backup JSONs found")
+	assert.ErrorIs(t, err, ErrCorruptBackup)
+}
+
+// BenchmarkReadingBackupEntries shows that memory use stays flat as backups
+// grow: "peak-heap-B" should barely move between sizes, unlike "B/op".
+func BenchmarkReadingBackupEntries(b *testing.B) {
+	benchmarkReadingBackupEntries(b, generateBackupJSON)
+}
+
+// BenchmarkReadingBackupEntriesWithTagsLast shows that memory use stays flat
+// even when day entries come before the tags needed to convert them.
+func BenchmarkReadingBackupEntriesWithTagsLast(b *testing.B) {
+	benchmarkReadingBackupEntries(b, generateBackupJSONWithTagsLast)
+}
+
+func benchmarkReadingBackupEntries(b *testing.B, generate func(testing.TB, int) []byte) {
+	for _, n := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("entries=%d", n), func(b *testing.B) {
+			fpath := writeTestBackup(b, generate(b, n))
+			var stats runtime.MemStats
+			var peak uint64
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				runtime.ReadMemStats(&stats)
+				baseline := stats.HeapAlloc
+				count := 0
+				err := ReadEntriesFromBackupFile(fpath, ReadOptions{}, func(e *Entry) error {
+					count++
+					if count%1000 == 0 {
+						runtime.ReadMemStats(&stats)
+						if stats.HeapAlloc > baseline && stats.HeapAlloc-baseline > peak {
+							peak = stats.HeapAlloc - baseline
+						}
+					}
+					return nil
+				})
+				if err != nil || count != n {
+					b.Fatalf("read %d of %d entries: %v", count, n, err)
+				}
+			}
+			b.ReportMetric(float64(peak), "peak-heap-B")
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package daylio
 
 import (
+	"bytes"
+	"io"
 	"io/fs"
 	"os"
 	"testing"
@@ -168,9 +170,15 @@ func TestReadingBackupJSON(t *testing.T) {
 			},
 		},
 	}
-	got, err := parseBackupJSON(json)
+	dec := NewBackupDecoder(bytes.NewReader(json))
+	got := Backup{}
+	err = dec.Decode(func(d *DayEntry) error {
+		got.DayEntries = append(got.DayEntries, *d)
+		return nil
+	})
 	assert.NoError(t, err)
-	assert.Equal(t, &want, got)
+	got.Tags = dec.Tags
+	assert.Equal(t, want, got)
 }
 
 func TestDecodingBackupJSON(t *testing.T) {
@@ -178,24 +186,26 @@ func TestDecodingBackupJSON(t *testing.T) {
 	require.NoError(t, err)
 	data, err := os.ReadFile("./fixtures/daylio.json.encoded")
 	require.NoError(t, err)
-	got, err := decodeDaylioJSON(data)
+	got, err := io.ReadAll(newBackupJSONReader(bytes.NewReader(data)))
 	assert.NoError(t, err)
 	assert.Equal(t, want, got)
 }
 
 func TestDayEntryToSimpleEntry(t *testing.T) {
 	entry := DayEntry{
-		Note:     "note text 1",
-		Title:    "note title",
-		TimeUNIX: 1702800000000,
-		TagIDs:   []int{1, 2, 3},
-		Mood:     1,
+		Note:           "note text 1",
+		Title:          "note title",
+		TimeUNIX:       1702800000000,
+		TagIDs:         []int{1, 2, 3},
+		Mood:           1,
+		TimeZoneOffset: -21600000,
 	}
 	tags := []Tag{
-		{ID: 1, Name: "activity 1"},
-		{ID: 2, Name: "activity 2"},
+		{ID: 1, Name: "activity 1", GroupID: 1},
+		{ID: 2, Name: "activity 2", GroupID: 1},
 		{ID: 3, Name: "activity 3"},
 	}
+	groups := []TagGroup{{ID: 1, Name: "group 1"}}
 	want := Entry{
 		FullDate:       "2023-12-17",
 		Date:           "Dec 17",
@@ -205,8 +215,15 @@ func TestDayEntryToSimpleEntry(t *testing.T) {
 		ActivitiesList: []string{"activity 1", "activity 2", "activity 3", "mood: rad"},
 		NoteTitle:      "note title",
 		Note:           "note text 1",
+		MoodID:         1,
+		ActivityDetails: []Activity{
+			{Name: "activity 1", Group: "group 1"},
+			{Name: "activity 2", Group: "group 1"},
+			{Name: "activity 3"},
+		},
+		TimeZoneOffset: -21600000,
 	}
-	got, err := dayEntryToEntry(&entry, tags)
+	got, err := dayEntryToEntry(&entry, tags, groups, ReadOptions{})
 	assert.NoError(t, err)
 	assert.Equal(t, want, *got)
 }
@@ -1,20 +1,59 @@
 package daylio
 
 import (
-	"os"
+	encCSV "encoding/csv"
+	"io"
+	"strings"
 
 	csv "github.com/gocarina/gocsv"
 )
 
-func GetEntriesFromCSVFile(csvFile string) ([]Entry, error) {
-	f, err := os.OpenFile(csvFile, os.O_RDWR|os.O_CREATE, os.ModePerm)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
+// CSVColumns are the columns in a CSV exported from (or imported into) Daylio.
+var CSVColumns = []string{
+	"full_date",
+	"date",
+	"weekday",
+	"time",
+	"mood",
+	"activities",
+	"note_title",
+	"note",
+}
+
+// ReadCSVEntries reads entries from a CSV exported from Daylio.
+func ReadCSVEntries(r io.Reader) ([]Entry, error) {
 	var entries []Entry
-	if err := csv.UnmarshalFile(f, &entries); err != nil {
-		return nil, err
+	if err := csv.Unmarshal(r, &entries); err != nil {
+		return nil, classify(ErrCorruptCSV, err)
 	}
 	return entries, nil
 }
+
+// WriteEntriesToCSV writes entries in the format that Daylio exports and
+// imports CSVs in.
+func WriteEntriesToCSV(w io.Writer, entries []Entry) error {
+	cw := encCSV.NewWriter(w)
+	if err := cw.Write(CSVColumns); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		activities := e.Activities
+		if len(e.ActivitiesList) > 0 {
+			activities = strings.Join(e.ActivitiesList, " | ")
+		}
+		if err := cw.Write([]string{
+			e.FullDate,
+			e.Date,
+			e.Weekday,
+			e.Time,
+			e.Mood,
+			activities,
+			e.NoteTitle,
+			e.Note,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
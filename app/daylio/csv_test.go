@@ -0,0 +1,53 @@
+package daylio
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	csv "github.com/gocarina/gocsv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteEntriesToCSV(t *testing.T) {
+	entries := []Entry{
+		{
+			FullDate:       "2023-12-17",
+			Date:           "Dec 17",
+			Weekday:        "Sunday",
+			Time:           "08:00",
+			Mood:           "rad",
+			ActivitiesList: []string{"activity 1", "activity 2"},
+			NoteTitle:      "note title",
+			Note:           "note, with a comma",
+		},
+		{
+			FullDate:   "2023-12-16",
+			Date:       "Dec 16",
+			Weekday:    "Saturday",
+			Time:       "20:15",
+			Mood:       "bad",
+			Activities: "activity 3",
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, WriteEntriesToCSV(&buf, entries))
+	want := `full_date,date,weekday,time,mood,activities,note_title,note
+2023-12-17,Dec 17,Sunday,08:00,rad,activity 1 | activity 2,note title,"note, with a comma"
+2023-12-16,Dec 16,Saturday,20:15,bad,activity 3,,
+`
+	assert.Equal(t, want, buf.String())
+	var got []Entry
+	require.NoError(t, csv.UnmarshalBytes(buf.Bytes(), &got))
+	assert.Equal(t, "activity 1 | activity 2", got[0].Activities)
+	assert.Equal(t, "note, with a comma", got[0].Note)
+}
+
+func TestReadingMissingCSVFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mistyped.csv")
+	_, err := GetSourcesFromFiles([]string{path})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NoFileExists(t, path, "reading must never create the file")
+}
@@ -0,0 +1,64 @@
+package daylio
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoBackupFound means that no Daylio backup could be found to read.
+	ErrNoBackupFound = errors.New("No Daylio backup found")
+	// ErrCorruptBackup means that a file isn't a Daylio backup, or is damaged.
+	ErrCorruptBackup = errors.New("Not a valid Daylio backup")
+	// ErrCorruptCSV means that a file isn't a Daylio CSV export, or is
+	// damaged.
+	ErrCorruptCSV = errors.New("Not a valid Daylio CSV export")
+	// ErrUnknownMood means that an entry has a mood that Daylio doesn't have.
+	ErrUnknownMood = errors.New("Not a valid Daylio mood ID")
+	// ErrUnknownTag means that an entry has an activity that isn't within
+	// its backup.
+	ErrUnknownTag = errors.New("Tag ID not in Daylio backup")
+	// ErrInvalidOption means that an option, like a conflict policy or
+	// redaction config, isn't valid.
+	ErrInvalidOption = errors.New("Not a valid option")
+)
+
+// EntryError is a problem with a single entry.
+type EntryError struct {
+	// Index is the entry's position within the file it was read from, or
+	// within the entries being converted, starting at 0.
+	Index int
+	// Date is the entry's date (YYYY-MM-DD), if it's known.
+	Date string
+	Err  error
+}
+
+func (e *EntryError) Error() string {
+	if e.Date == "" {
+		return fmt.Sprintf("Entry %d: %s", e.Index, e.Err)
+	}
+	return fmt.Sprintf("Entry %d (%s): %s", e.Index, e.Date, e.Err)
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// classify gives an error the class of a sentinel error, like
+// ErrCorruptBackup, without changing its message.
+func classify(class error, err error) error {
+	return &classifiedError{class: class, err: err}
+}
+
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.class, e.err}
+}
@@ -0,0 +1,67 @@
+package daylio
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadingBackupWithUnknownMood(t *testing.T) {
+	backupJSON, err := json.Marshal(Backup{
+		DayEntries: []DayEntry{
+			{TimeUNIX: 1702800000000, Mood: 1},
+			{TimeUNIX: 1702713600000, Mood: 42},
+		},
+	})
+	require.NoError(t, err)
+	err = ReadBackupEntries(openBackupJSON(string(backupJSON)), ReadOptions{}, func(e *Entry) error { return nil })
+	var entryErr *EntryError
+	require.ErrorAs(t, err, &entryErr)
+	assert.Equal(t, 1, entryErr.Index)
+	assert.Equal(t, "2023-12-16", entryErr.Date)
+	assert.ErrorIs(t, err, ErrUnknownMood)
+	assert.EqualError(t, err, "Entry 1 (2023-12-16): Not a valid Daylio mood ID: 42")
+}
+
+func TestReadingBackupWithUnknownTag(t *testing.T) {
+	backupJSON, err := json.Marshal(Backup{
+		DayEntries: []DayEntry{{TimeUNIX: 1702800000000, Mood: 1, TagIDs: []int{7}}},
+	})
+	require.NoError(t, err)
+	err = ReadBackupEntries(openBackupJSON(string(backupJSON)), ReadOptions{}, func(e *Entry) error { return nil })
+	assert.ErrorIs(t, err, ErrUnknownTag)
+}
+
+func TestReadingCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	notZip := filepath.Join(dir, "backup.daylio")
+	require.NoError(t, os.WriteFile(notZip, []byte("not a zip"), 0o644))
+	_, err := ReadEntriesFromFile(notZip, ReadOptions{})
+	assert.ErrorIs(t, err, ErrCorruptBackup)
+
+	_, err = ReadEntries("daylio.csv", []byte("full_date,time\n\"unterminated"), ReadOptions{})
+	assert.ErrorIs(t, err, ErrCorruptCSV)
+
+	_, err = ReadEntriesFromFile(filepath.Join(dir, "missing.daylio"), ReadOptions{})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestInvalidOptions(t *testing.T) {
+	_, err := ParseConflictPolicy("newest")
+	assert.ErrorIs(t, err, ErrInvalidOption)
+	assert.EqualError(t, err, "Not a valid conflict policy: newest")
+
+	_, err = ReadRedactionConfig(strings.NewReader(`{"phrases": [""]}`))
+	assert.ErrorIs(t, err, ErrInvalidOption)
+}
+
+func TestEntryErrorWithoutDate(t *testing.T) {
+	err := &EntryError{Index: 3, Err: errors.New("boom")}
+	assert.EqualError(t, err, "Entry 3: boom")
+}
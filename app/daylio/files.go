@@ -0,0 +1,109 @@
+package daylio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// This file has everything within daylio that reads files or environment
+// variables. The rest of the package only works with readers and bytes, so
+// that it can run where there's no file system, like in a web browser.
+
+// ReadOptionsFromEnv enables every quirk that isn't disabled by an
+// environment variable, like NO_ALONE_TIME_SCORING.
+func ReadOptionsFromEnv() ReadOptions {
+	return ReadOptions{AloneTimeScoring: os.Getenv("NO_ALONE_TIME_SCORING") == ""}
+}
+
+// GetEntriesFromBackupFile retrieves entries from a backup file, with quirks
+// configured by environment variables.
+func GetEntriesFromBackupFile(providedFile string) ([]Entry, error) {
+	fpath, err := resolveDaylioBackupLocation(providedFile)
+	if err != nil {
+		return nil, err
+	}
+	return collectEntries(func(fn func(*Entry) error) error {
+		return ReadEntriesFromBackupFile(fpath, ReadOptionsFromEnv(), fn)
+	})
+}
+
+// ReadEntriesFromBackupFile calls fn with every entry within a backup file
+// while it's being decoded, so that large backups needn't fit in memory.
+func ReadEntriesFromBackupFile(fpath string, opts ReadOptions, fn func(*Entry) error) error {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := ReadBackup(f, info.Size(), opts, fn); err != nil {
+		if errors.Is(err, errNoBackupJSON) {
+			return classify(ErrCorruptBackup, fmt.Errorf("No Daylio backup JSONs found in file: %s", fpath))
+		}
+		return err
+	}
+	return nil
+}
+
+// GetEntriesFromCSVFile retrieves entries from a Daylio CSV export. Missing
+// files are an error rather than an empty export.
+func GetEntriesFromCSVFile(csvFile string) ([]Entry, error) {
+	f, err := os.Open(csvFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCSVEntries(f)
+}
+
+// GetEntriesFromFile retrieves entries from a Daylio backup or, if its name
+// ends with ".csv", a Daylio CSV export.
+func GetEntriesFromFile(providedFile string) ([]Entry, error) {
+	if IsCSVFileName(providedFile) {
+		return GetEntriesFromCSVFile(providedFile)
+	}
+	return GetEntriesFromBackupFile(providedFile)
+}
+
+// ReadEntriesFromFile is like GetEntriesFromFile, but it never reads
+// environment variables.
+func ReadEntriesFromFile(path string, opts ReadOptions) ([]Entry, error) {
+	if !IsCSVFileName(path) {
+		return collectEntries(func(fn func(*Entry) error) error {
+			return ReadEntriesFromBackupFile(path, opts, fn)
+		})
+	}
+	return GetEntriesFromCSVFile(path)
+}
+
+// GetSourcesFromFiles retrieves entries from several backups and CSV exports.
+// Tag IDs in each backup are resolved against that backup's own tags.
+func GetSourcesFromFiles(providedFiles []string) ([]Source, error) {
+	sources := []Source{}
+	for _, f := range providedFiles {
+		entries, err := GetEntriesFromFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		log.Debugf("Read %d entries from %s", len(entries), f)
+		sources = append(sources, Source{Path: f, Entries: entries})
+	}
+	return sources, nil
+}
+
+// ReadRedactionConfigFile reads a redaction config and builds a redactor from
+// it.
+func ReadRedactionConfigFile(path string) (*Redactor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadRedactionConfig(f)
+}
@@ -0,0 +1,89 @@
+package daylio
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Filter decides which entries are exported. The zero value keeps every
+// entry.
+type Filter struct {
+	// From and To limit entries to a range of dates, inclusive.
+	From time.Time
+	To   time.Time
+	// Activities keeps entries with at least one of these activities.
+	Activities []string
+	// ExcludeActivities drops entries with any of these activities.
+	ExcludeActivities []string
+}
+
+// Apply returns the entries that match the filter, in the same order.
+func (f *Filter) Apply(entries []Entry) []Entry {
+	out := []Entry{}
+	for idx := range entries {
+		if f.Matches(&entries[idx]) {
+			out = append(out, entries[idx])
+		}
+	}
+	return out
+}
+
+// Matches is true when an entry should be kept.
+func (f *Filter) Matches(e *Entry) bool {
+	if !f.From.IsZero() && e.FullDate < f.From.Format("2006-01-02") {
+		return false
+	}
+	if !f.To.IsZero() && e.FullDate > f.To.Format("2006-01-02") {
+		return false
+	}
+	activities := map[string]bool{}
+	for _, a := range e.ActivityNames() {
+		activities[strings.ToLower(a)] = true
+	}
+	for _, a := range f.ExcludeActivities {
+		if activities[strings.ToLower(a)] {
+			return false
+		}
+	}
+	if len(f.Activities) == 0 {
+		return true
+	}
+	for _, a := range f.Activities {
+		if activities[strings.ToLower(a)] {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFilter builds a filter from dates (YYYY-MM-DD) and activities
+// separated by commas, as they're typed into flags and forms. Empty values
+// don't filter anything.
+func ParseFilter(from, to, activities, excludeActivities string) (Filter, error) {
+	var filter Filter
+	var err error
+	if from != "" {
+		if filter.From, err = time.Parse("2006-01-02", from); err != nil {
+			return filter, classify(ErrInvalidOption, fmt.Errorf("Not a valid date for 'from': %s", from))
+		}
+	}
+	if to != "" {
+		if filter.To, err = time.Parse("2006-01-02", to); err != nil {
+			return filter, classify(ErrInvalidOption, fmt.Errorf("Not a valid date for 'to': %s", to))
+		}
+	}
+	filter.Activities = splitList(activities)
+	filter.ExcludeActivities = splitList(excludeActivities)
+	return filter, nil
+}
+
+func splitList(s string) []string {
+	out := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
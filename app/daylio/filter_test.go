@@ -0,0 +1,51 @@
+package daylio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilteringEntries(t *testing.T) {
+	entries := []Entry{
+		{FullDate: "2023-12-17", Activities: "work | gym"},
+		{FullDate: "2023-12-16", ActivitiesList: []string{"Friends", "mood: rad"}},
+		{FullDate: "2023-12-15", Activities: "work"},
+		{FullDate: "2023-11-30", Activities: "gym"},
+	}
+	dates := func(entries []Entry) []string {
+		out := []string{}
+		for _, e := range entries {
+			out = append(out, e.FullDate)
+		}
+		return out
+	}
+	f := Filter{}
+	assert.Len(t, f.Apply(entries), 4)
+	f = Filter{
+		From: mustParseDaylioEntryTime("2023-12-01"),
+		To:   mustParseDaylioEntryTime("2023-12-16"),
+	}
+	assert.Equal(t, []string{"2023-12-16", "2023-12-15"}, dates(f.Apply(entries)))
+	f = Filter{Activities: []string{"friends", "gym"}}
+	assert.Equal(t, []string{"2023-12-17", "2023-12-16", "2023-11-30"}, dates(f.Apply(entries)))
+	f = Filter{Activities: []string{"gym"}, ExcludeActivities: []string{"Work"}}
+	assert.Equal(t, []string{"2023-11-30"}, dates(f.Apply(entries)))
+}
+
+func TestParsingFilters(t *testing.T) {
+	f, err := ParseFilter("2023-12-01", "2023-12-16", " work, gym ,", "")
+	assert.NoError(t, err)
+	assert.Equal(t, Filter{
+		From:              mustParseDaylioEntryTime("2023-12-01"),
+		To:                mustParseDaylioEntryTime("2023-12-16"),
+		Activities:        []string{"work", "gym"},
+		ExcludeActivities: []string{},
+	}, f)
+
+	_, err = ParseFilter("soon", "", "", "")
+	assert.EqualError(t, err, "Not a valid date for 'from': soon")
+	assert.ErrorIs(t, err, ErrInvalidOption)
+	_, err = ParseFilter("", "12/16/2023", "", "")
+	assert.EqualError(t, err, "Not a valid date for 'to': 12/16/2023")
+}
@@ -0,0 +1,38 @@
+package daylio
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// findLatestBackup finds the latest backup within iCloud Drive, where Daylio
+// for iOS saves them.
+func findLatestBackup() (string, error) {
+	return resolveDaylioBackupLocationMacOS(&defaultBFT{})
+}
+
+type defaultBFT struct{}
+
+func (t *defaultBFT) Dir() string {
+	return filepath.Join(os.Getenv("HOME"), "Library", "Mobile Documents", "com~apple~CloudDocs", "Downloads")
+}
+
+func (t *defaultBFT) ListBackups() ([]fs.DirEntry, error) {
+	log.Debugf("Searching for Daylio backups here: %s", t.Dir())
+	fl, err := os.ReadDir(t.Dir())
+	if err != nil {
+		return nil, err
+	}
+	var daylioFiles []fs.DirEntry
+	for _, f := range fl {
+		if strings.Contains(f.Name(), "ios_backup") {
+			log.Debugf("Found backup file: %s", f.Name())
+			daylioFiles = append(daylioFiles, f)
+		}
+	}
+	return daylioFiles, nil
+}
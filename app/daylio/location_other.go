@@ -0,0 +1,10 @@
+//go:build !darwin
+
+package daylio
+
+import "fmt"
+
+// findLatestBackup isn't supported here, so backups must be provided.
+func findLatestBackup() (string, error) {
+	return "", classify(ErrNoBackupFound, fmt.Errorf("This operating system type does not support finding Daylio backups automatically. Provide a path to a Daylio backup and try again."))
+}
@@ -0,0 +1,228 @@
+package daylio
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ConflictPolicy decides what happens when entries from different files were
+// created at the same time but have different content.
+type ConflictPolicy string
+
+const (
+	// ConflictKeepAll keeps every conflicting entry.
+	ConflictKeepAll ConflictPolicy = "keep-all"
+	// ConflictKeepFirst keeps the entry from the file provided first.
+	ConflictKeepFirst ConflictPolicy = "first"
+	// ConflictKeepLast keeps the entry from the file provided last.
+	ConflictKeepLast ConflictPolicy = "last"
+	// ConflictKeepLongest keeps the entry with the longest note.
+	ConflictKeepLongest ConflictPolicy = "longest"
+)
+
+// Source is a list of entries along with the file they came from.
+type Source struct {
+	Path    string
+	Entries []Entry
+}
+
+// MergeReport describes where merged entries came from.
+type MergeReport struct {
+	Policy    ConflictPolicy
+	Sources   []MergeSourceReport
+	Conflicts []MergeConflict
+}
+
+// MergeSourceReport describes what was kept from a single file.
+type MergeSourceReport struct {
+	Path string
+	// Read is how many entries were in the file.
+	Read int
+	// Kept is how many entries from the file were kept.
+	Kept int
+	// Duplicates is how many entries were dropped because an identical entry
+	// was already kept from another file.
+	Duplicates int
+	// Conflicts is how many entries were dropped by the conflict policy.
+	Conflicts int
+}
+
+// MergeConflict is a time at which files had entries with different content.
+type MergeConflict struct {
+	// FullDate and Time are on the clock of the device that created the
+	// entries, like within a CSV export.
+	FullDate string
+	Time     string
+	// KeptFrom and DroppedFrom are file paths.
+	KeptFrom    []string
+	DroppedFrom []string
+}
+
+// ParseConflictPolicy turns a policy name into a ConflictPolicy.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(strings.ToLower(s)); p {
+	case ConflictKeepAll, ConflictKeepFirst, ConflictKeepLast, ConflictKeepLongest:
+		return p, nil
+	default:
+		return "", classify(ErrInvalidOption, fmt.Errorf("Not a valid conflict policy: %s", s))
+	}
+}
+
+// ReadEntries reads entries from the contents of a Daylio backup or, if its
+// name ends with ".csv", a Daylio CSV export.
+func ReadEntries(name string, data []byte, opts ReadOptions) ([]Entry, error) {
+	if IsCSVFileName(name) {
+		return ReadCSVEntries(bytes.NewReader(data))
+	}
+	return collectEntries(func(fn func(*Entry) error) error {
+		return ReadBackup(bytes.NewReader(data), int64(len(data)), opts, fn)
+	})
+}
+
+// IsCSVFileName is true for names of Daylio CSV exports rather than backups.
+func IsCSVFileName(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".csv")
+}
+
+type mergeCandidate struct {
+	entry  Entry
+	source int
+}
+
+// MergeEntries combines entries from several sources, newest first. Entries
+// created at the same time with the same content are only kept once; entries
+// created at the same time with different content are resolved with policy.
+// Times are compared on the clock of the device that created the entries,
+// since that's all CSV exports have.
+func MergeEntries(sources []Source, policy ConflictPolicy) ([]Entry, *MergeReport) {
+	r := MergeReport{Policy: policy}
+	timestamps := []string{}
+	byTimestamp := map[string][]mergeCandidate{}
+	for idx, src := range sources {
+		r.Sources = append(r.Sources, MergeSourceReport{Path: src.Path, Read: len(src.Entries)})
+		for _, e := range src.Entries {
+			ts := localTimestamp(&e)
+			if _, ok := byTimestamp[ts]; !ok {
+				timestamps = append(timestamps, ts)
+			}
+			byTimestamp[ts] = append(byTimestamp[ts], mergeCandidate{entry: e, source: idx})
+		}
+	}
+	sort.SliceStable(timestamps, func(i, j int) bool {
+		return timestamps[i] > timestamps[j]
+	})
+	merged := []Entry{}
+	for _, ts := range timestamps {
+		unique := dedupeCandidates(byTimestamp[ts], r.Sources)
+		kept := resolveConflicts(unique, policy)
+		if len(kept) < len(unique) {
+			date, clock, _ := strings.Cut(ts, " ")
+			c := MergeConflict{FullDate: date, Time: clock}
+			for _, k := range kept {
+				c.KeptFrom = append(c.KeptFrom, sources[k.source].Path)
+			}
+			for _, u := range unique {
+				if !containsCandidate(kept, u) {
+					r.Sources[u.source].Conflicts++
+					c.DroppedFrom = append(c.DroppedFrom, sources[u.source].Path)
+				}
+			}
+			r.Conflicts = append(r.Conflicts, c)
+		}
+		for _, k := range kept {
+			r.Sources[k.source].Kept++
+			merged = append(merged, k.entry)
+		}
+	}
+	return merged, &r
+}
+
+// localTimestamp is when an entry was created on the clock of the device that
+// created it. See Entry.LocalDateTime.
+func localTimestamp(e *Entry) string {
+	date, clock := e.LocalDateTime()
+	return date + " " + clock
+}
+
+// dedupeCandidates drops entries with the same content as an entry from an
+// earlier source. Identical entries from the same source are kept, since
+// Daylio allows them.
+func dedupeCandidates(candidates []mergeCandidate, reports []MergeSourceReport) []mergeCandidate {
+	unique := []mergeCandidate{}
+	seen := map[string]int{}
+	for _, c := range candidates {
+		key := entryContentKey(&c.entry)
+		if src, ok := seen[key]; ok && src != c.source {
+			reports[c.source].Duplicates++
+			continue
+		}
+		seen[key] = c.source
+		unique = append(unique, c)
+	}
+	return unique
+}
+
+func resolveConflicts(candidates []mergeCandidate, policy ConflictPolicy) []mergeCandidate {
+	sources := map[int]bool{}
+	for _, c := range candidates {
+		sources[c.source] = true
+	}
+	if len(sources) < 2 || policy == ConflictKeepAll || policy == "" {
+		return candidates
+	}
+	winner := candidates[0].source
+	for _, c := range candidates[1:] {
+		switch policy {
+		case ConflictKeepLast:
+			if c.source > winner {
+				winner = c.source
+			}
+		case ConflictKeepLongest:
+			if len(c.entry.NoteTitle)+len(c.entry.Note) > longestNote(candidates, winner) {
+				winner = c.source
+			}
+		}
+	}
+	kept := []mergeCandidate{}
+	for _, c := range candidates {
+		if c.source == winner {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+func longestNote(candidates []mergeCandidate, source int) int {
+	longest := 0
+	for _, c := range candidates {
+		if l := len(c.entry.NoteTitle) + len(c.entry.Note); c.source == source && l > longest {
+			longest = l
+		}
+	}
+	return longest
+}
+
+func containsCandidate(candidates []mergeCandidate, c mergeCandidate) bool {
+	for _, k := range candidates {
+		if k.source == c.source && entryContentKey(&k.entry) == entryContentKey(&c.entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// entryContentKey identifies an entry by its content regardless of whether it
+// came from a backup or a CSV export.
+func entryContentKey(e *Entry) string {
+	names := e.ActivityNames()
+	sort.Strings(names)
+	mood := e.Mood
+	// Backups call this mood "ok" while CSV exports call it "meh".
+	if mood == "ok" {
+		mood = DaylioMoodMeh
+	}
+	return strings.Join([]string{mood, strings.Join(names, "|"), e.NoteTitle, e.Note}, "\x00")
+}
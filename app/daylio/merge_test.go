@@ -0,0 +1,114 @@
+package daylio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockMergeSources() []Source {
+	return []Source{
+		{
+			Path: "old_phone.daylio",
+			Entries: []Entry{
+				{FullDate: "2023-12-16", Time: "08:00", Mood: "ok", ActivitiesList: []string{"activity 2", "activity 1", "mood: ok"}, Note: "same"},
+				{FullDate: "2023-12-15", Time: "08:00", Mood: "rad", Note: "short"},
+				{FullDate: "2023-12-14", Time: "08:00", Mood: "bad", Note: "only in the old phone"},
+			},
+		},
+		{
+			Path: "new_phone.csv",
+			Entries: []Entry{
+				{FullDate: "2023-12-17", Time: "08:00", Mood: "good", Note: "only in the new phone"},
+				{FullDate: "2023-12-16", Time: "08:00", Mood: "meh", Activities: "activity 1 | activity 2", Note: "same"},
+				{FullDate: "2023-12-15", Time: "08:00", Mood: "rad", Note: "a much longer note"},
+			},
+		},
+	}
+}
+
+func TestMergeEntriesKeepAll(t *testing.T) {
+	got, report := MergeEntries(mockMergeSources(), ConflictKeepAll)
+	notes := []string{}
+	for _, e := range got {
+		notes = append(notes, e.Note)
+	}
+	assert.Equal(t, []string{
+		"only in the new phone",
+		"same",
+		"short",
+		"a much longer note",
+		"only in the old phone",
+	}, notes)
+	assert.Equal(t, []MergeSourceReport{
+		{Path: "old_phone.daylio", Read: 3, Kept: 3},
+		{Path: "new_phone.csv", Read: 3, Kept: 2, Duplicates: 1},
+	}, report.Sources)
+	assert.Empty(t, report.Conflicts)
+}
+
+func TestMergeEntriesConflictPolicies(t *testing.T) {
+	for policy, want := range map[ConflictPolicy]string{
+		ConflictKeepFirst:   "short",
+		ConflictKeepLast:    "a much longer note",
+		ConflictKeepLongest: "a much longer note",
+	} {
+		got, report := MergeEntries(mockMergeSources(), policy)
+		assert.Len(t, got, 4, policy)
+		assert.Equal(t, want, got[2].Note, policy)
+		assert.Len(t, report.Conflicts, 1, policy)
+		assert.Equal(t, "2023-12-15", report.Conflicts[0].FullDate, policy)
+	}
+	_, report := MergeEntries(mockMergeSources(), ConflictKeepFirst)
+	assert.Equal(t, MergeConflict{
+		FullDate:    "2023-12-15",
+		Time:        "08:00",
+		KeptFrom:    []string{"old_phone.daylio"},
+		DroppedFrom: []string{"new_phone.csv"},
+	}, report.Conflicts[0])
+	assert.Equal(t, 1, report.Sources[1].Conflicts)
+}
+
+func TestMergeEntriesKeepsIdenticalEntriesFromTheSameFile(t *testing.T) {
+	sources := []Source{{Path: "a", Entries: []Entry{
+		{FullDate: "2023-12-15", Time: "08:00", Mood: "rad"},
+		{FullDate: "2023-12-15", Time: "08:00", Mood: "rad"},
+	}}}
+	got, _ := MergeEntries(sources, ConflictKeepFirst)
+	assert.Len(t, got, 2)
+}
+
+func TestMergeEntriesComparesBackupTimesOnTheLocalClock(t *testing.T) {
+	sources := []Source{
+		{Path: "backup.daylio", Entries: []Entry{
+			// 20:00 and 21:00 on Dec 16 in Chicago (UTC-6).
+			{FullDate: "2023-12-17", Time: "02:00", Mood: "rad", Note: "same", TimeZoneOffset: -21600000},
+			{FullDate: "2023-12-17", Time: "03:00", Mood: "rad", Note: "short", TimeZoneOffset: -21600000},
+		}},
+		{Path: "daylio.csv", Entries: []Entry{
+			{FullDate: "2023-12-16", Time: "20:00", Mood: "rad", Note: "same"},
+			{FullDate: "2023-12-16", Time: "21:00", Mood: "rad", Note: "a much longer note"},
+		}},
+	}
+	got, report := MergeEntries(sources, ConflictKeepLongest)
+	require.Len(t, got, 2)
+	assert.Equal(t, "a much longer note", got[0].Note)
+	assert.Equal(t, "same", got[1].Note)
+	assert.Equal(t, "2023-12-17", got[1].FullDate)
+	assert.Equal(t, 1, report.Sources[1].Duplicates)
+	assert.Equal(t, []MergeConflict{{
+		FullDate:    "2023-12-16",
+		Time:        "21:00",
+		KeptFrom:    []string{"daylio.csv"},
+		DroppedFrom: []string{"backup.daylio"},
+	}}, report.Conflicts)
+}
+
+func TestParseConflictPolicy(t *testing.T) {
+	got, err := ParseConflictPolicy("Longest")
+	assert.NoError(t, err)
+	assert.Equal(t, ConflictKeepLongest, got)
+	_, err = ParseConflictPolicy("coin-flip")
+	assert.EqualError(t, err, "Not a valid conflict policy: coin-flip")
+}
@@ -0,0 +1,240 @@
+package daylio
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DEFAULT_REDACTION_MASK replaces redacted names, phrases, and patterns.
+	DEFAULT_REDACTION_MASK = "[redacted]"
+	// REDACTED_ENTRY_NOTE replaces the note of entries with a redacted
+	// activity.
+	REDACTED_ENTRY_NOTE = "This entry was redacted."
+)
+
+// RedactionKind is what a redaction rule matches.
+type RedactionKind string
+
+const (
+	// RedactPhrase masks a name or phrase, ignoring case.
+	RedactPhrase RedactionKind = "phrase"
+	// RedactPattern masks text matching a regular expression.
+	RedactPattern RedactionKind = "pattern"
+	// RedactActivity replaces the note of entries with an activity.
+	RedactActivity RedactionKind = "activity"
+)
+
+// builtInPatterns can be used by name within redaction configs.
+var builtInPatterns = map[string]string{
+	"email": `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	"phone": `\+?\(?\d{1,4}\)?[\s.\-]?\(?\d{2,4}\)?[\s.\-]?\d{3,4}[\s.\-]?\d{3,4}`,
+}
+
+// RedactionRule is a single thing to redact.
+type RedactionRule struct {
+	// Name identifies the rule within audits. Phrases are numbered instead of
+	// named after themselves so that audits don't give them away.
+	Name  string
+	Kind  RedactionKind
+	Value string
+	re    *regexp.Regexp
+}
+
+// Redactor removes private details from entries before they're exported.
+type Redactor struct {
+	Rules []RedactionRule
+	// Mask replaces redacted text. Defaults to DEFAULT_REDACTION_MASK.
+	Mask string
+}
+
+// RedactionConfig is the YAML file describing what to redact, i.e.
+//
+//	phrases: [Alice, Acme Corp]
+//	patterns:
+//	  - name: email
+//	  - name: account number
+//	    regex: '\bACC-\d+\b'
+//	activities: [therapy]
+type RedactionConfig struct {
+	Phrases    []string                 `yaml:"phrases"`
+	Patterns   []RedactionPatternConfig `yaml:"patterns"`
+	Activities []string                 `yaml:"activities"`
+	Mask       string                   `yaml:"mask"`
+}
+
+// RedactionPatternConfig is a regular expression to redact. Built-in patterns
+// ("email" and "phone") only need a name.
+type RedactionPatternConfig struct {
+	Name  string `yaml:"name"`
+	Regex string `yaml:"regex"`
+}
+
+// RedactionCount is how many redactions a rule made.
+type RedactionCount struct {
+	Rule       string
+	Kind       RedactionKind
+	Redactions int
+}
+
+// RedactionReport is an audit of a redaction run. It never contains the text
+// that was redacted.
+type RedactionReport struct {
+	Rules []RedactionCount
+	// Entries is how many entries had anything redacted.
+	Entries int
+}
+
+// Total is how many redactions were made across every rule.
+func (r *RedactionReport) Total() int {
+	total := 0
+	for _, c := range r.Rules {
+		total += c.Redactions
+	}
+	return total
+}
+
+// ReadRedactionConfig reads a YAML redaction config and builds a redactor
+// from it.
+func ReadRedactionConfig(r io.Reader) (*Redactor, error) {
+	var cfg RedactionConfig
+	if err := yaml.NewDecoder(r).Decode(&cfg); err != nil && err != io.EOF {
+		return nil, classify(ErrInvalidOption, fmt.Errorf("Not a valid redaction config: %w", err))
+	}
+	return NewRedactor(&cfg)
+}
+
+// NewRedactor compiles the rules within a redaction config.
+func NewRedactor(cfg *RedactionConfig) (*Redactor, error) {
+	rd := Redactor{Mask: cfg.Mask}
+	for _, a := range cfg.Activities {
+		rd.Rules = append(rd.Rules, RedactionRule{Name: a, Kind: RedactActivity, Value: a})
+	}
+	for idx, p := range cfg.Phrases {
+		if strings.TrimSpace(p) == "" {
+			return nil, classify(ErrInvalidOption, fmt.Errorf("Redacted phrases can't be empty"))
+		}
+		rd.Rules = append(rd.Rules, RedactionRule{
+			Name:  fmt.Sprintf("phrase %d", idx+1),
+			Kind:  RedactPhrase,
+			Value: p,
+			re:    phraseRegexp(p),
+		})
+	}
+	for _, p := range cfg.Patterns {
+		regex := p.Regex
+		if regex == "" {
+			builtIn, ok := builtInPatterns[p.Name]
+			if !ok {
+				return nil, classify(ErrInvalidOption, fmt.Errorf("Not a built-in redaction pattern: %s", p.Name))
+			}
+			regex = builtIn
+		}
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, classify(ErrInvalidOption, fmt.Errorf("Not a valid redaction pattern for '%s': %w", p.Name, err))
+		}
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("pattern %d", len(rd.Rules)+1)
+		}
+		rd.Rules = append(rd.Rules, RedactionRule{Name: name, Kind: RedactPattern, Value: regex, re: re})
+	}
+	return &rd, nil
+}
+
+// phraseRegexp matches a phrase as a whole word, ignoring case, so that "Al"
+// doesn't mask part of "Always".
+func phraseRegexp(phrase string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(phrase)
+	if r, _ := utf8.DecodeRuneInString(phrase); isWordRune(r) {
+		expr = `\b` + expr
+	}
+	if r, _ := utf8.DecodeLastRuneInString(phrase); isWordRune(r) {
+		expr = expr + `\b`
+	}
+	return regexp.MustCompile("(?i)" + expr)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Apply returns redacted copies of entries, in the same order, along with an
+// audit of the redactions.
+func (rd *Redactor) Apply(entries []Entry) ([]Entry, *RedactionReport) {
+	report := rd.NewReport()
+	out := make([]Entry, len(entries))
+	for idx := range entries {
+		out[idx] = entries[idx]
+		rd.Redact(&out[idx], report)
+	}
+	return out, report
+}
+
+// NewReport is an empty audit of redactions by rd, for Redact to add to.
+func (rd *Redactor) NewReport() *RedactionReport {
+	report := RedactionReport{Rules: make([]RedactionCount, len(rd.Rules))}
+	for idx, rule := range rd.Rules {
+		report.Rules[idx] = RedactionCount{Rule: rule.Name, Kind: rule.Kind}
+	}
+	return &report
+}
+
+// Redact redacts a single entry in place, i.e. while entries are being read,
+// and adds what was redacted to report.
+func (rd *Redactor) Redact(e *Entry, report *RedactionReport) {
+	mask := rd.Mask
+	if mask == "" {
+		mask = DEFAULT_REDACTION_MASK
+	}
+	redacted := false
+	if ruleIdx := rd.matchingActivityRule(e); ruleIdx >= 0 {
+		report.Rules[ruleIdx].Redactions++
+		e.NoteTitle = ""
+		e.Note = REDACTED_ENTRY_NOTE
+		redacted = true
+	} else {
+		for ruleIdx, rule := range rd.Rules {
+			if rule.re == nil {
+				continue
+			}
+			var n int
+			e.NoteTitle, n = replaceAllCounting(rule.re, e.NoteTitle, mask)
+			report.Rules[ruleIdx].Redactions += n
+			redacted = redacted || n > 0
+			e.Note, n = replaceAllCounting(rule.re, e.Note, mask)
+			report.Rules[ruleIdx].Redactions += n
+			redacted = redacted || n > 0
+		}
+	}
+	if redacted {
+		report.Entries++
+	}
+}
+
+func (rd *Redactor) matchingActivityRule(e *Entry) int {
+	for _, a := range e.ActivityNames() {
+		for idx, rule := range rd.Rules {
+			if rule.Kind == RedactActivity && strings.EqualFold(rule.Value, a) {
+				return idx
+			}
+		}
+	}
+	return -1
+}
+
+func replaceAllCounting(re *regexp.Regexp, s string, mask string) (string, int) {
+	n := 0
+	out := re.ReplaceAllStringFunc(s, func(string) string {
+		n++
+		return mask
+	})
+	return out, n
+}
@@ -0,0 +1,107 @@
+package daylio
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustCreateRedactor(t *testing.T, config string) *Redactor {
+	rd, err := ReadRedactionConfig(strings.NewReader(config))
+	require.NoError(t, err)
+	return rd
+}
+
+func TestRedactPhrases(t *testing.T) {
+	rd := mustCreateRedactor(t, `
+phrases: [Alice, "Acme Corp"]
+`)
+	got, report := rd.Apply([]Entry{
+		{Mood: "good", NoteTitle: "Lunch with alice", Note: "Alice and I talked about ACME CORP. Always fun."},
+		{Mood: "bad", Note: "Nothing to hide"},
+	})
+	assert.Equal(t, "Lunch with [redacted]", got[0].NoteTitle)
+	assert.Equal(t, "[redacted] and I talked about [redacted]. Always fun.", got[0].Note)
+	assert.Equal(t, "Nothing to hide", got[1].Note)
+	assert.Equal(t, []RedactionCount{
+		{Rule: "phrase 1", Kind: RedactPhrase, Redactions: 2},
+		{Rule: "phrase 2", Kind: RedactPhrase, Redactions: 1},
+	}, report.Rules)
+	assert.Equal(t, 1, report.Entries)
+	assert.Equal(t, 3, report.Total())
+}
+
+func TestRedactPatterns(t *testing.T) {
+	rd := mustCreateRedactor(t, `
+patterns:
+  - name: email
+  - name: phone
+  - name: account
+    regex: 'ACC-\d+'
+mask: "***"
+`)
+	got, report := rd.Apply([]Entry{
+		{Note: "Email me at jane.doe@example.com or call +1 (555) 123-4567 about ACC-42."},
+	})
+	assert.Equal(t, "Email me at *** or call *** about ***.", got[0].Note)
+	assert.Equal(t, []RedactionCount{
+		{Rule: "email", Kind: RedactPattern, Redactions: 1},
+		{Rule: "phone", Kind: RedactPattern, Redactions: 1},
+		{Rule: "account", Kind: RedactPattern, Redactions: 1},
+	}, report.Rules)
+}
+
+func TestRedactActivities(t *testing.T) {
+	rd := mustCreateRedactor(t, `
+phrases: [Alice]
+activities: [Therapy]
+`)
+	entries := []Entry{
+		{FullDate: "2023-12-17", Time: "08:00", Mood: "bad", Activities: "therapy | work", NoteTitle: "Session", Note: "Talked about Alice"},
+	}
+	got, report := rd.Apply(entries)
+	assert.Equal(t, Entry{
+		FullDate:   "2023-12-17",
+		Time:       "08:00",
+		Mood:       "bad",
+		Activities: "therapy | work",
+		Note:       REDACTED_ENTRY_NOTE,
+	}, got[0])
+	assert.Equal(t, "Talked about Alice", entries[0].Note, "the original entries must not change")
+	assert.Equal(t, 1, report.Rules[0].Redactions)
+	assert.Equal(t, 0, report.Rules[1].Redactions)
+	assert.Equal(t, 1, report.Entries)
+}
+
+func TestRedactionReportDoesNotContainRedactedText(t *testing.T) {
+	rd := mustCreateRedactor(t, `
+phrases: [Alice]
+patterns:
+  - name: email
+`)
+	_, report := rd.Apply([]Entry{{Note: "Alice alice@example.com"}})
+	for _, c := range report.Rules {
+		assert.NotContains(t, strings.ToLower(c.Rule), "alice")
+	}
+}
+
+func TestReadRedactionConfigErrors(t *testing.T) {
+	for name, config := range map[string]string{
+		"unknown built-in": "patterns:\n  - name: passport\n",
+		"bad regex":        "patterns:\n  - name: broken\n    regex: '('\n",
+		"empty phrase":     "phrases: ['']\n",
+		"not yaml":         "phrases: [",
+	} {
+		_, err := ReadRedactionConfig(strings.NewReader(config))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestEmptyRedactionConfig(t *testing.T) {
+	rd := mustCreateRedactor(t, "")
+	got, report := rd.Apply([]Entry{{Note: "unchanged"}})
+	assert.Equal(t, "unchanged", got[0].Note)
+	assert.Equal(t, 0, report.Entries)
+}
@@ -2,14 +2,13 @@ package daylio
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
 
 // we're assuming that no Daylio entries have tag IDs that aren't in the backup.
-func exportTagsFromIDs(ids []int, tags []Tag) ([]string, error) {
+func exportTagsFromIDs(ids []int, tags []Tag, opts ReadOptions) ([]string, error) {
 	tagNames := []string{}
 	tagHT := map[int]string{}
 	for _, tag := range tags {
@@ -20,20 +19,41 @@ func exportTagsFromIDs(ids []int, tags []Tag) ([]string, error) {
 		log.Tracef("looking for tag id: '%d'", id)
 		tagName, ok := tagHT[id]
 		if !ok {
-			return []string{}, fmt.Errorf("tag ID not in Daylio backup: %d", id)
+			return []string{}, fmt.Errorf("%w: %d", ErrUnknownTag, id)
 		}
-		if score := generateAloneTimeScore(tagName); score != "" {
-			tagName = score
+		if opts.AloneTimeScoring {
+			if score := generateAloneTimeScore(tagName); score != "" {
+				tagName = score
+			}
 		}
 		tagNames = append(tagNames, tagName)
 	}
 	return tagNames, nil
 }
 
-func generateAloneTimeScore(s string) string {
-	if os.Getenv("NO_ALONE_TIME_SCORING") != "" {
-		return ""
+// exportActivitiesFromIDs is like exportTagsFromIDs, but it also resolves the
+// group each tag belongs to.
+func exportActivitiesFromIDs(ids []int, tags []Tag, groups []TagGroup, opts ReadOptions) ([]Activity, error) {
+	names, err := exportTagsFromIDs(ids, tags, opts)
+	if err != nil {
+		return nil, err
+	}
+	groupHT := map[int]string{}
+	for _, group := range groups {
+		groupHT[group.ID] = group.Name
+	}
+	tagGroupHT := map[int]string{}
+	for _, tag := range tags {
+		tagGroupHT[tag.ID] = groupHT[tag.GroupID]
 	}
+	activities := []Activity{}
+	for idx, id := range ids {
+		activities = append(activities, Activity{Name: names[idx], Group: tagGroupHT[id]})
+	}
+	return activities, nil
+}
+
+func generateAloneTimeScore(s string) string {
 	var score int
 	switch strings.ToLower(s) {
 	case "no":
@@ -13,7 +13,7 @@ func TestExportTagsNoQuirks(t *testing.T) {
 		{ID: 2, Name: "activity 3"},
 	}
 	want := []string{"activity 1", "activity 2", "activity 3"}
-	got, err := exportTagsFromIDs([]int{0, 1, 2}, tags)
+	got, err := exportTagsFromIDs([]int{0, 1, 2}, tags, ReadOptions{AloneTimeScoring: true})
 	assert.NoError(t, err)
 	assert.Equal(t, want, got)
 }
@@ -29,13 +29,12 @@ func TestExportTagsAloneTimeQuirk(t *testing.T) {
 		"alone score: 1",
 		"alone score: 2",
 	}
-	got, err := exportTagsFromIDs([]int{0, 1, 2}, tags)
+	got, err := exportTagsFromIDs([]int{0, 1, 2}, tags, ReadOptions{AloneTimeScoring: true})
 	assert.NoError(t, err)
 	assert.Equal(t, want, got)
 }
 
 func TestExportTagsAloneTimeQuirkWhenDisabled(t *testing.T) {
-	t.Setenv("NO_ALONE_TIME_SCORING", "anything")
 	tags := []Tag{
 		{ID: 0, Name: "No"},
 		{ID: 1, Name: "A Little Bit"},
@@ -46,7 +45,14 @@ func TestExportTagsAloneTimeQuirkWhenDisabled(t *testing.T) {
 		"A Little Bit",
 		"Yes!",
 	}
-	got, err := exportTagsFromIDs([]int{0, 1, 2}, tags)
+	got, err := exportTagsFromIDs([]int{0, 1, 2}, tags, ReadOptions{})
 	assert.NoError(t, err)
 	assert.Equal(t, want, got)
 }
+
+func TestReadOptionsFromEnv(t *testing.T) {
+	t.Setenv("NO_ALONE_TIME_SCORING", "")
+	assert.Equal(t, ReadOptions{AloneTimeScoring: true}, ReadOptionsFromEnv())
+	t.Setenv("NO_ALONE_TIME_SCORING", "anything")
+	assert.Equal(t, ReadOptions{}, ReadOptionsFromEnv())
+}
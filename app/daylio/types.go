@@ -1,5 +1,10 @@
 package daylio
 
+import (
+	"strings"
+	"time"
+)
+
 const (
 	DaylioMoodRad   = "rad"
 	DaylioMoodGood  = "good"
@@ -8,6 +13,39 @@ const (
 	DaylioMoodAwful = "awful"
 )
 
+// MoodScore converts a Daylio mood into a score between 1 (awful) and 5
+// (rad). Unknown moods score 0.
+func MoodScore(mood string) int {
+	switch mood {
+	case DaylioMoodRad:
+		return 5
+	case DaylioMoodGood:
+		return 4
+	case DaylioMoodMeh, "ok":
+		return 3
+	case DaylioMoodBad:
+		return 2
+	case DaylioMoodAwful:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// MoodIDFromName finds the Daylio mood ID for a mood name. Unknown moods
+// return 0.
+func MoodIDFromName(mood string) int {
+	if mood == DaylioMoodMeh {
+		mood = "ok"
+	}
+	for id, name := range DaylioMoodIDs {
+		if name == mood {
+			return id
+		}
+	}
+	return 0
+}
+
 // Entry is an entry in Daylio.
 type Entry struct {
 	FullDate       string   `csv:"full_date"`
@@ -19,6 +57,59 @@ type Entry struct {
 	ActivitiesList []string `csv:"activities_list,omitempty"`
 	NoteTitle      string   `csv:"note_title"`
 	Note           string   `csv:"note"`
+	// MoodID, ActivityDetails and TimeZoneOffset are only available for
+	// entries read from a backup.
+	MoodID          int        `csv:"-"`
+	ActivityDetails []Activity `csv:"-"`
+	// TimeZoneOffset is the offset from UTC, in milliseconds, of the device
+	// that created the entry.
+	TimeZoneOffset int64 `csv:"-"`
+}
+
+// ActivityNames lists an entry's activities whether it came from a backup
+// (ActivitiesList) or from a CSV export (Activities), without the "mood: "
+// activity added to entries from backups.
+func (e *Entry) ActivityNames() []string {
+	activities := e.ActivitiesList
+	if len(activities) == 0 {
+		activities = strings.Split(strings.ReplaceAll(e.Activities, " | ", "|"), "|")
+	}
+	names := []string{}
+	for _, a := range activities {
+		if a == "" || strings.HasPrefix(a, "mood: ") {
+			continue
+		}
+		names = append(names, a)
+	}
+	return names
+}
+
+// LocalDateTime is when an entry was created on the clock of the device that
+// created it, as "2006-01-02" and "15:04". Entries from backups are in UTC and
+// know their offset; entries from CSV exports are already local.
+func (e *Entry) LocalDateTime() (string, string) {
+	if e.TimeZoneOffset == 0 {
+		return e.FullDate, e.Time
+	}
+	t, err := time.Parse("2006-01-02 15:04", e.FullDate+" "+e.Time)
+	if err != nil {
+		return e.FullDate, e.Time
+	}
+	local := t.Add(time.Duration(e.TimeZoneOffset) * time.Millisecond)
+	return local.Format("2006-01-02"), local.Format("15:04")
+}
+
+// LocalDate is the day an entry was created on, on the clock of the device
+// that created it. See LocalDateTime.
+func (e *Entry) LocalDate() string {
+	date, _ := e.LocalDateTime()
+	return date
+}
+
+// Activity is an activity within an entry along with the group it belongs to.
+type Activity struct {
+	Name  string
+	Group string
 }
 
 // Backup is a full Daylio backup that can be used to restore Daylio from
@@ -26,6 +117,7 @@ type Entry struct {
 type Backup struct {
 	// Tags is a JSON representation of Daylio's tags database.
 	Tags       []Tag      `json:"tags"`
+	TagGroups  []TagGroup `json:"tag_groups"`
 	DayEntries []DayEntry `json:"dayEntries"`
 }
 
@@ -33,6 +125,13 @@ type Backup struct {
 // in the actual backup than exposed here; ID and Name are the only
 // ones we care about.
 type Tag struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	GroupID int    `json:"id_tag_group"`
+}
+
+// TagGroup is a group of tags within Daylio, like "Social" or "Hobbies".
+type TagGroup struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
 }
@@ -44,4 +143,6 @@ type DayEntry struct {
 	TimeUNIX int64  `json:"datetime"`
 	TagIDs   []int  `json:"tags"`
 	Mood     int    `json:"mood"`
+	// TimeZoneOffset is in milliseconds.
+	TimeZoneOffset int64 `json:"timeZoneOffset"`
 }
@@ -0,0 +1,140 @@
+package dayone
+
+import (
+	"exporter/daylio"
+	"exporter/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultMood is used for entries without a "mood: " tag, since Daylio won't
+// import entries without one.
+const DefaultMood = daylio.DaylioMoodMeh
+
+// Entries generated by the exporter, like summaries, are tagged with one of
+// these. They have no Daylio counterpart.
+const (
+	SUMMARY_TAG        = "daylio-summary"
+	YEAR_IN_REVIEW_TAG = "daylio-year-in-review"
+)
+
+// IsGeneratedEntry is true for entries generated by the exporter.
+func IsGeneratedEntry(e *types.DayOneEntry) bool {
+	for _, tag := range e.Tags {
+		if tag == SUMMARY_TAG || tag == YEAR_IN_REVIEW_TAG {
+			return true
+		}
+	}
+	return false
+}
+
+// aloneTimeActivities reverses the alone time scoring quirk.
+var aloneTimeActivities = map[int]string{
+	0: "No",
+	1: "A Little Bit",
+	2: "Yes!",
+}
+
+// ToDaylioEntries converts Day One entries back into Daylio entries, newest
+// first like Daylio's own CSV exports.
+//
+// Entries keep the time of day they were written at in their own time zone.
+// Set utc to keep times in UTC instead, i.e. for entries this exporter made
+// from a Daylio CSV export, which stores Daylio's time as UTC.
+func ToDaylioEntries(exports []types.DayOneExport, utc bool) []daylio.Entry {
+	type timedEntry struct {
+		t     time.Time
+		entry daylio.Entry
+	}
+	timed := []timedEntry{}
+	for _, export := range exports {
+		for idx := range export.Entries {
+			if IsGeneratedEntry(&export.Entries[idx]) {
+				continue
+			}
+			t := entryTime(&export.Entries[idx], utc)
+			timed = append(timed, timedEntry{t: t, entry: toDaylioEntry(&export.Entries[idx], t)})
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool {
+		return timed[i].t.After(timed[j].t)
+	})
+	entries := []daylio.Entry{}
+	for _, te := range timed {
+		entries = append(entries, te.entry)
+	}
+	return entries
+}
+
+func entryTime(e *types.DayOneEntry, utc bool) time.Time {
+	t := time.Time(e.CreationDate).UTC()
+	if utc || e.TimeZone == "" {
+		return t
+	}
+	loc, err := time.LoadLocation(e.TimeZone)
+	if err != nil {
+		log.Warningf("Unknown time zone '%s' in entry %s; using UTC", e.TimeZone, e.UUID)
+		return t
+	}
+	return t.In(loc)
+}
+
+func toDaylioEntry(e *types.DayOneEntry, t time.Time) daylio.Entry {
+	mood := DefaultMood
+	activities := []string{}
+	for _, tag := range e.Tags {
+		if m, ok := strings.CutPrefix(tag, "mood: "); ok {
+			mood = m
+			continue
+		}
+		if score, ok := strings.CutPrefix(tag, "alone score: "); ok {
+			if n, err := strconv.Atoi(score); err == nil {
+				if a, ok := aloneTimeActivities[n]; ok {
+					tag = a
+				}
+			}
+		}
+		if tag != "" {
+			activities = append(activities, tag)
+		}
+	}
+	title, note := splitText(e.Text)
+	return daylio.Entry{
+		FullDate:   t.Format("2006-01-02"),
+		Date:       t.Format("Jan 02"),
+		Weekday:    t.Format("Monday"),
+		Time:       t.Format("15:04"),
+		Mood:       mood,
+		Activities: strings.Join(activities, " | "),
+		NoteTitle:  title,
+		Note:       note,
+	}
+}
+
+// splitText reverses createDayOneText: the first paragraph is the title,
+// unless it's the "Note" placeholder. Day One adds Markdown headers and
+// escapes to entries written in the app, so those are removed.
+func splitText(text string) (string, string) {
+	title, note, found := strings.Cut(text, "\n\n")
+	if !found {
+		return "", unescapeMarkdown(text)
+	}
+	// A first paragraph spanning several lines isn't a title.
+	if strings.Contains(title, "\n") {
+		return "", unescapeMarkdown(text)
+	}
+	title = strings.TrimSpace(strings.TrimLeft(title, "#"))
+	if title == "Note" {
+		title = ""
+	}
+	return unescapeMarkdown(title), unescapeMarkdown(note)
+}
+
+func unescapeMarkdown(s string) string {
+	r := strings.NewReplacer(`\.`, ".", `\-`, "-", `\!`, "!", `\(`, "(", `\)`, ")", `\#`, "#", `\*`, "*", `\_`, "_")
+	return r.Replace(s)
+}
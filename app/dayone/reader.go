@@ -0,0 +1,62 @@
+package dayone
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"exporter/types"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ReadExportZip reads every journal within a Day One JSON ZIP file, whether it
+// was made by this exporter or by Day One itself.
+func ReadExportZip(fpath string) ([]types.DayOneExport, error) {
+	reader, err := zip.OpenReader(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return readExportZip(&reader.Reader, fpath)
+}
+
+func readExportZip(reader *zip.Reader, fpath string) ([]types.DayOneExport, error) {
+	exports := []types.DayOneExport{}
+	for _, f := range reader.File {
+		// Day One puts journals at the root of the ZIP and attachments in
+		// folders like "photos/".
+		if path.Dir(f.Name) != "." || !strings.HasSuffix(strings.ToLower(f.Name), ".json") {
+			continue
+		}
+		log.Debugf("Reading Day One journal: %s", f.Name)
+		export, err := readJournal(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		exports = append(exports, *export)
+	}
+	if len(exports) == 0 {
+		return nil, fmt.Errorf("No Day One journals found in file: %s", fpath)
+	}
+	return exports, nil
+}
+
+func readJournal(f *zip.File) (*types.DayOneExport, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return parseJournal(r)
+}
+
+func parseJournal(r io.Reader) (*types.DayOneExport, error) {
+	var export types.DayOneExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
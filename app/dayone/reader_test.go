@@ -0,0 +1,117 @@
+package dayone
+
+import (
+	"archive/zip"
+	"bytes"
+	"exporter/daylio"
+	"exporter/types"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustCreateMockZip(t *testing.T, files map[string][]byte) *zip.Reader {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return r
+}
+
+func TestReadingExportZip(t *testing.T) {
+	journal, err := os.ReadFile("./fixtures/journal.json")
+	require.NoError(t, err)
+	r := mustCreateMockZip(t, map[string][]byte{
+		"From Daylio.json":     journal,
+		"photos/8E7C2B1A.jpeg": []byte("not really a photo"),
+	})
+	got, err := readExportZip(r, "export.zip")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Entries, 3)
+	assert.Equal(t, []string{"activity 1", "activity 2", "mood: rad"}, got[0].Entries[1].Tags)
+}
+
+func TestReadingExportZipWithoutJournals(t *testing.T) {
+	r := mustCreateMockZip(t, map[string][]byte{"photos/1.jpeg": []byte("")})
+	_, err := readExportZip(r, "export.zip")
+	assert.EqualError(t, err, "No Day One journals found in file: export.zip")
+}
+
+func TestToDaylioEntriesInUTC(t *testing.T) {
+	f, err := os.Open("./fixtures/journal.json")
+	require.NoError(t, err)
+	defer f.Close()
+	export, err := parseJournal(f)
+	require.NoError(t, err)
+	want := []daylio.Entry{
+		{
+			FullDate:   "2023-12-18",
+			Date:       "Dec 18",
+			Weekday:    "Monday",
+			Time:       "03:30",
+			Mood:       DefaultMood,
+			Activities: "",
+			NoteTitle:  "Written in Day One",
+			Note:       "Went to the store. It was fine!",
+		},
+		{
+			FullDate:   "2023-12-17",
+			Date:       "Dec 17",
+			Weekday:    "Sunday",
+			Time:       "08:00",
+			Mood:       "rad",
+			Activities: "activity 1 | activity 2",
+			NoteTitle:  "note title",
+			Note:       "note text 1",
+		},
+		{
+			FullDate:   "2023-12-16",
+			Date:       "Dec 16",
+			Weekday:    "Saturday",
+			Time:       "08:00",
+			Mood:       "good",
+			Activities: "activity 1 | A Little Bit",
+			NoteTitle:  "",
+			Note:       "note text 2",
+		},
+	}
+	got := ToDaylioEntries([]types.DayOneExport{*export}, true)
+	assert.Equal(t, want, got)
+}
+
+func TestToDaylioEntriesInEntryTimeZoneByDefault(t *testing.T) {
+	f, err := os.Open("./fixtures/journal.json")
+	require.NoError(t, err)
+	defer f.Close()
+	export, err := parseJournal(f)
+	require.NoError(t, err)
+	got := ToDaylioEntries([]types.DayOneExport{*export}, false)
+	require.Len(t, got, 3)
+	assert.Equal(t, "2023-12-17", got[0].FullDate)
+	assert.Equal(t, "Sunday", got[0].Weekday)
+	assert.Equal(t, "21:30", got[0].Time)
+}
+
+func TestToDaylioEntriesSkipsGeneratedEntries(t *testing.T) {
+	f, err := os.Open("./fixtures/journal.json")
+	require.NoError(t, err)
+	defer f.Close()
+	export, err := parseJournal(f)
+	require.NoError(t, err)
+	export.Entries = append(export.Entries, types.DayOneEntry{
+		Tags: []string{SUMMARY_TAG},
+		Text: "Daylio Summary: December 2023",
+	})
+	got := ToDaylioEntries([]types.DayOneExport{*export}, false)
+	assert.Len(t, got, 3)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"exporter/daylio"
+	"exporter/exporter"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Exit codes, so that scripts can tell failures apart. They're documented in
+// EXIT_CODES_USAGE and the README.
+const (
+	EXIT_FAILURE        = 1
+	EXIT_DISCREPANCIES  = 2
+	EXIT_USAGE          = 3
+	EXIT_NO_BACKUP      = 4
+	EXIT_CORRUPT_INPUT  = 5
+	EXIT_INVALID_ENTRY  = 6
+	EXIT_WRITE_FAILED   = 7
+	EXIT_DECRYPT_FAILED = 8
+	EXIT_CANCELLED      = 130
+)
+
+const EXIT_CODES_USAGE = `EXIT CODES
+
+	0			Success.
+	1			Something else went wrong.
+	2			"verify" found discrepancies.
+	3			A flag or argument isn't valid.
+	4			No Daylio backup was found, or a file doesn't exist.
+	5			A Daylio backup or CSV export is damaged.
+	6			An entry couldn't be converted, i.e. its mood or
+						activity isn't in the backup.
+	7			An export couldn't be written, i.e. the disk is full.
+	8			An encrypted export couldn't be decrypted.
+	130			The export was interrupted.
+`
+
+// usageError is a flag or argument that isn't valid.
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *usageError) Unwrap() error {
+	return e.err
+}
+
+// usage marks an error as caused by a flag or argument.
+func usage(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &usageError{err: err}
+}
+
+// exitCode maps an error to the exit code documented for its class.
+func exitCode(err error) int {
+	var writeErr *exporter.WriteError
+	var settingsErr *exporter.SettingsError
+	var usageErr *usageError
+	var entryErr *daylio.EntryError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return EXIT_CANCELLED
+	case errors.As(err, &writeErr):
+		return EXIT_WRITE_FAILED
+	case errors.Is(err, exporter.ErrDecryptionFailed), errors.Is(err, exporter.ErrNotEncrypted):
+		return EXIT_DECRYPT_FAILED
+	case errors.As(err, &usageErr), errors.As(err, &settingsErr), errors.Is(err, daylio.ErrInvalidOption):
+		return EXIT_USAGE
+	case errors.Is(err, daylio.ErrNoBackupFound), errors.Is(err, os.ErrNotExist):
+		return EXIT_NO_BACKUP
+	case errors.Is(err, daylio.ErrCorruptBackup), errors.Is(err, daylio.ErrCorruptCSV):
+		return EXIT_CORRUPT_INPUT
+	case errors.As(err, &entryErr), errors.Is(err, daylio.ErrUnknownMood), errors.Is(err, daylio.ErrUnknownTag):
+		return EXIT_INVALID_ENTRY
+	default:
+		return EXIT_FAILURE
+	}
+}
+
+// fail logs what went wrong and exits with the error's exit code.
+func fail(doing string, err error) {
+	log.Errorf("Something went wrong while %s: %s", doing, err.Error())
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"errors"
+	"exporter/types"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DeviceProfileConfig is the YAML file describing a custom device profile,
+// i.e.
+//
+//	base: iphone
+//	device: Alice's iPhone
+//	osVersion: "17.4"
+//
+// Anything left out is taken from the base profile, which defaults to "mac".
+type DeviceProfileConfig struct {
+	Base                string `yaml:"base"`
+	types.DeviceProfile `yaml:",inline"`
+}
+
+// DeviceProfileNames lists the built-in device profiles.
+func DeviceProfileNames() []string {
+	names := []string{}
+	for name := range types.DeviceProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseDeviceProfile looks up a built-in device profile by name, ignoring
+// case.
+func ParseDeviceProfile(name string) (types.DeviceProfile, error) {
+	if p, ok := types.DeviceProfiles[strings.ToLower(name)]; ok {
+		return p, nil
+	}
+	return types.DeviceProfile{}, invalidOption(fmt.Errorf("Not a valid device profile: %s; use one of %s or a config file", name, strings.Join(DeviceProfileNames(), ", ")))
+}
+
+// ResolveDeviceProfile is the built-in device profile with that name, or else
+// the custom profile within the config file at that path.
+func ResolveDeviceProfile(nameOrPath string) (types.DeviceProfile, error) {
+	if p, ok := types.DeviceProfiles[strings.ToLower(nameOrPath)]; ok {
+		return p, nil
+	}
+	p, err := ReadDeviceProfileFile(nameOrPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return ParseDeviceProfile(nameOrPath)
+	}
+	return p, err
+}
+
+// ReadDeviceProfileFile reads a custom device profile from a YAML config file.
+func ReadDeviceProfileFile(path string) (types.DeviceProfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return types.DeviceProfile{}, err
+	}
+	defer f.Close()
+	return ReadDeviceProfile(f)
+}
+
+// ReadDeviceProfile reads a custom device profile from YAML.
+func ReadDeviceProfile(r io.Reader) (types.DeviceProfile, error) {
+	var cfg DeviceProfileConfig
+	if err := yaml.NewDecoder(r).Decode(&cfg); err != nil && err != io.EOF {
+		return types.DeviceProfile{}, invalidOption(fmt.Errorf("Not a valid device profile config: %w", err))
+	}
+	return NewDeviceProfile(&cfg)
+}
+
+// NewDeviceProfile fills in a custom device profile from its base profile.
+func NewDeviceProfile(cfg *DeviceProfileConfig) (types.DeviceProfile, error) {
+	p := types.MacDeviceProfile
+	if cfg.Base != "" {
+		base, err := ParseDeviceProfile(cfg.Base)
+		if err != nil {
+			return types.DeviceProfile{}, err
+		}
+		p = base
+	}
+	p.Name = "custom"
+	for _, field := range []struct {
+		to   *string
+		from string
+	}{
+		{&p.Name, cfg.Name},
+		{&p.Device, cfg.Device},
+		{&p.DeviceType, cfg.DeviceType},
+		{&p.DeviceModel, cfg.DeviceModel},
+		{&p.OSName, cfg.OSName},
+		{&p.OSVersion, cfg.OSVersion},
+		{&p.Platform, cfg.Platform},
+	} {
+		if field.from != "" {
+			*field.to = field.from
+		}
+	}
+	if cfg.PlatformVersion < 0 {
+		return types.DeviceProfile{}, invalidOption(fmt.Errorf("Not a valid platform version: %d", cfg.PlatformVersion))
+	}
+	if cfg.PlatformVersion != 0 {
+		p.PlatformVersion = cfg.PlatformVersion
+	}
+	return p, nil
+}
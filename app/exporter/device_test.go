@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	"exporter/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDeviceProfile(t *testing.T) {
+	got, err := ParseDeviceProfile("iPhone")
+	require.NoError(t, err)
+	assert.Equal(t, types.IPhoneDeviceProfile, got)
+	_, err = ParseDeviceProfile("toaster")
+	assert.ErrorIs(t, err, ErrInvalidOption)
+	assert.ErrorContains(t, err, "android, daylio, iphone, mac")
+}
+
+func TestReadDeviceProfile(t *testing.T) {
+	got, err := ReadDeviceProfile(strings.NewReader("base: iphone\ndevice: Alice's iPhone\nosVersion: \"17.4\"\n"))
+	require.NoError(t, err)
+	want := types.IPhoneDeviceProfile
+	want.Name, want.Device, want.OSVersion = "custom", "Alice's iPhone", "17.4"
+	assert.Equal(t, want, got)
+}
+
+func TestReadDeviceProfileDefaultsToMac(t *testing.T) {
+	got, err := ReadDeviceProfile(strings.NewReader("name: work laptop\ndeviceModel: Mac15,3\nplatformVersion: 1600\n"))
+	require.NoError(t, err)
+	want := types.MacDeviceProfile
+	want.Name, want.DeviceModel, want.PlatformVersion = "work laptop", "Mac15,3", 1600
+	assert.Equal(t, want, got)
+}
+
+func TestReadDeviceProfileFailures(t *testing.T) {
+	for name, config := range map[string]string{
+		"not YAML":          "device: [",
+		"unknown base":      "base: toaster",
+		"negative platform": "platformVersion: -1",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadDeviceProfile(strings.NewReader(config))
+			assert.ErrorIs(t, err, ErrInvalidOption)
+		})
+	}
+}
+
+func TestResolveDeviceProfile(t *testing.T) {
+	got, err := ResolveDeviceProfile("daylio")
+	require.NoError(t, err)
+	assert.Equal(t, types.DaylioDeviceProfile, got)
+
+	path := filepath.Join(t.TempDir(), "device.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("base: android\ndevice: Galaxy S23\n"), 0o644))
+	got, err = ResolveDeviceProfile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Galaxy S23", got.Device)
+	assert.Equal(t, types.AndroidDeviceProfile.Platform, got.Platform)
+
+	_, err = ResolveDeviceProfile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorIs(t, err, ErrInvalidOption)
+}
@@ -0,0 +1,178 @@
+package exporter
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// ENCRYPTED_FILE_EXTENSION is added to the names of encrypted exports.
+	ENCRYPTED_FILE_EXTENSION = ".enc"
+	// ENCRYPTION_MAGIC starts every encrypted export so that it can be told
+	// apart from other files.
+	ENCRYPTION_MAGIC = "DAYLIOENC1"
+	// ENCRYPTION_KDF_ITERATIONS is how many PBKDF2-HMAC-SHA256 iterations turn
+	// a passphrase into a key. It's stored within each file so that it can be
+	// raised later without breaking older files.
+	ENCRYPTION_KDF_ITERATIONS = 600000
+	// ENCRYPTION_KDF_MIN_ITERATIONS and ENCRYPTION_KDF_MAX_ITERATIONS bound
+	// the iterations a file may ask for. The count is read before the file
+	// can be authenticated, so a damaged or hostile file mustn't be able to
+	// weaken the key or stall decryption for hours.
+	ENCRYPTION_KDF_MIN_ITERATIONS = 100000
+	ENCRYPTION_KDF_MAX_ITERATIONS = 10 * ENCRYPTION_KDF_ITERATIONS
+	// PASSPHRASE_ENV_VAR provides a passphrase without prompting for one.
+	PASSPHRASE_ENV_VAR = "EXPORT_PASSPHRASE"
+
+	encryptionSaltSize = 16
+	encryptionKeySize  = 32
+)
+
+// An encrypted export is an envelope around the plain file:
+//
+//	magic | salt (16 bytes) | iterations (uint32, big endian) | nonce (12 bytes) | AES-256-GCM ciphertext
+//
+// Everything before the ciphertext is authenticated along with it, so
+// tampering with the header is caught too.
+
+// Encrypt seals data with a key derived from a passphrase.
+func Encrypt(plaintext []byte, passphrase string) ([]byte, error) {
+	return encrypt(plaintext, passphrase, ENCRYPTION_KDF_ITERATIONS)
+}
+
+func encrypt(plaintext []byte, passphrase string, iterations int) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("A passphrase is required to encrypt exports")
+	}
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newEncryptionCipher(passphrase, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	var header bytes.Buffer
+	header.WriteString(ENCRYPTION_MAGIC)
+	header.Write(salt)
+	binary.Write(&header, binary.BigEndian, uint32(iterations))
+	header.Write(nonce)
+	return gcm.Seal(header.Bytes(), nonce, plaintext, header.Bytes()), nil
+}
+
+// Decrypt opens data sealed by Encrypt.
+func Decrypt(data []byte, passphrase string) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, ErrNotEncrypted
+	}
+	rest := data[len(ENCRYPTION_MAGIC):]
+	if len(rest) < encryptionSaltSize+4 {
+		return nil, fmt.Errorf("%w: the export is truncated", ErrDecryptionFailed)
+	}
+	salt := rest[:encryptionSaltSize]
+	iterations := binary.BigEndian.Uint32(rest[encryptionSaltSize:])
+	if iterations < ENCRYPTION_KDF_MIN_ITERATIONS || iterations > ENCRYPTION_KDF_MAX_ITERATIONS {
+		return nil, fmt.Errorf("%w: the export is damaged (%d key derivation iterations, expected %d to %d)",
+			ErrDecryptionFailed, iterations, ENCRYPTION_KDF_MIN_ITERATIONS, ENCRYPTION_KDF_MAX_ITERATIONS)
+	}
+	gcm, err := newEncryptionCipher(passphrase, salt, int(iterations))
+	if err != nil {
+		return nil, err
+	}
+	headerSize := len(ENCRYPTION_MAGIC) + encryptionSaltSize + 4 + gcm.NonceSize()
+	if len(data) < headerSize+gcm.Overhead() {
+		return nil, fmt.Errorf("%w: the export is truncated", ErrDecryptionFailed)
+	}
+	header := data[:headerSize]
+	nonce := header[headerSize-gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, data[headerSize:], header)
+	if err != nil {
+		return nil, fmt.Errorf("%w; the passphrase is wrong or the file is damaged", ErrDecryptionFailed)
+	}
+	return plaintext, nil
+}
+
+// IsEncrypted is true when data starts like an encrypted export.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(ENCRYPTION_MAGIC))
+}
+
+// DecryptFile decrypts an encrypted export next to it, dropping the ".enc"
+// extension unless another output path is provided. Like other exports,
+// existing files are never overwritten; "-2", "-3", and so on are added to the
+// name instead. It returns the name that was written.
+func DecryptFile(encryptedFile string, outputFile string, passphrase string) (string, error) {
+	data, err := os.ReadFile(encryptedFile)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := Decrypt(data, passphrase)
+	if err != nil {
+		return "", err
+	}
+	if outputFile == "" {
+		outputFile = DecryptedFileName(encryptedFile)
+	}
+	return writeExportFile(outputFile, 0o600, func(w io.Writer) error {
+		_, err := w.Write(plaintext)
+		return err
+	})
+}
+
+// DecryptedFileName is where DecryptFile writes to by default.
+func DecryptedFileName(encryptedFile string) string {
+	if name, ok := strings.CutSuffix(encryptedFile, ENCRYPTED_FILE_EXTENSION); ok {
+		return name
+	}
+	return encryptedFile + ".decrypted.zip"
+}
+
+func newEncryptionCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key := pbkdf2([]byte(passphrase), salt, iterations, encryptionKeySize, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2 derives a key per RFC 8018 section 5.2.
+func pbkdf2(password []byte, salt []byte, iterations int, keyLen int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+	key := make([]byte, 0, numBlocks*hashLen)
+	u := make([]byte, hashLen)
+	t := make([]byte, hashLen)
+	for block := uint32(1); block <= uint32(numBlocks); block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.Write(prf, binary.BigEndian, block)
+		u = prf.Sum(u[:0])
+		copy(t, u)
+		for n := 1; n < iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for idx := range t {
+				t[idx] ^= u[idx]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
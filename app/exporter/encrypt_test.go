@@ -0,0 +1,137 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"exporter/types"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// Test vectors for PBKDF2-HMAC-SHA256 from RFC 7914 and Go's x/crypto.
+	for _, tc := range []struct {
+		password   string
+		salt       string
+		iterations int
+		keyLen     int
+		want       string
+	}{
+		{"password", "salt", 1, 32, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 2, 32, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{"password", "salt", 4096, 32, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		{"passwd", "salt", 1, 64, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+	} {
+		got := pbkdf2([]byte(tc.password), []byte(tc.salt), tc.iterations, tc.keyLen, sha256.New)
+		assert.Equal(t, tc.want, hex.EncodeToString(got))
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	plaintext := []byte("a Day One ZIP file")
+	encrypted, err := encrypt(plaintext, "correct horse", ENCRYPTION_KDF_MIN_ITERATIONS)
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, string(encrypted), string(plaintext))
+
+	got, err := Decrypt(encrypted, "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, got)
+}
+
+func TestDecryptFailures(t *testing.T) {
+	encrypted, err := encrypt([]byte("secret"), "correct horse", ENCRYPTION_KDF_MIN_ITERATIONS)
+	require.NoError(t, err)
+
+	_, err = Decrypt(encrypted, "battery staple")
+	assert.ErrorIs(t, err, ErrDecryptionFailed, "wrong passphrase")
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(ENCRYPTION_MAGIC)] ^= 1
+	_, err = Decrypt(tampered, "correct horse")
+	assert.Error(t, err, "tampered header")
+
+	_, err = Decrypt(encrypted[:len(encrypted)-1], "correct horse")
+	assert.Error(t, err, "truncated ciphertext")
+
+	_, err = Decrypt(encrypted[:len(ENCRYPTION_MAGIC)+2], "correct horse")
+	assert.Error(t, err, "truncated header")
+
+	_, err = Decrypt([]byte("PK\x03\x04"), "correct horse")
+	assert.ErrorIs(t, err, ErrNotEncrypted, "not encrypted")
+}
+
+func TestEncryptRequiresPassphrase(t *testing.T) {
+	_, err := Encrypt([]byte("secret"), "")
+	assert.Error(t, err)
+}
+
+func TestDecryptFile(t *testing.T) {
+	dir := t.TempDir()
+	encrypted, err := encrypt([]byte("zip"), "pass", ENCRYPTION_KDF_MIN_ITERATIONS)
+	require.NoError(t, err)
+	file := filepath.Join(dir, "export-20231217.zip.enc")
+	require.NoError(t, os.WriteFile(file, encrypted, 0o600))
+
+	got, err := DecryptFile(file, "", "pass")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "export-20231217.zip"), got)
+	data, err := os.ReadFile(got)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("zip"), data)
+
+	again, err := DecryptFile(file, "", "pass")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "export-20231217-2.zip"), again, "existing files must not be overwritten")
+}
+
+func TestDecryptRejectsUnreasonableIterations(t *testing.T) {
+	for _, iterations := range []uint32{0, 10, ENCRYPTION_KDF_MIN_ITERATIONS - 1, ENCRYPTION_KDF_MAX_ITERATIONS + 1, math.MaxUint32} {
+		var data bytes.Buffer
+		data.WriteString(ENCRYPTION_MAGIC)
+		data.Write(make([]byte, encryptionSaltSize))
+		binary.Write(&data, binary.BigEndian, iterations)
+		data.Write(make([]byte, 64))
+		_, err := Decrypt(data.Bytes(), "pass")
+		assert.ErrorIs(t, err, ErrDecryptionFailed, "%d iterations", iterations)
+	}
+}
+
+func TestDecryptedFileName(t *testing.T) {
+	assert.Equal(t, "exports/export.zip", DecryptedFileName("exports/export.zip.enc"))
+	assert.Equal(t, "export.bin.decrypted.zip", DecryptedFileName("export.bin"))
+}
+
+func TestWriteEncryptedDayOneExports(t *testing.T) {
+	entries := mustConvertEntries(t, mustGetMockDaylioEntries(t))
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+	require.NoError(t, os.MkdirAll(DEFAULT_EXPORT_DIRECTORY, 0o755))
+
+	r, err := (&DayOneSink{Passphrase: "pass", Directory: DEFAULT_EXPORT_DIRECTORY}).Write(entries, nil)
+	require.NoError(t, err)
+	result := r.(*types.DayOneExportResult)
+	assert.True(t, result.Encrypted)
+	assert.Equal(t, ENCRYPTED_FILE_EXTENSION, filepath.Ext(result.ZipFile))
+	_, err = os.Stat(DecryptedFileName(result.ZipFile))
+	assert.True(t, os.IsNotExist(err), "the unencrypted ZIP file must not be written")
+
+	data, err := os.ReadFile(result.ZipFile)
+	require.NoError(t, err)
+	plain, err := Decrypt(data, "pass")
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(plain), int64(len(plain)))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 1)
+	assert.Equal(t, result.JournalName+".json", zr.File[0].Name)
+}
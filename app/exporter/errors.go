@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	"errors"
+	"exporter/daylio"
+	"fmt"
+)
+
+var (
+	// ErrNotEncrypted means that a file isn't an encrypted export.
+	ErrNotEncrypted = errors.New("Not an encrypted export")
+	// ErrDecryptionFailed means that an encrypted export couldn't be
+	// decrypted, because the passphrase is wrong or the file is damaged.
+	ErrDecryptionFailed = errors.New("Couldn't decrypt the export")
+	// ErrInvalidOption means that an option, like an export format, isn't
+	// valid. It's the same error as daylio.ErrInvalidOption.
+	ErrInvalidOption = daylio.ErrInvalidOption
+)
+
+// WriteError is returned when an export can't be written.
+type WriteError struct {
+	// Path is the file or folder being written.
+	Path string
+	Err  error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("Couldn't write %s: %s", e.Path, e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// SettingsError is returned by Initialize when a setting isn't valid.
+type SettingsError struct {
+	// Setting is the name of the field within ExportSettings.
+	Setting string
+	Err     error
+}
+
+func (e *SettingsError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SettingsError) Unwrap() error {
+	return e.Err
+}
+
+// invalidOption gives an error the class of ErrInvalidOption without
+// changing its message.
+func invalidOption(err error) error {
+	return &invalidOptionError{err: err}
+}
+
+type invalidOptionError struct {
+	err error
+}
+
+func (e *invalidOptionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *invalidOptionError) Unwrap() []error {
+	return []error{ErrInvalidOption, e.err}
+}
@@ -0,0 +1,38 @@
+package exporter
+
+import (
+	"context"
+	"exporter/daylio"
+	"exporter/types"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteErrors(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "missing", "export.zip")
+	_, err := writeExportFile(name, 0o644, func(w io.Writer) error { return nil })
+	var writeErr *WriteError
+	require.ErrorAs(t, err, &writeErr)
+	assert.Equal(t, name, writeErr.Path)
+}
+
+func TestInvalidOptionErrors(t *testing.T) {
+	_, err := NewSinks([]string{"pdf"}, SinkOptions{})
+	assert.ErrorIs(t, err, ErrInvalidOption)
+	_, err = ParseMarkdownLayout("per-week")
+	assert.ErrorIs(t, err, daylio.ErrInvalidOption)
+}
+
+func TestConvertingEntriesReportsWhichEntryFailed(t *testing.T) {
+	entries := generateDaylioEntries(5)
+	entries[3].Time = "8 o'clock"
+	_, err := convertEntries(context.Background(), entries, types.DefaultDayOneGenerators(), ConvertOptions{})
+	var entryErr *daylio.EntryError
+	require.ErrorAs(t, err, &entryErr)
+	assert.Equal(t, 3, entryErr.Index)
+	assert.Equal(t, entries[3].FullDate, entryErr.Date)
+}
@@ -2,12 +2,16 @@ package exporter
 
 import (
 	"archive/zip"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"exporter/daylio"
+	"exporter/stats"
 	"exporter/types"
 	"fmt"
 	"io"
-	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -16,12 +20,13 @@ import (
 )
 
 const (
-	DEFAULT_DESTINATION_JOURNAL          = "From Daylio"
-	DAY_ONE_MAX_ENTRIES_IN_SINGLE_EXPORT = 99
-	DEFAULT_EXPORT_DIRECTORY             = "./exports"
-	BASE_FILE_NAME                       = "export"
-	VERSION                              = "%%VER_CHANGED_BY_MAKE%%"
-	COMMIT_SHA                           = "%%SHA_CHANGED_BY_MAKE%%"
+	DEFAULT_DESTINATION_JOURNAL = "From Daylio"
+	DEFAULT_EXPORT_DIRECTORY    = "./exports"
+	BASE_FILE_NAME              = "export"
+	DAY_ONE_SINK_NAME           = "dayone"
+	DAY_ONE_PHOTOS_DIRECTORY    = "photos"
+	VERSION                     = "%%VER_CHANGED_BY_MAKE%%"
+	COMMIT_SHA                  = "%%SHA_CHANGED_BY_MAKE%%"
 )
 
 type dayOneTimestamps struct {
@@ -34,69 +39,274 @@ func Version() {
 	fmt.Printf("exporter version %s, commit %s\n", VERSION, COMMIT_SHA)
 }
 
-// Initializes sets up an export job.
-func Initialize() error {
-	log.Info("Starting Daylio to Day One export")
-	setLogLevel()
-	if err := createExportDirectoryIfMissing(); err != nil {
-		return err
-	}
-	return nil
+// DayOneSink writes converted entries into a Day One JSON ZIP file.
+type DayOneSink struct {
+	// Passphrase encrypts the ZIP file when it isn't empty.
+	Passphrase string
+	// Directory is where the ZIP file is written. Required.
+	Directory string
+	// JournalName names the Day One journal. Defaults to "From Daylio".
+	JournalName string
 }
 
-// ConvertToDayOneExportFromBackup converts entries within a Daylio backup file
-// into a list of DayOne-compatible JSON import files.
-func ConvertToDayOneExportFromDaylioBackup(providedFile string, generators types.DayOneGenerators) (*types.DayOneExport, error) {
-	log.Debug("Starting conversion from backup file")
-	entries, err := daylio.GetEntriesFromBackupFile(providedFile)
+func (s *DayOneSink) Name() string {
+	return DAY_ONE_SINK_NAME
+}
+
+func (s *DayOneSink) Write(entries []ConvertedEntry, summary *RunSummary) (SinkResult, error) {
+	attachments := []Attachment{}
+	for _, e := range entries {
+		attachments = append(attachments, e.Attachments...)
+	}
+	export := types.NewDayOneExport(dayOneEntries(entries))
+	journalName := s.JournalName
+	if journalName == "" {
+		journalName = DEFAULT_DESTINATION_JOURNAL
+	}
+	dir, err := sinkDirectory(s.Directory)
 	if err != nil {
 		return nil, err
 	}
-	log.Infof("Exporting %d Daylio entries; this might take a few moments", len(entries))
-	dayOneEntries, err := convertToDayOneEntries(entries, generators)
+	zipFile := exportZipFileName(dir)
+	if s.Passphrase != "" {
+		r, err := writeEncryptedDayOneExports(zipFile, journalName, export, s.Passphrase, attachments)
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	r, err := writeDayOneExports(zipFile, journalName, export, attachments)
 	if err != nil {
 		return nil, err
 	}
-	return types.NewDayOneExport(dayOneEntries), nil
+	return r, nil
+}
+
+// ConvertOptions configures how Daylio entries are read and converted.
+type ConvertOptions struct {
+	// ConflictPolicy resolves conflicting entries when several files are
+	// merged.
+	ConflictPolicy daylio.ConflictPolicy
+	// Filter decides which entries are converted.
+	Filter daylio.Filter
+	// YearInReview adds a "Year in Review" entry for that year, with a Year in
+	// Pixels image attached, when it isn't zero.
+	YearInReview int
+	// Redactor removes private details from entries before they're
+	// converted, if provided.
+	Redactor *daylio.Redactor
+	// Summaries adds an entry summarizing every month and year.
+	Summaries bool
+	// Palette colours the Year in Pixels image. The default palette is used
+	// when it's empty.
+	Palette stats.Palette
+	// HomeLocation is given to entries with the "home" activity, if set.
+	HomeLocation *types.DayOneEntryLocation
+	// TimeZone is recorded on every Day One entry. Defaults to "UTC".
+	TimeZone string
+	// LocationHistory gives entries where they were written, if provided.
+	// Entries given a location by their activities keep it.
+	LocationHistory *LocationHistory
+	// Weather is given to entries on days it has weather for, if provided.
+	Weather *WeatherHistory
+	// Device is what entries look like they were created on. Defaults to
+	// types.MacDeviceProfile.
+	Device types.DeviceProfile
+	// Pipeline configures how many entries are converted at once and where
+	// progress is reported.
+	Pipeline PipelineOptions
+	// Logger reports what's happening, like how many entries were skipped by
+	// the filter. Nothing is logged when it's nil.
+	Logger log.FieldLogger
+}
+
+// logger is where opts are logged to.
+func (opts *ConvertOptions) logger() log.FieldLogger {
+	if opts.Logger != nil {
+		return opts.Logger
+	}
+	l := log.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+// MergeSources merges entries that were already read from Daylio backups and
+// CSV exports, when there are several, and drops those that don't match the
+// filter. The merge report is nil unless there were several sources.
+func MergeSources(sources []daylio.Source, opts ConvertOptions) ([]daylio.Entry, *daylio.MergeReport) {
+	entries, report := mergeSources(sources, opts.ConflictPolicy)
+	return filterEntries(entries, opts.Filter, opts.logger()), report
+}
+
+func mergeSources(sources []daylio.Source, policy daylio.ConflictPolicy) ([]daylio.Entry, *daylio.MergeReport) {
+	if len(sources) == 1 {
+		return sources[0].Entries, nil
+	}
+	return daylio.MergeEntries(sources, policy)
 }
 
-// ConvertToDayOneExportFromDaylioCSV converts entries within an exported CSV file from
-// Daylio into a list of DayOne-compatible JSON import files.
-func ConvertToDayOneExportFromDaylioCSV(daylioCSVPath string, generators types.DayOneGenerators) (*types.DayOneExport, error) {
-	entries, err := daylio.GetEntriesFromCSVFile(daylioCSVPath)
+func filterEntries(entries []daylio.Entry, filter daylio.Filter, logger log.FieldLogger) []daylio.Entry {
+	filtered := filter.Apply(entries)
+	if skipped := len(entries) - len(filtered); skipped > 0 {
+		logger.Infof("Skipping %d entries that don't match filters", skipped)
+	}
+	return filtered
+}
+
+// ConvertSources converts entries that were already read from Daylio backups
+// and CSV exports, merging them when there are several. It doesn't read
+// environment variables or files, and only logs to opts.Logger.
+func ConvertSources(ctx context.Context, sources []daylio.Source, opts ConvertOptions, generators types.DayOneGenerators) ([]ConvertedEntry, *RunSummary, error) {
+	startedAt := time.Now()
+	logger := opts.logger()
+	entries, report := MergeSources(sources, opts)
+	var redactions *daylio.RedactionReport
+	if opts.Redactor != nil {
+		entries, redactions = opts.Redactor.Apply(entries)
+	}
+	logger.Infof("Exporting %d Daylio entries; this might take a few moments", len(entries))
+	converted, err := convertEntries(ctx, entries, generators, opts)
 	if err != nil {
+		return nil, nil, err
+	}
+	paths := []string{}
+	for _, src := range sources {
+		paths = append(paths, src.Path)
+	}
+	summary := newRunSummary(paths, startedAt, converted)
+	summary.Merge = report
+	summary.Redactions = redactions
+	extras := []ConvertedEntry{}
+	if opts.Summaries {
+		summaries, err := createSummaryEntries(converted, entryDevice(&opts), generators)
+		if err != nil {
+			return nil, nil, err
+		}
+		extras = append(extras, summaries...)
+	}
+	if opts.YearInReview != 0 {
+		palette := opts.Palette
+		if palette == (stats.Palette{}) {
+			palette = stats.DefaultPalette
+		}
+		review, err := createYearInReviewEntry(entries, opts.YearInReview, palette, entryDevice(&opts), generators)
+		if err != nil {
+			return nil, nil, err
+		}
+		extras = append(extras, review)
+	}
+	for idx := range extras {
+		extras[idx].DayOne.TimeZone = entryTimeZone(&opts)
+	}
+	return append(converted, extras...), summary, nil
+}
+
+// StreamEntries converts entries from a single source as read gives them,
+// handing each one to opts.Pipeline.Writers as soon as it's converted. Unlike
+// ConvertSources, no entries are kept, so a backup of any size never needs to
+// fit in memory, which also means there are no summaries or "Year in Review"
+// entries. source names where entries were read from.
+func StreamEntries(ctx context.Context, source string, read func(fn func(*daylio.Entry) error) error, opts ConvertOptions, generators types.DayOneGenerators) (*RunSummary, error) {
+	if opts.Summaries || opts.YearInReview != 0 {
+		return nil, invalidOption(errors.New("Summaries and Year in Review entries need every entry, so they can't be streamed"))
+	}
+	logger := opts.logger()
+	summary := RunSummary{Sources: []string{source}, StartedAt: time.Now()}
+	if opts.Redactor != nil {
+		summary.Redactions = opts.Redactor.NewReport()
+	}
+	skipped := 0
+	filtered := func(fn func(*daylio.Entry) error) error {
+		return read(func(e *daylio.Entry) error {
+			if !opts.Filter.Matches(e) {
+				skipped++
+				return nil
+			}
+			if opts.Redactor != nil {
+				opts.Redactor.Redact(e, summary.Redactions)
+			}
+			return fn(e)
+		})
+	}
+	logger.Infof("Exporting Daylio entries from %s; this might take a few moments", source)
+	if err := runPipeline(ctx, filtered, 0, generators, opts, summary.count); err != nil {
 		return nil, err
 	}
-	dayOneEntries, err := convertToDayOneEntries(entries, generators)
+	if skipped > 0 {
+		logger.Infof("Skipped %d entries that don't match filters", skipped)
+	}
+	return &summary, nil
+}
+
+func writeDayOneExports(zipFile string, journalName string, export *types.DayOneExport, attachments []Attachment) (*types.DayOneExportResult, error) {
+	r := types.DayOneExportResult{
+		JournalName: journalName,
+	}
+	name, err := writeExportFile(zipFile, 0o644, func(w io.Writer) error {
+		return writeDayOneZip(w, r.JournalName, export, attachments)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return types.NewDayOneExport(dayOneEntries), nil
+	r.ZipFile = name
+	return &r, nil
 }
 
-// WriteDayOneExports zips a DayOne export JSON and writes it to disk.
-func WriteDayOneExports(export *types.DayOneExport) (*types.DayOneExportResult, error) {
+func writeEncryptedDayOneExports(zipFile string, journalName string, export *types.DayOneExport, passphrase string, attachments []Attachment) (*types.DayOneExportResult, error) {
 	r := types.DayOneExportResult{
-		ZipFile:     exportZipFileName(),
-		JournalName: exportDayOneJournalName(),
+		JournalName: journalName,
+		Encrypted:   true,
 	}
-	f, err := os.Create(r.ZipFile)
-	if err != nil {
+	var buf bytes.Buffer
+	if err := writeDayOneZip(&buf, r.JournalName, export, attachments); err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	zip := zip.NewWriter(f)
-	defer zip.Close()
-	fInZip, err := zip.Create(r.JournalName + ".json")
+	encrypted, err := Encrypt(buf.Bytes(), passphrase)
 	if err != nil {
 		return nil, err
 	}
-	if err := writeDayOneExport(fInZip, export); err != nil {
+	name, err := writeExportFile(zipFile+ENCRYPTED_FILE_EXTENSION, 0o600, func(w io.Writer) error {
+		_, err := w.Write(encrypted)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
+	r.ZipFile = name
 	return &r, nil
 }
 
+// WriteDayOneZip writes converted entries, along with their attachments, as a
+// Day One JSON ZIP file for a journal.
+func WriteDayOneZip(w io.Writer, journalName string, entries []ConvertedEntry) error {
+	attachments := []Attachment{}
+	for _, e := range entries {
+		attachments = append(attachments, e.Attachments...)
+	}
+	return writeDayOneZip(w, journalName, types.NewDayOneExport(dayOneEntries(entries)), attachments)
+}
+
+func writeDayOneZip(w io.Writer, journalName string, export *types.DayOneExport, attachments []Attachment) error {
+	zip := zip.NewWriter(w)
+	fInZip, err := zip.Create(journalName + ".json")
+	if err != nil {
+		return err
+	}
+	if err := writeDayOneExport(fInZip, export); err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		fInZip, err := zip.Create(path.Join(DAY_ONE_PHOTOS_DIRECTORY, a.Name))
+		if err != nil {
+			return err
+		}
+		if _, err := fInZip.Write(a.Data); err != nil {
+			return err
+		}
+	}
+	return zip.Close()
+}
+
 func writeDayOneExport(buf io.Writer, export *types.DayOneExport) error {
 	json, err := json.Marshal(export)
 	if err != nil {
@@ -106,49 +316,21 @@ func writeDayOneExport(buf io.Writer, export *types.DayOneExport) error {
 	return err
 }
 
-func numEntriesInThisPage(entries []daylio.Entry, idx int) int {
-	if len(entries) <= DAY_ONE_MAX_ENTRIES_IN_SINGLE_EXPORT {
-		return len(entries) - 1
-	}
-	numEntries := idx + DAY_ONE_MAX_ENTRIES_IN_SINGLE_EXPORT
-	if numEntries > len(entries) {
-		numEntries = len(entries)
+func dayOneEntries(converted []ConvertedEntry) []types.DayOneEntry {
+	outs := []types.DayOneEntry{}
+	for _, c := range converted {
+		outs = append(outs, c.DayOne)
 	}
-	return numEntries
+	return outs
 }
 
-func convertToDayOneEntries(entries []daylio.Entry, generators types.DayOneGenerators) ([]types.DayOneEntry, error) {
-	outs := []types.DayOneEntry{}
-	for idx := 0; idx < len(entries); idx++ {
-		daylioEntry := entries[idx]
-		dayOneEntry := types.NewEmptyDayOneEntry()
-		id := generators.IDGenerator.CreateID()
-		rt, err := generateDayOneRichText(&daylioEntry, generators.UUIDGenerator)
-		if err != nil {
-			return nil, err
-		}
-		activities := daylioEntry.ActivitiesList
-		if len(activities) == 0 {
-			activities = strings.Split(strings.ReplaceAll(daylioEntry.Activities, " | ", "|"), "|")
-		}
-		loc, err := generateLocationFromDaylioActivities(activities)
-		if err != nil {
-			return nil, err
-		}
-		ts, err := createTimestamps(&daylioEntry, generators.Timestamper)
-		if err != nil {
-			return nil, err
-		}
-		dayOneEntry.RichText = rt
-		dayOneEntry.UUID = id
-		dayOneEntry.Tags = activities
-		dayOneEntry.Location = loc
-		dayOneEntry.CreationDate = ts.Created
-		dayOneEntry.ModifiedDate = ts.Modified
-		dayOneEntry.Text = createDayOneText(&daylioEntry)
-		outs = append(outs, *dayOneEntry)
-	}
-	return outs, nil
+// entryActivities returns an entry's activities whether it came from a backup
+// (ActivitiesList) or from a CSV export (Activities).
+func entryActivities(entry *daylio.Entry) []string {
+	if len(entry.ActivitiesList) > 0 {
+		return entry.ActivitiesList
+	}
+	return strings.Split(strings.ReplaceAll(entry.Activities, " | ", "|"), "|")
 }
 
 func createDayOneText(entry *daylio.Entry) string {
@@ -162,31 +344,58 @@ func createDayOneText(entry *daylio.Entry) string {
 	return fmt.Sprintf("%s\n\n%s", noteParts[0], noteParts[1])
 }
 
-func generateDayOneRichText(entry *daylio.Entry, gen types.DayOneEntryUUIDGenerator) (string, error) {
+// RetitleEntry changes the title of an entry converted from Daylio, along with
+// its text and rich text.
+func RetitleEntry(e *ConvertedEntry, title string) error {
+	if e.Synthetic {
+		return errors.New("Only entries from Daylio can be retitled")
+	}
+	var rt types.DayOneRichTextObjectData
+	if err := json.Unmarshal([]byte(e.DayOne.RichText), &rt); err != nil {
+		return err
+	}
+	e.Source.NoteTitle = title
+	e.DayOne.Text = createDayOneText(&e.Source)
+	if len(rt.Contents) > 0 {
+		rt.Contents[0].Text = e.DayOne.Text
+	}
+	richText, err := json.Marshal(rt)
+	if err != nil {
+		return err
+	}
+	e.DayOne.RichText = string(richText)
+	return nil
+}
+
+func generateDayOneRichText(entry *daylio.Entry, device *types.DeviceProfile, gen types.DayOneEntryUUIDGenerator) (string, error) {
 	uuid, err := gen.GenerateUUID()
 	if err != nil {
 		return "", err
 	}
+	return marshalDayOneRichText(device, types.DayOneRichTextObject{
+		Text: createDayOneText(entry),
+		Attributes: &types.DayOneRichTextObjectAttributes{
+			Line: types.DayOneRichTextLineObject{
+				Header:     1,
+				Identifier: uuid,
+			},
+		},
+	})
+}
+
+// marshalDayOneRichText records the device's Day One app as the one that
+// created the rich text.
+func marshalDayOneRichText(device *types.DeviceProfile, contents ...types.DayOneRichTextObject) (string, error) {
 	rt := types.DayOneRichTextObjectData{
 		Meta: types.DayOneRichTextObjectDataMetadata{
 			Version:           1,
 			SmallLinesRemoved: false,
 			Created: types.DayOneRichTextObjectCreatedProperties{
-				Version:  1527,
-				Platform: "com.bloombuilt.dayone-mac",
-			},
-		},
-		Contents: []types.DayOneRichTextObject{
-			{
-				Text: createDayOneText(entry),
-				Attributes: types.DayOneRichTextObjectAttributes{
-					Line: types.DayOneRichTextLineObject{
-						Header:     1,
-						Identifier: uuid,
-					},
-				},
+				Version:  device.PlatformVersion,
+				Platform: device.Platform,
 			},
 		},
+		Contents: contents,
 	}
 	out, err := json.Marshal(rt)
 	if err != nil {
@@ -195,29 +404,35 @@ func generateDayOneRichText(entry *daylio.Entry, gen types.DayOneEntryUUIDGenera
 	return string(out), nil
 }
 
-func generateLocationFromDaylioActivities(activities []string) (types.DayOneEntryLocation, error) {
-	if os.Getenv("NO_AUTO_HOME_LOCATION") != "" {
-		return types.DayOneEntryLocation{}, nil
-	}
-	if os.Getenv("HOME_ADDRESS_JSON") == "" {
-		return types.DayOneEntryLocation{}, nil
-	}
-	var out types.DayOneEntryLocation
-	if err := json.Unmarshal([]byte(os.Getenv("HOME_ADDRESS_JSON")), &out); err != nil {
-		return types.DayOneEntryLocation{}, err
+func generateLocationFromDaylioActivities(activities []string, home *types.DayOneEntryLocation) types.DayOneEntryLocation {
+	if home == nil {
+		return types.DayOneEntryLocation{}
 	}
 	for _, activity := range activities {
 		if activity == "home" {
-			return out, nil
+			return *home
 		}
 	}
-	return types.DayOneEntryLocation{}, nil
+	return types.DayOneEntryLocation{}
+}
 
+// entryTimeZone is the time zone recorded on Day One entries.
+func entryTimeZone(opts *ConvertOptions) string {
+	if opts.TimeZone == "" {
+		return "UTC"
+	}
+	return opts.TimeZone
+}
+
+func entryDevice(opts *ConvertOptions) *types.DeviceProfile {
+	if opts.Device == (types.DeviceProfile{}) {
+		return &types.MacDeviceProfile
+	}
+	return &opts.Device
 }
 
 func createTimestamps(entry *daylio.Entry, g types.DayOneEntryModifiedTimestamper) (dayOneTimestamps, error) {
-	createdRaw := fmt.Sprintf("%sT%s:00Z", entry.FullDate, entry.Time)
-	created, err := time.Parse("2006-01-02T15:04:05Z", createdRaw)
+	created, err := entryTime(entry)
 	if err != nil {
 		return dayOneTimestamps{}, err
 	}
@@ -231,37 +446,23 @@ func createTimestamps(entry *daylio.Entry, g types.DayOneEntryModifiedTimestampe
 	}, nil
 }
 
-func exportDirectory() string {
-	return DEFAULT_EXPORT_DIRECTORY
+// entryTime parses the date and time of a Daylio entry. Daylio entries don't
+// carry a time zone, so they are treated as UTC.
+func entryTime(entry *daylio.Entry) (time.Time, error) {
+	return time.Parse("2006-01-02T15:04:05Z", fmt.Sprintf("%sT%s:00Z", entry.FullDate, entry.Time))
 }
 
-func exportDayOneJournalName() string {
-	return DEFAULT_DESTINATION_JOURNAL
-}
-
-func exportZipFileName() string {
-	return filepath.Join(exportDirectory(), fmt.Sprintf("export-%s.zip", time.Now().Format("20060102")))
-}
-
-func createExportDirectoryIfMissing() error {
-	_, err := os.Stat(exportDirectory())
-	if err == nil {
-		return nil
-	}
-	if exists := os.IsExist(err); !exists {
-		log.Debugf("Creating export directory: %s", exportDirectory())
-		return os.Mkdir(exportDirectory(), 0755)
+// localCreationTime is when an entry was created on the clock of the device
+// that created it. Entries from backups are in UTC and know their offset;
+// entries from CSV exports are already local.
+func localCreationTime(entry *ConvertedEntry) time.Time {
+	created := time.Time(entry.DayOne.CreationDate)
+	if offset := entry.Source.TimeZoneOffset; offset != 0 {
+		return created.In(time.FixedZone("", int(offset/1000)))
 	}
-	return err
+	return created
 }
 
-func setLogLevel() {
-	if os.Getenv("LOG_LEVEL") == "" {
-		return
-	}
-	level, err := log.ParseLevel(os.Getenv("LOG_LEVEL"))
-	if err != nil {
-		log.Warningf("Invalid log level, using default: %s", os.Getenv("LOG_LEVEL"))
-	}
-	log.SetLevel(level)
+func exportZipFileName(dir string) string {
+	return filepath.Join(dir, fmt.Sprintf("export-%s.zip", time.Now().Format("20060102")))
 }
@@ -2,6 +2,7 @@ package exporter
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"exporter/daylio"
 	"exporter/types"
@@ -103,7 +104,7 @@ func TestGenerateDayOneRichText(t *testing.T) {
 		Note:      "note text 1",
 	}
 	uGen := newMockUUIDGenerator(t, &entry)
-	got, err := generateDayOneRichText(&entry, uGen)
+	got, err := generateDayOneRichText(&entry, &types.MacDeviceProfile, uGen)
 	assert.NoError(t, err)
 	assert.Contains(t, got, fmt.Sprintf(`"identifier":"%s"`, strings.ToLower(FirstMockNoteUUID)))
 	assert.Contains(t, got, fmt.Sprintf(`"text":"%s\n\n%s"`, entry.NoteTitle, entry.Note))
@@ -114,12 +115,25 @@ func TestGenerateDayOneRichTextWithoutTitle(t *testing.T) {
 		Note: "note text 1",
 	}
 	uGen := newMockUUIDGenerator(t, &entry)
-	got, err := generateDayOneRichText(&entry, uGen)
+	got, err := generateDayOneRichText(&entry, &types.MacDeviceProfile, uGen)
 	assert.NoError(t, err)
 	assert.Contains(t, got, fmt.Sprintf(`"identifier":"%s"`, strings.ToLower(FirstMockNoteUUID)))
 	assert.Contains(t, got, fmt.Sprintf(`"text":"Note\n\n%s"`, entry.Note))
 }
 
+func TestRetitleEntry(t *testing.T) {
+	converted := mustConvertEntries(t, []daylio.Entry{
+		{FullDate: "2023-12-17", Time: "08:00", Mood: "good", NoteTitle: "Title", Note: "note text 1"},
+	})
+	e := converted[0]
+	require.NoError(t, RetitleEntry(&e, "New title"))
+	assert.Equal(t, "New title\n\nnote text 1", e.DayOne.Text)
+	assert.Contains(t, e.DayOne.RichText, `"text":"New title\n\nnote text 1"`)
+	assert.Equal(t, "Title", converted[0].Source.NoteTitle, "the original entry is left alone")
+
+	assert.Error(t, RetitleEntry(&ConvertedEntry{Synthetic: true}, "Summary"))
+}
+
 func TestCreateTimestamps(t *testing.T) {
 	entry := daylio.Entry{
 		FullDate: "2023-12-17",
@@ -136,7 +150,6 @@ func TestCreateTimestamps(t *testing.T) {
 }
 
 func TestConvertToDayOneSingle(t *testing.T) {
-	t.Setenv("TZ", "America/Chicago")
 	var export types.DayOneExport
 	wantJSON, err := os.ReadFile("./fixtures/dayone.json")
 	require.NoError(t, err)
@@ -156,7 +169,7 @@ func TestConvertToDayOneSingle(t *testing.T) {
 2023-12-17,Dec 17,Sunday,08:00,good,activity 1 | activity 2 | activity 3,note title,note text 1`
 	err = csv.UnmarshalString(csvRaw, &entries)
 	require.NoError(t, err)
-	got, err := convertToDayOneEntries(entries, generators)
+	got, err := convertToDayOneEntries(entries, generators, ConvertOptions{TimeZone: "America/Chicago"})
 	// NOTE: Ignore testing RichText, as this is covered by another test.  This
 	// will always fail due to the keys in the underlying map being inserted in
 	// random order.
@@ -168,7 +181,6 @@ func TestConvertToDayOneSingle(t *testing.T) {
 }
 
 func TestConvertDaylioEntriesToDayOne(t *testing.T) {
-	t.Setenv("TZ", "America/Chicago")
 	var entries []daylio.Entry
 	mockEntries, err := os.OpenFile("./fixtures/daylio.csv", os.O_RDWR|os.O_CREATE, os.ModePerm)
 	require.NoError(t, err)
@@ -189,7 +201,7 @@ func TestConvertDaylioEntriesToDayOne(t *testing.T) {
 		IDGenerator:   iGen,
 		Timestamper:   &tGen,
 	}
-	got, err := convertToDayOneEntries(entries, generators)
+	got, err := convertToDayOneEntries(entries, generators, ConvertOptions{TimeZone: "America/Chicago"})
 	// NOTE: Ignore testing RichText and UUIOD, as this is covered by another test.
 	for idx := 0; idx < len(want); idx++ {
 		want[idx].RichText = ""
@@ -203,6 +215,14 @@ func TestConvertDaylioEntriesToDayOne(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func convertToDayOneEntries(entries []daylio.Entry, generators types.DayOneGenerators, opts ConvertOptions) ([]types.DayOneEntry, error) {
+	converted, err := convertEntries(context.Background(), entries, generators, opts)
+	if err != nil {
+		return nil, err
+	}
+	return dayOneEntries(converted), nil
+}
+
 func TestExportLocationQuirk(t *testing.T) {
 	locJSON, err := os.ReadFile("./fixtures/home_location.json")
 	require.NoError(t, err)
@@ -210,11 +230,28 @@ func TestExportLocationQuirk(t *testing.T) {
 	var want types.DayOneEntryLocation
 	err = json.Unmarshal(locJSON, &want)
 	require.NoError(t, err)
-	got, err := generateLocationFromDaylioActivities([]string{
+	home, err := HomeLocationFromEnv()
+	require.NoError(t, err)
+	got := generateLocationFromDaylioActivities([]string{
 		"activity 1",
 		"home",
 		"activity 2",
-	})
-	assert.NoError(t, err)
+	}, home)
 	assert.Equal(t, want, got)
+	assert.Empty(t, generateLocationFromDaylioActivities([]string{"activity 1"}, home))
+}
+
+func TestConvertSourcesWithRedaction(t *testing.T) {
+	redactor, err := daylio.NewRedactor(&daylio.RedactionConfig{Phrases: []string{"text 1"}})
+	require.NoError(t, err)
+	sources := []daylio.Source{{Path: "./fixtures/daylio.csv", Entries: mustGetMockDaylioEntries(t)}}
+	got, summary, err := ConvertSources(context.Background(), sources, ConvertOptions{
+		Redactor: redactor,
+	}, types.DefaultDayOneGenerators())
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, "note [redacted]", got[0].Source.Note)
+	assert.Equal(t, "note title\n\nnote [redacted]", got[0].DayOne.Text)
+	require.NotNil(t, summary.Redactions)
+	assert.Equal(t, 1, summary.Redactions.Total())
 }
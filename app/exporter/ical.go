@@ -0,0 +1,205 @@
+package exporter
+
+import (
+	"exporter/daylio"
+	"exporter/types"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	ICAL_SINK_NAME = "ical"
+	// ICAL_EVENT_DURATION is how long timed events last on the calendar.
+	ICAL_EVENT_DURATION = 30 * time.Minute
+	// ICAL_MAX_LINE_OCTETS is the longest a content line can be before it
+	// needs to be folded, per RFC 5545 section 3.1.
+	ICAL_MAX_LINE_OCTETS = 75
+)
+
+var moodEmojis = map[string]string{
+	daylio.DaylioMoodRad:   "😄",
+	daylio.DaylioMoodGood:  "🙂",
+	daylio.DaylioMoodMeh:   "😐",
+	"ok":                   "😐",
+	daylio.DaylioMoodBad:   "🙁",
+	daylio.DaylioMoodAwful: "😫",
+}
+
+// ICalExportResult provides details about an iCalendar export.
+type ICalExportResult struct {
+	File   string
+	Events int
+}
+
+// Files lists the iCalendar file written by the export.
+func (r *ICalExportResult) Files() []string {
+	return []string{r.File}
+}
+
+// ICalSink writes converted entries into an iCalendar (.ics) file with one
+// event per entry.
+type ICalSink struct {
+	// AllDay creates all-day events instead of events at the time of each
+	// entry.
+	AllDay bool
+	// Directory is where the file is written. Required.
+	Directory string
+}
+
+func (s *ICalSink) Name() string {
+	return ICAL_SINK_NAME
+}
+
+func (s *ICalSink) Write(entries []ConvertedEntry, summary *RunSummary) (SinkResult, error) {
+	entries = daylioEntriesOnly(entries)
+	dir, err := sinkDirectory(s.Directory)
+	if err != nil {
+		return nil, err
+	}
+	name, err := writeExportFile(icalFileName(dir), 0o644, func(w io.Writer) error {
+		return writeICalendar(w, entries, s.AllDay)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ICalExportResult{File: name, Events: len(entries)}, nil
+}
+
+func icalFileName(dir string) string {
+	return filepath.Join(dir, fmt.Sprintf("journal-%s.ics", time.Now().Format("20060102")))
+}
+
+// icalWriter writes folded iCalendar content lines and remembers the first
+// error it ran into.
+type icalWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *icalWriter) line(name string, value string) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = io.WriteString(w.w, foldICalLine(name+":"+value))
+}
+
+func writeICalendar(w io.Writer, entries []ConvertedEntry, allDay bool) error {
+	iw := icalWriter{w: w}
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", fmt.Sprintf("-//daylio-to-day-one//exporter %s//EN", VERSION))
+	iw.line("CALSCALE", "GREGORIAN")
+	for idx := range entries {
+		writeICalEvent(&iw, &entries[idx], allDay)
+	}
+	iw.line("END", "VCALENDAR")
+	return iw.err
+}
+
+func writeICalEvent(iw *icalWriter, entry *ConvertedEntry, allDay bool) {
+	start := time.Time(entry.DayOne.CreationDate).UTC()
+	iw.line("BEGIN", "VEVENT")
+	iw.line("UID", entry.DayOne.UUID+"@daylio-to-day-one")
+	iw.line("DTSTAMP", time.Time(entry.DayOne.ModifiedDate).UTC().Format("20060102T150405Z"))
+	if allDay {
+		// All-day events are on the day the entry was written where it was
+		// written.
+		day := localCreationTime(entry)
+		iw.line("DTSTART;VALUE=DATE", day.Format("20060102"))
+		iw.line("DTEND;VALUE=DATE", day.AddDate(0, 0, 1).Format("20060102"))
+		iw.line("TRANSP", "TRANSPARENT")
+	} else {
+		iw.line("DTSTART", start.Format("20060102T150405Z"))
+		iw.line("DTEND", start.Add(ICAL_EVENT_DURATION).Format("20060102T150405Z"))
+	}
+	iw.line("SUMMARY", escapeICalText(icalSummary(&entry.Source)))
+	if desc := icalDescription(&entry.Source); desc != "" {
+		iw.line("DESCRIPTION", escapeICalText(desc))
+	}
+	if loc := entry.DayOne.Location; loc != (types.DayOneEntryLocation{}) {
+		if name := icalLocationName(&loc); name != "" {
+			iw.line("LOCATION", escapeICalText(name))
+		}
+		center := loc.Location.Region.Center
+		if center != (types.DayOneEntryLocationCoords{}) {
+			iw.line("GEO", fmt.Sprintf("%f;%f", center.Latitude, center.Longitude))
+		}
+	}
+	if activities := entry.Source.ActivityNames(); len(activities) > 0 {
+		escaped := []string{}
+		for _, a := range activities {
+			escaped = append(escaped, escapeICalText(a))
+		}
+		iw.line("CATEGORIES", strings.Join(escaped, ","))
+	}
+	iw.line("END", "VEVENT")
+}
+
+func icalSummary(entry *daylio.Entry) string {
+	summary := entry.Mood
+	if emoji, ok := moodEmojis[entry.Mood]; ok {
+		summary = emoji + " " + summary
+	}
+	if activities := entry.ActivityNames(); len(activities) > 0 {
+		summary = fmt.Sprintf("%s: %s", summary, strings.Join(activities, ", "))
+	}
+	return summary
+}
+
+func icalDescription(entry *daylio.Entry) string {
+	if entry.NoteTitle == "" {
+		return entry.Note
+	}
+	if entry.Note == "" {
+		return entry.NoteTitle
+	}
+	return entry.NoteTitle + "\n\n" + entry.Note
+}
+
+func icalLocationName(loc *types.DayOneEntryLocation) string {
+	parts := []string{}
+	for _, p := range []string{loc.PlaceName, loc.LocalityName, loc.AdministrativeArea, loc.Country} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// escapeICalText escapes TEXT values per RFC 5545 section 3.3.11.
+func escapeICalText(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+	return r.Replace(s)
+}
+
+// foldICalLine splits content lines longer than 75 octets per RFC 5545
+// section 3.1 without splitting multi-byte characters.
+func foldICalLine(line string) string {
+	var b strings.Builder
+	limit := ICAL_MAX_LINE_OCTETS
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards the limit.
+		limit = ICAL_MAX_LINE_OCTETS - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
@@ -0,0 +1,138 @@
+package exporter
+
+import (
+	"bytes"
+	"exporter/daylio"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var icalContentLine = regexp.MustCompile(`^([A-Za-z0-9-]+)((?:;[A-Za-z0-9-]+=[^;:,]+)*):(.*)$`)
+
+// validateRFC5545 checks the parts of RFC 5545 that calendar apps tend to be
+// picky about: CRLF line endings, folding, matching components, and required
+// properties.
+func validateRFC5545(t *testing.T, ics string) []map[string][]string {
+	t.Helper()
+	require.True(t, strings.HasSuffix(ics, "\r\n"), "calendar must end with CRLF")
+	rawLines := strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n")
+	lines := []string{}
+	for _, l := range rawLines {
+		require.NotContains(t, l, "\n", "bare LF found")
+		require.LessOrEqual(t, len(l), 75, "line longer than 75 octets: %q", l)
+		if strings.HasPrefix(l, " ") {
+			require.NotEmpty(t, lines, "continuation line before any content line")
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		lines = append(lines, l)
+	}
+	stack := []string{}
+	events := []map[string][]string{}
+	calendarProps := map[string][]string{}
+	var current map[string][]string
+	for _, l := range lines {
+		m := icalContentLine.FindStringSubmatch(l)
+		require.NotNil(t, m, "not a valid content line: %q", l)
+		name, value := m[1], m[3]
+		switch name {
+		case "BEGIN":
+			stack = append(stack, value)
+			if value == "VEVENT" {
+				current = map[string][]string{}
+			}
+			continue
+		case "END":
+			require.NotEmpty(t, stack)
+			require.Equal(t, stack[len(stack)-1], value, "mismatched END")
+			stack = stack[:len(stack)-1]
+			if value == "VEVENT" {
+				for _, p := range []string{"UID", "DTSTAMP", "DTSTART"} {
+					require.Len(t, current[p], 1, "VEVENT needs exactly one %s", p)
+				}
+				require.False(t, len(current["DTEND"]) > 0 && len(current["DURATION"]) > 0,
+					"VEVENT can't have both DTEND and DURATION")
+				events = append(events, current)
+				current = nil
+			}
+			continue
+		}
+		if current != nil {
+			current[name] = append(current[name], value)
+		} else {
+			calendarProps[name] = append(calendarProps[name], value)
+		}
+	}
+	require.Empty(t, stack, "unclosed components")
+	require.Len(t, calendarProps["VERSION"], 1)
+	require.Equal(t, "2.0", calendarProps["VERSION"][0])
+	require.Len(t, calendarProps["PRODID"], 1)
+	return events
+}
+
+func TestWriteICalendar(t *testing.T) {
+	entries := mustConvertEntries(t, mustGetMockDaylioEntries(t))
+	var buf bytes.Buffer
+	require.NoError(t, writeICalendar(&buf, entries, false))
+	events := validateRFC5545(t, buf.String())
+	require.Len(t, events, 3)
+	assert.Equal(t, []string{"20231217T080000Z"}, events[0]["DTSTART"])
+	assert.Equal(t, []string{"20231217T083000Z"}, events[0]["DTEND"])
+	assert.Equal(t, []string{`🙂 good: activity 1\, activity 2\, activity 3`}, events[0]["SUMMARY"])
+	assert.Equal(t, []string{`note title\n\nnote text 1`}, events[0]["DESCRIPTION"])
+	assert.Equal(t, []string{`activity 1,activity 2,activity 3`}, events[0]["CATEGORIES"])
+	assert.Equal(t, []string{"note text 3"}, events[2]["DESCRIPTION"])
+}
+
+func TestWriteICalendarAllDay(t *testing.T) {
+	entries := mustConvertEntries(t, []daylio.Entry{
+		{FullDate: "2023-12-31", Time: "23:00", Mood: "rad"},
+	})
+	var buf bytes.Buffer
+	require.NoError(t, writeICalendar(&buf, entries, true))
+	events := validateRFC5545(t, buf.String())
+	require.Len(t, events, 1)
+	assert.Contains(t, buf.String(), "DTSTART;VALUE=DATE:20231231\r\n")
+	assert.Contains(t, buf.String(), "DTEND;VALUE=DATE:20240101\r\n")
+}
+
+func TestWriteICalendarAllDayFromBackupEntry(t *testing.T) {
+	// 20:00 on Dec 31 in Chicago is already Jan 1 in UTC.
+	entries := mustConvertEntries(t, []daylio.Entry{
+		{FullDate: "2024-01-01", Time: "02:00", Mood: "rad", TimeZoneOffset: -21600000},
+	})
+	var buf bytes.Buffer
+	require.NoError(t, writeICalendar(&buf, entries, true))
+	assert.Contains(t, buf.String(), "DTSTART;VALUE=DATE:20231231\r\n")
+	assert.Contains(t, buf.String(), "DTEND;VALUE=DATE:20240101\r\n")
+}
+
+func TestWriteICalendarWithLocation(t *testing.T) {
+	entries := mustConvertEntriesWith(t, []daylio.Entry{
+		{FullDate: "2023-12-17", Time: "08:00", Mood: "bad", Activities: "home"},
+	}, ConvertOptions{HomeLocation: mustReadHomeLocation(t)})
+	var buf bytes.Buffer
+	require.NoError(t, writeICalendar(&buf, entries, false))
+	events := validateRFC5545(t, buf.String())
+	require.Len(t, events, 1)
+	assert.Equal(t, []string{"10.000000;-10.000000"}, events[0]["GEO"])
+}
+
+func TestFoldICalLine(t *testing.T) {
+	long := "DESCRIPTION:" + strings.Repeat("é", 100)
+	folded := foldICalLine(long)
+	for _, l := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(l), 75)
+		assert.True(t, strings.ToValidUTF8(l, "?") == l, fmt.Sprintf("line split a character: %q", l))
+	}
+	assert.Equal(t, long, strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", ""))
+}
+
+func TestEscapeICalText(t *testing.T) {
+	assert.Equal(t, `a\\b\;c\,d\ne`, escapeICalText("a\\b;c,d\ne"))
+}
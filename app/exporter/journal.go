@@ -0,0 +1,42 @@
+package exporter
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MAX_JOURNAL_NAME_BYTES keeps "<journal name>.json" within the 255 byte file
+// name limit of most file systems.
+const MAX_JOURNAL_NAME_BYTES = 200
+
+// SanitizeJournalName makes a journal name safe to use as the name of the
+// JSON file within a Day One ZIP file, which Day One names the journal after.
+// Path separators, control characters, and characters that Windows doesn't
+// allow in file names are replaced with spaces. It returns an empty string if
+// nothing usable is left.
+func SanitizeJournalName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r), strings.ContainsRune(`/\:*?"<>|`, r):
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	// Words made up of dots alone, like "..", are special everywhere, and
+	// Windows doesn't allow names that end with dots.
+	words := []string{}
+	for _, w := range strings.Fields(b.String()) {
+		if strings.Trim(w, ".") != "" {
+			words = append(words, w)
+		}
+	}
+	out := strings.TrimRight(strings.Join(words, " "), ". ")
+	for len(out) > MAX_JOURNAL_NAME_BYTES {
+		_, size := utf8.DecodeLastRuneInString(out)
+		out = strings.TrimRight(out[:len(out)-size], ". ")
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeJournalName(t *testing.T) {
+	for name, want := range map[string]string{
+		"From Daylio":            "From Daylio",
+		"Mood 😄 Journal":         "Mood 😄 Journal",
+		"../../etc/passwd":       "etc passwd",
+		`C:\Users\me`:            "C Users me",
+		"Journal: 2023?":         "Journal 2023",
+		"tab\there\nnewline":     "tab here newline",
+		"trailing dots...":       "trailing dots",
+		"..":                     "",
+		"   ":                    "",
+		"bad \xff utf-8":         "bad utf-8",
+		`"quotes" <and> |pipes|`: "quotes and pipes",
+	} {
+		assert.Equal(t, want, SanitizeJournalName(name), name)
+	}
+}
+
+func TestSanitizeJournalNameLength(t *testing.T) {
+	got := SanitizeJournalName(strings.Repeat("é", MAX_JOURNAL_NAME_BYTES))
+	assert.LessOrEqual(t, len(got), MAX_JOURNAL_NAME_BYTES)
+	assert.True(t, utf8.ValidString(got))
+}
+
+func TestInitializeWithSettings(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "exports")
+	got, err := Initialize(ExportSettings{Directory: dir, JournalName: "Daylio/Old"})
+	require.NoError(t, err)
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+	assert.Equal(t, ExportSettings{Directory: dir, JournalName: "Daylio Old"}, got)
+
+	// Existing directories are fine too.
+	_, err = Initialize(ExportSettings{Directory: dir})
+	require.NoError(t, err)
+}
+
+func TestInitializeFromEnvironment(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "from-env")
+	t.Setenv("EXPORT_DIRECTORY", dir)
+	t.Setenv("JOURNAL_NAME", "Env Journal")
+	got, err := Initialize(ExportSettings{})
+	require.NoError(t, err)
+	assert.Equal(t, ExportSettings{Directory: dir, JournalName: "Env Journal"}, got)
+}
+
+func TestInitializeFailures(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+	var settingsErr *SettingsError
+	_, err := Initialize(ExportSettings{Directory: file})
+	require.ErrorAs(t, err, &settingsErr, "export directory is a file")
+	assert.Equal(t, "Directory", settingsErr.Setting)
+	_, err = Initialize(ExportSettings{Directory: t.TempDir(), JournalName: "/../"})
+	require.ErrorAs(t, err, &settingsErr, "journal name is empty once sanitized")
+	assert.Equal(t, "JournalName", settingsErr.Setting)
+}
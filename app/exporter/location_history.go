@@ -0,0 +1,378 @@
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"exporter/types"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DEFAULT_LOCATION_WINDOW is how far from an entry's time a place visit or
+	// point can be and still be given to it.
+	DEFAULT_LOCATION_WINDOW = 30 * time.Minute
+	// DEFAULT_LOCATION_RADIUS is the radius, in metres, of locations that
+	// don't say how accurate they are.
+	DEFAULT_LOCATION_RADIUS = 50
+)
+
+// LocationVisit is time spent at a place.
+type LocationVisit struct {
+	Start    time.Time
+	End      time.Time
+	Location types.DayOneEntryLocation
+}
+
+// LocationPoint is where someone was at a moment. Records.json can hold
+// millions of them, so they're kept as Takeout has them, in degrees times
+// 10^7, and only become a Day One location once they're looked up.
+type LocationPoint struct {
+	Time        time.Time
+	LatitudeE7  int32
+	LongitudeE7 int32
+	// Accuracy is a radius in metres, or 0 when it isn't known.
+	Accuracy int32
+}
+
+// location is the Day One location of a point.
+func (p *LocationPoint) location() types.DayOneEntryLocation {
+	radius := int(p.Accuracy)
+	if radius <= 0 {
+		radius = DEFAULT_LOCATION_RADIUS
+	}
+	return takeoutLocation(int64(p.LatitudeE7), int64(p.LongitudeE7), "", radius)
+}
+
+// LocationHistory is where someone was over time, read from Google Takeout.
+type LocationHistory struct {
+	// Window is how far from an entry's time a visit or point can be and
+	// still be given to it. Defaults to DEFAULT_LOCATION_WINDOW.
+	Window time.Duration
+	visits []LocationVisit
+	points []LocationPoint
+}
+
+// NewLocationHistory sorts visits and points so that they can be looked up.
+func NewLocationHistory(visits []LocationVisit, points []LocationPoint) *LocationHistory {
+	sort.Slice(visits, func(i, j int) bool { return visits[i].Start.Before(visits[j].Start) })
+	sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	return &LocationHistory{visits: visits, points: points}
+}
+
+// takeoutTimelineObject is within a monthly file from "Semantic Location
+// History". Only place visits are used.
+type takeoutTimelineObject struct {
+	PlaceVisit *takeoutPlaceVisit `json:"placeVisit"`
+}
+
+type takeoutPoint struct {
+	LatitudeE7  int32  `json:"latitudeE7"`
+	LongitudeE7 int32  `json:"longitudeE7"`
+	Accuracy    int32  `json:"accuracy"`
+	Timestamp   string `json:"timestamp"`
+	TimestampMs string `json:"timestampMs"`
+}
+
+type takeoutPlaceVisit struct {
+	Location struct {
+		LatitudeE7  int64  `json:"latitudeE7"`
+		LongitudeE7 int64  `json:"longitudeE7"`
+		Name        string `json:"name"`
+		Address     string `json:"address"`
+	} `json:"location"`
+	Duration struct {
+		StartTimestamp   string `json:"startTimestamp"`
+		EndTimestamp     string `json:"endTimestamp"`
+		StartTimestampMs string `json:"startTimestampMs"`
+		EndTimestampMs   string `json:"endTimestampMs"`
+	} `json:"duration"`
+}
+
+// errNotLocationHistory is returned for JSON that isn't location history,
+// which is skipped when reading a folder.
+var errNotLocationHistory = errors.New("Not a Google Takeout location history file")
+
+// ReadLocationHistoryFiles reads Records.json, a monthly file from "Semantic
+// Location History", or a folder of them, i.e. Takeout's "Location History".
+func ReadLocationHistoryFiles(path string) (*LocationHistory, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		visits, points, err := readLocationHistoryFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return NewLocationHistory(visits, points), nil
+	}
+	visits, points := []LocationVisit{}, []LocationPoint{}
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".json") {
+			return err
+		}
+		v, pts, err := readLocationHistoryFile(p)
+		if errors.Is(err, errNotLocationHistory) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		visits, points = append(visits, v...), append(points, pts...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewLocationHistory(visits, points), nil
+}
+
+func readLocationHistoryFile(path string) ([]LocationVisit, []LocationPoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	visits, points, err := ReadLocationHistory(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return visits, points, nil
+}
+
+// ReadLocationHistory reads the place visits and points within a Google
+// Takeout location history file: either Records.json, with "locations", or a
+// monthly file from "Semantic Location History", with "timelineObjects".
+// Points are decoded one at a time, so Records.json is never held in memory.
+func ReadLocationHistory(r io.Reader) ([]LocationVisit, []LocationPoint, error) {
+	d := &takeoutDecoder{dec: json.NewDecoder(r), visits: []LocationVisit{}, points: []LocationPoint{}}
+	if err := d.decode(); err != nil {
+		return nil, nil, invalidOption(err)
+	}
+	if !d.found {
+		return nil, nil, invalidOption(errNotLocationHistory)
+	}
+	return d.visits, d.points, nil
+}
+
+// takeoutDecoder reads a Takeout location history file a token at a time.
+type takeoutDecoder struct {
+	dec    *json.Decoder
+	visits []LocationVisit
+	points []LocationPoint
+	// found is whether there were "locations" or "timelineObjects".
+	found bool
+}
+
+func (d *takeoutDecoder) decode() error {
+	if err := d.expectDelim('{'); err != nil {
+		return err
+	}
+	for d.dec.More() {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return notLocationHistory(err)
+		}
+		switch tok {
+		case "locations":
+			err = d.decodePoints()
+		case "timelineObjects":
+			err = d.decodeTimelineObjects()
+		default:
+			err = d.skipValue()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return d.expectDelim('}')
+}
+
+func (d *takeoutDecoder) decodePoints() error {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return notLocationHistory(err)
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('[') {
+		return notLocationHistory(fmt.Errorf("expected '[', got %v", tok))
+	}
+	d.found = true
+	for idx := 0; d.dec.More(); idx++ {
+		var p takeoutPoint
+		if err := d.dec.Decode(&p); err != nil {
+			return notLocationHistory(err)
+		}
+		t, err := takeoutTime(p.Timestamp, p.TimestampMs)
+		if err != nil {
+			return fmt.Errorf("Location %d: %w", idx+1, err)
+		}
+		if p.LatitudeE7 == 0 && p.LongitudeE7 == 0 {
+			continue
+		}
+		d.points = append(d.points, LocationPoint{Time: t, LatitudeE7: p.LatitudeE7, LongitudeE7: p.LongitudeE7, Accuracy: p.Accuracy})
+	}
+	return d.expectDelim(']')
+}
+
+// decodeTimelineObjects reads timeline objects all at once, since monthly
+// files are small.
+func (d *takeoutDecoder) decodeTimelineObjects() error {
+	var objects *[]takeoutTimelineObject
+	if err := d.dec.Decode(&objects); err != nil {
+		return notLocationHistory(err)
+	}
+	if objects == nil {
+		return nil
+	}
+	d.found = true
+	for idx, o := range *objects {
+		v := o.PlaceVisit
+		if v == nil || (v.Location.LatitudeE7 == 0 && v.Location.LongitudeE7 == 0) {
+			continue
+		}
+		start, err := takeoutTime(v.Duration.StartTimestamp, v.Duration.StartTimestampMs)
+		if err != nil {
+			return fmt.Errorf("Timeline object %d: %w", idx+1, err)
+		}
+		end, err := takeoutTime(v.Duration.EndTimestamp, v.Duration.EndTimestampMs)
+		if err != nil {
+			return fmt.Errorf("Timeline object %d: %w", idx+1, err)
+		}
+		name := v.Location.Name
+		if name == "" {
+			name = v.Location.Address
+		}
+		d.visits = append(d.visits, LocationVisit{
+			Start:    start,
+			End:      end,
+			Location: takeoutLocation(v.Location.LatitudeE7, v.Location.LongitudeE7, name, DEFAULT_LOCATION_RADIUS),
+		})
+	}
+	return nil
+}
+
+// skipValue skips over values we don't use, like "deviceSettings", without
+// decoding them.
+func (d *takeoutDecoder) skipValue() error {
+	depth := 0
+	for {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return notLocationHistory(err)
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func (d *takeoutDecoder) expectDelim(want json.Delim) error {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return notLocationHistory(err)
+	}
+	if tok != want {
+		return notLocationHistory(fmt.Errorf("expected '%s', got %v", want, tok))
+	}
+	return nil
+}
+
+func notLocationHistory(err error) error {
+	return fmt.Errorf("%w: %w", errNotLocationHistory, err)
+}
+
+// takeoutTime reads an RFC 3339 timestamp or, within older exports,
+// milliseconds since the epoch.
+func takeoutTime(timestamp string, timestampMs string) (time.Time, error) {
+	if timestamp != "" {
+		t, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Not a valid timestamp: %s", timestamp)
+		}
+		return t.UTC(), nil
+	}
+	ms, err := strconv.ParseInt(timestampMs, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Not a valid timestamp: '%s'", timestampMs)
+	}
+	return time.UnixMilli(ms).UTC(), nil
+}
+
+// takeoutLocation converts coordinates in degrees times 10^7.
+func takeoutLocation(latE7 int64, lonE7 int64, name string, radius int) types.DayOneEntryLocation {
+	lat, lon := float32(float64(latE7)/1e7), float32(float64(lonE7)/1e7)
+	return types.DayOneEntryLocation{
+		Location: types.DayOneEntryLocationDetails{
+			Region: types.DayOneEntryLocationRegion{
+				Radius: radius,
+				Center: types.DayOneEntryLocationCoords{Latitude: lat, Longitude: lon},
+			},
+		},
+		Latitude:  lat,
+		Longitude: lon,
+		PlaceName: name,
+	}
+}
+
+// Lookup finds where someone was at a time. A place visit during that time
+// wins; otherwise the visit or point nearest in time within the window is
+// used.
+func (h *LocationHistory) Lookup(t time.Time) (types.DayOneEntryLocation, bool) {
+	window := h.Window
+	if window <= 0 {
+		window = DEFAULT_LOCATION_WINDOW
+	}
+	var visit *LocationVisit
+	var point *LocationPoint
+	bestGap, found := window, false
+	consider := func(gap time.Duration, v *LocationVisit, p *LocationPoint) {
+		// Ties go to whatever was considered first, so visits beat points.
+		if gap < bestGap || (!found && gap == bestGap) {
+			visit, point, bestGap, found = v, p, gap, true
+		}
+	}
+	// Visits don't overlap, so only the last visit to start by t and the
+	// first to start after it can be nearest.
+	next := sort.Search(len(h.visits), func(i int) bool { return h.visits[i].Start.After(t) })
+	if next > 0 {
+		v := &h.visits[next-1]
+		if !t.After(v.End) {
+			return v.Location, true
+		}
+		consider(t.Sub(v.End), v, nil)
+	}
+	if next < len(h.visits) {
+		consider(h.visits[next].Start.Sub(t), &h.visits[next], nil)
+	}
+	next = sort.Search(len(h.points), func(i int) bool { return h.points[i].Time.After(t) })
+	if next > 0 {
+		consider(t.Sub(h.points[next-1].Time), nil, &h.points[next-1])
+	}
+	if next < len(h.points) {
+		consider(h.points[next].Time.Sub(t), nil, &h.points[next])
+	}
+	switch {
+	case visit != nil:
+		return visit.Location, true
+	case point != nil:
+		return point.location(), true
+	}
+	return types.DayOneEntryLocation{}, false
+}
@@ -0,0 +1,128 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRecordsJSON = `{"locations": [
+  {"latitudeE7": 418781000, "longitudeE7": -876298000, "accuracy": 20, "timestamp": "2023-12-17T14:05:00.123Z"},
+  {"latitudeE7": 419000000, "longitudeE7": -876500000, "timestampMs": "1702825800000"}
+]}`
+
+const testSemanticJSON = `{"timelineObjects": [
+  {"activitySegment": {"duration": {"startTimestamp": "2023-12-17T13:30:00Z", "endTimestamp": "2023-12-17T13:50:00Z"}}},
+  {"placeVisit": {
+    "location": {"latitudeE7": 418827000, "longitudeE7": -876233000, "name": "Millennium Park", "address": "201 E Randolph St, Chicago"},
+    "duration": {"startTimestamp": "2023-12-17T14:00:00Z", "endTimestamp": "2023-12-17T15:00:00Z"}
+  }}
+]}`
+
+func mustReadLocationHistory(t *testing.T, data ...string) *LocationHistory {
+	visits, points := []LocationVisit{}, []LocationPoint{}
+	for _, d := range data {
+		v, p, err := ReadLocationHistory(strings.NewReader(d))
+		require.NoError(t, err)
+		visits, points = append(visits, v...), append(points, p...)
+	}
+	return NewLocationHistory(visits, points)
+}
+
+func TestReadLocationHistoryRecords(t *testing.T) {
+	_, points, err := ReadLocationHistory(strings.NewReader(testRecordsJSON))
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.Equal(t, "2023-12-17T14:05:00Z", points[0].Time.Truncate(time.Second).Format(time.RFC3339))
+	assert.Equal(t, LocationPoint{Time: points[0].Time, LatitudeE7: 418781000, LongitudeE7: -876298000, Accuracy: 20}, points[0])
+	loc := points[0].location()
+	assert.InDelta(t, 41.8781, loc.Latitude, 0.0001)
+	assert.InDelta(t, -87.6298, loc.Location.Region.Center.Longitude, 0.0001)
+	assert.Equal(t, 20, loc.Location.Region.Radius)
+	assert.Equal(t, "2023-12-17T15:10:00Z", points[1].Time.Format(time.RFC3339))
+	assert.Equal(t, DEFAULT_LOCATION_RADIUS, points[1].location().Location.Region.Radius)
+}
+
+func TestReadLocationHistorySkipsUnknownKeys(t *testing.T) {
+	data := `{"settings": {"nested": [[], {}]}, "locations": [
+  {"latitudeE7": 0, "longitudeE7": 0, "timestamp": "2023-12-17T14:00:00Z"},
+  {"latitudeE7": 418781000, "longitudeE7": -876298000, "timestamp": "2023-12-17T14:05:00Z"}
+], "version": 2}`
+	visits, points, err := ReadLocationHistory(strings.NewReader(data))
+	require.NoError(t, err)
+	assert.Empty(t, visits)
+	require.Len(t, points, 1, "points without coordinates are left out")
+	assert.Equal(t, int32(418781000), points[0].LatitudeE7)
+}
+
+func TestReadLocationHistorySemantic(t *testing.T) {
+	visits, points, err := ReadLocationHistory(strings.NewReader(testSemanticJSON))
+	require.NoError(t, err)
+	assert.Empty(t, points)
+	require.Len(t, visits, 1)
+	assert.Equal(t, "Millennium Park", visits[0].Location.PlaceName)
+	assert.Equal(t, time.Hour, visits[0].End.Sub(visits[0].Start))
+}
+
+func TestReadLocationHistoryFailures(t *testing.T) {
+	for name, data := range map[string]string{
+		"not JSON":      "locations",
+		"other JSON":    `{"settings": {}}`,
+		"not an object": `[]`,
+		"locations":     `{"locations": {}}`,
+		"truncated":     `{"locations": [{"latitudeE7": 1`,
+		"bad timestamp": `{"locations": [{"latitudeE7": 1, "longitudeE7": 1, "timestamp": "yesterday"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := ReadLocationHistory(strings.NewReader(data))
+			assert.ErrorIs(t, err, ErrInvalidOption)
+		})
+	}
+}
+
+func TestReadLocationHistoryFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Semantic Location History", "2023"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Records.json"), []byte(testRecordsJSON), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Semantic Location History", "2023", "2023_DECEMBER.json"), []byte(testSemanticJSON), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Settings.json"), []byte(`{"deviceSettings": []}`), 0o644))
+
+	h, err := ReadLocationHistoryFiles(dir)
+	require.NoError(t, err)
+	assert.Len(t, h.visits, 1)
+	assert.Len(t, h.points, 2)
+
+	_, err = ReadLocationHistoryFiles(filepath.Join(dir, "Settings.json"))
+	assert.ErrorIs(t, err, ErrInvalidOption, "single files must be location history")
+}
+
+func TestLocationHistoryLookup(t *testing.T) {
+	h := mustReadLocationHistory(t, testRecordsJSON, testSemanticJSON)
+	at := func(s string) time.Time {
+		t, _ := time.Parse(time.RFC3339, s)
+		return t
+	}
+	got, ok := h.Lookup(at("2023-12-17T14:05:00Z"))
+	require.True(t, ok)
+	assert.Equal(t, "Millennium Park", got.PlaceName, "visits during the time win over nearer points")
+
+	got, ok = h.Lookup(at("2023-12-17T15:20:00Z"))
+	require.True(t, ok)
+	assert.Equal(t, "", got.PlaceName, "the 15:10 point is nearer than the end of the visit")
+	assert.InDelta(t, 41.9, got.Latitude, 0.0001)
+
+	got, ok = h.Lookup(at("2023-12-17T13:45:00Z"))
+	require.True(t, ok)
+	assert.Equal(t, "Millennium Park", got.PlaceName)
+
+	_, ok = h.Lookup(at("2023-12-17T12:00:00Z"))
+	assert.False(t, ok)
+	h.Window = 3 * time.Hour
+	_, ok = h.Lookup(at("2023-12-17T12:00:00Z"))
+	assert.True(t, ok)
+}
@@ -0,0 +1,120 @@
+package exporter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"exporter/types"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MANIFEST_FILE_EXTENSION replaces the extension of the Day One ZIP file that
+// a manifest describes, i.e. "export-20231217.manifest.json".
+const MANIFEST_FILE_EXTENSION = ".manifest.json"
+
+// Manifest records how an export was made, so that it can be traced back to
+// the backup it came from and checked for corruption later on.
+type Manifest struct {
+	Tool       ManifestTool `json:"tool"`
+	CreatedAt  time.Time    `json:"created_at"`
+	Sources    []FileDigest `json:"sources"`
+	EntryCount int          `json:"entry_count"`
+	// FirstEntry and LastEntry are when the oldest and newest entries were
+	// created.
+	FirstEntry *time.Time `json:"first_entry,omitempty"`
+	LastEntry  *time.Time `json:"last_entry,omitempty"`
+	// Options are the options that the export was run with.
+	Options map[string]string `json:"options"`
+	Files   []FileDigest      `json:"files"`
+}
+
+// ManifestTool identifies the version of the exporter that made an export.
+type ManifestTool struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+}
+
+// FileDigest is the SHA-256 checksum of a file.
+type FileDigest struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// NewManifest describes an export run along with the files it produced.
+func NewManifest(summary *RunSummary, results []SinkResult, options map[string]string) (*Manifest, error) {
+	m := Manifest{
+		Tool:       ManifestTool{Version: VERSION, Commit: COMMIT_SHA},
+		CreatedAt:  time.Now().UTC(),
+		Sources:    []FileDigest{},
+		EntryCount: summary.EntryCount,
+		Options:    options,
+		Files:      []FileDigest{},
+	}
+	if m.Options == nil {
+		m.Options = map[string]string{}
+	}
+	if !summary.FirstEntry.IsZero() {
+		first, last := summary.FirstEntry.UTC(), summary.LastEntry.UTC()
+		m.FirstEntry, m.LastEntry = &first, &last
+	}
+	for _, src := range summary.Sources {
+		d, err := digestFile(src)
+		if err != nil {
+			return nil, err
+		}
+		m.Sources = append(m.Sources, d)
+	}
+	for _, r := range results {
+		for _, f := range r.Files() {
+			d, err := digestFile(f)
+			if err != nil {
+				return nil, err
+			}
+			m.Files = append(m.Files, d)
+		}
+	}
+	return &m, nil
+}
+
+// WriteManifest writes a manifest next to the Day One ZIP file it describes,
+// or into dir, the export directory, when there isn't one.
+func WriteManifest(m *Manifest, results []SinkResult, dir string) (string, error) {
+	return writeExportFile(manifestFileName(results, dir), 0o644, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	})
+}
+
+func manifestFileName(results []SinkResult, dir string) string {
+	for _, r := range results {
+		if r, ok := r.(*types.DayOneExportResult); ok {
+			dir, base := filepath.Split(r.ZipFile)
+			if idx := strings.Index(base, "."); idx > 0 {
+				base = base[:idx]
+			}
+			return filepath.Join(dir, base+MANIFEST_FILE_EXTENSION)
+		}
+	}
+	return filepath.Join(dir, fmt.Sprintf("export-%s%s", time.Now().Format("20060102"), MANIFEST_FILE_EXTENSION))
+}
+
+func digestFile(path string) (FileDigest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FileDigest{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return FileDigest{}, err
+	}
+	return FileDigest{Path: path, SHA256: hex.EncodeToString(h.Sum(nil)), Bytes: n}, nil
+}
@@ -0,0 +1,70 @@
+package exporter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"exporter/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustWriteFile(t *testing.T, name string, data string) string {
+	require.NoError(t, os.WriteFile(name, []byte(data), 0o644))
+	return name
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestNewManifest(t *testing.T) {
+	dir := t.TempDir()
+	backup := mustWriteFile(t, filepath.Join(dir, "backup.daylio"), "backup")
+	zipFile := mustWriteFile(t, filepath.Join(dir, "export-20231217.zip"), "zip")
+	first := time.Date(2023, time.December, 15, 8, 0, 0, 0, time.UTC)
+	last := time.Date(2023, time.December, 17, 8, 0, 0, 0, time.UTC)
+	summary := &RunSummary{
+		Sources:    []string{backup},
+		EntryCount: 3,
+		FirstEntry: first,
+		LastEntry:  last,
+	}
+	results := []SinkResult{&types.DayOneExportResult{ZipFile: zipFile}}
+	m, err := NewManifest(summary, results, map[string]string{"format": "dayone"})
+	require.NoError(t, err)
+	assert.Equal(t, ManifestTool{Version: VERSION, Commit: COMMIT_SHA}, m.Tool)
+	assert.Equal(t, []FileDigest{{Path: backup, SHA256: sha256Hex("backup"), Bytes: 6}}, m.Sources)
+	assert.Equal(t, []FileDigest{{Path: zipFile, SHA256: sha256Hex("zip"), Bytes: 3}}, m.Files)
+	assert.Equal(t, 3, m.EntryCount)
+	assert.Equal(t, &first, m.FirstEntry)
+	assert.Equal(t, &last, m.LastEntry)
+	assert.Equal(t, map[string]string{"format": "dayone"}, m.Options)
+
+	name, err := WriteManifest(m, results, dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "export-20231217.manifest.json"), name)
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	var got Manifest
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, m.Files, got.Files)
+}
+
+func TestNewManifestWithMissingFile(t *testing.T) {
+	_, err := NewManifest(&RunSummary{}, []SinkResult{&mockSinkResult{files: []string{"missing.ndjson"}}}, nil)
+	assert.Error(t, err)
+}
+
+func TestManifestFileName(t *testing.T) {
+	assert.Equal(t, filepath.Join("exports", "export-20231217-2.manifest.json"), manifestFileName([]SinkResult{
+		&mockSinkResult{files: []string{"exports/entries.ndjson"}},
+		&types.DayOneExportResult{ZipFile: filepath.Join("exports", "export-20231217-2.zip.enc")},
+	}, "elsewhere"))
+}
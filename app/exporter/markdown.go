@@ -0,0 +1,263 @@
+package exporter
+
+import (
+	"bytes"
+	"exporter/daylio"
+	"exporter/types"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DEFAULT_MARKDOWN_DIRECTORY = "markdown"
+	MARKDOWN_SINK_NAME         = "markdown"
+)
+
+// MarkdownLayout decides how Daylio entries are split into Markdown files.
+type MarkdownLayout string
+
+const (
+	// MarkdownLayoutPerEntry writes one file per Daylio entry.
+	MarkdownLayoutPerEntry MarkdownLayout = "entry"
+	// MarkdownLayoutPerDay writes one file per day, with every entry from that
+	// day in it.
+	MarkdownLayoutPerDay MarkdownLayout = "day"
+)
+
+// MarkdownDocument is a single Markdown file within a vault.
+type MarkdownDocument struct {
+	// Path is relative to the root of the vault, i.e. "2023/12/2023-12-17.md"
+	Path    string
+	Content string
+}
+
+// MarkdownExportResult provides details about a Markdown export.
+type MarkdownExportResult struct {
+	Directory string
+	Written   []string
+}
+
+// Files lists the Markdown files written by the export.
+func (r *MarkdownExportResult) Files() []string {
+	return r.Written
+}
+
+type markdownFrontMatter struct {
+	Date       string                    `yaml:"date"`
+	Mood       string                    `yaml:"mood,omitempty"`
+	Moods      []string                  `yaml:"moods,omitempty"`
+	MoodScore  float64                   `yaml:"mood_score"`
+	Activities []string                  `yaml:"activities"`
+	Location   *markdownFrontMatterPlace `yaml:"location,omitempty"`
+}
+
+type markdownFrontMatterPlace struct {
+	Name      string  `yaml:"name,omitempty"`
+	Locality  string  `yaml:"locality,omitempty"`
+	Country   string  `yaml:"country,omitempty"`
+	Latitude  float32 `yaml:"latitude"`
+	Longitude float32 `yaml:"longitude"`
+}
+
+// ParseMarkdownLayout turns a layout name into a MarkdownLayout.
+func ParseMarkdownLayout(s string) (MarkdownLayout, error) {
+	switch MarkdownLayout(strings.ToLower(s)) {
+	case MarkdownLayoutPerEntry:
+		return MarkdownLayoutPerEntry, nil
+	case MarkdownLayoutPerDay:
+		return MarkdownLayoutPerDay, nil
+	default:
+		return "", invalidOption(fmt.Errorf("Not a valid Markdown layout: %s", s))
+	}
+}
+
+// MarkdownSink writes converted entries into a folder of Markdown files with
+// YAML front matter, i.e. for Obsidian.
+type MarkdownSink struct {
+	Layout MarkdownLayout
+	// Directory is where files are written. Required.
+	Directory string
+}
+
+func (s *MarkdownSink) Name() string {
+	return MARKDOWN_SINK_NAME
+}
+
+func (s *MarkdownSink) Write(entries []ConvertedEntry, summary *RunSummary) (SinkResult, error) {
+	dir, err := sinkDirectory(s.Directory)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := convertToMarkdownDocuments(daylioEntriesOnly(entries), s.Layout)
+	if err != nil {
+		return nil, err
+	}
+	r, err := writeMarkdownExports(dir, docs)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func writeMarkdownExports(dir string, docs []MarkdownDocument) (*MarkdownExportResult, error) {
+	r := MarkdownExportResult{Directory: filepath.Join(dir, DEFAULT_MARKDOWN_DIRECTORY)}
+	for _, doc := range docs {
+		fp := filepath.Join(r.Directory, doc.Path)
+		if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+			return nil, &WriteError{Path: filepath.Dir(fp), Err: err}
+		}
+		f, err := createExclusiveExportFile(fp, 0644)
+		if err != nil {
+			return nil, &WriteError{Path: fp, Err: err}
+		}
+		_, err = f.WriteString(doc.Content)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, &WriteError{Path: f.Name(), Err: err}
+		}
+		r.Written = append(r.Written, f.Name())
+	}
+	return &r, nil
+}
+
+func convertToMarkdownDocuments(entries []ConvertedEntry, layout MarkdownLayout) ([]MarkdownDocument, error) {
+	switch layout {
+	case MarkdownLayoutPerDay:
+		return convertToMarkdownDocumentsPerDay(entries)
+	case MarkdownLayoutPerEntry, "":
+		return convertToMarkdownDocumentsPerEntry(entries)
+	default:
+		return nil, fmt.Errorf("Not a valid Markdown layout: %s", layout)
+	}
+}
+
+func convertToMarkdownDocumentsPerEntry(entries []ConvertedEntry) ([]MarkdownDocument, error) {
+	docs := []MarkdownDocument{}
+	seen := map[string]int{}
+	for idx := range entries {
+		entry := entries[idx].Source
+		ts := time.Time(entries[idx].DayOne.CreationDate)
+		fm := markdownFrontMatter{
+			Date:       ts.Format(time.RFC3339),
+			Mood:       entry.Mood,
+			MoodScore:  float64(daylio.MoodScore(entry.Mood)),
+			Activities: entry.ActivityNames(),
+			Location:   markdownLocation(entries[idx].DayOne.Location),
+		}
+		content, err := renderMarkdown(&fm, markdownEntryBody(&entry, 1))
+		if err != nil {
+			return nil, err
+		}
+		name := ts.Format("2006-01-02-1504")
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, seen[name])
+		}
+		docs = append(docs, MarkdownDocument{
+			Path:    markdownDocumentPath(ts, name),
+			Content: content,
+		})
+	}
+	return docs, nil
+}
+
+func convertToMarkdownDocumentsPerDay(entries []ConvertedEntry) ([]MarkdownDocument, error) {
+	days := []string{}
+	byDay := map[string][]ConvertedEntry{}
+	for _, entry := range entries {
+		day := entry.Source.FullDate
+		if _, ok := byDay[day]; !ok {
+			days = append(days, day)
+		}
+		byDay[day] = append(byDay[day], entry)
+	}
+	docs := []MarkdownDocument{}
+	for _, day := range days {
+		dayEntries := byDay[day]
+		ts := time.Time(dayEntries[0].DayOne.CreationDate)
+		fm := markdownFrontMatter{Date: day, Activities: []string{}}
+		seenActivities := map[string]bool{}
+		var body strings.Builder
+		var totalScore int
+		for idx := range dayEntries {
+			entry := dayEntries[idx].Source
+			fm.Moods = append(fm.Moods, entry.Mood)
+			totalScore += daylio.MoodScore(entry.Mood)
+			for _, a := range entry.ActivityNames() {
+				if !seenActivities[a] {
+					seenActivities[a] = true
+					fm.Activities = append(fm.Activities, a)
+				}
+			}
+			if fm.Location == nil {
+				fm.Location = markdownLocation(dayEntries[idx].DayOne.Location)
+			}
+			if idx > 0 {
+				body.WriteString("\n")
+			}
+			body.WriteString(markdownEntryBody(&entry, 2))
+		}
+		fm.MoodScore = float64(totalScore) / float64(len(dayEntries))
+		content, err := renderMarkdown(&fm, fmt.Sprintf("# %s\n\n%s", day, body.String()))
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, MarkdownDocument{
+			Path:    markdownDocumentPath(ts, day),
+			Content: content,
+		})
+	}
+	return docs, nil
+}
+
+func markdownDocumentPath(ts time.Time, name string) string {
+	return filepath.Join(ts.Format("2006"), ts.Format("01"), name+".md")
+}
+
+func renderMarkdown(fm *markdownFrontMatter, body string) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(fm); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	buf.WriteString("---\n\n")
+	buf.WriteString(body)
+	return buf.String(), nil
+}
+
+// markdownEntryBody renders a Daylio note under a heading of the given level.
+func markdownEntryBody(entry *daylio.Entry, headerLevel int) string {
+	title := entry.NoteTitle
+	if title == "" {
+		title = "Note"
+	}
+	if headerLevel > 1 {
+		title = fmt.Sprintf("%s (%s, %s)", title, entry.Time, entry.Mood)
+	}
+	return fmt.Sprintf("%s %s\n\n%s\n", strings.Repeat("#", headerLevel), title, entry.Note)
+}
+
+func markdownLocation(loc types.DayOneEntryLocation) *markdownFrontMatterPlace {
+	if loc == (types.DayOneEntryLocation{}) {
+		return nil
+	}
+	return &markdownFrontMatterPlace{
+		Name:      loc.PlaceName,
+		Locality:  loc.LocalityName,
+		Country:   loc.Country,
+		Latitude:  loc.Location.Region.Center.Latitude,
+		Longitude: loc.Location.Region.Center.Longitude,
+	}
+}
@@ -0,0 +1,143 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"exporter/daylio"
+	"exporter/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	csv "github.com/gocarina/gocsv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustGetMockDaylioEntries(t *testing.T) []daylio.Entry {
+	var entries []daylio.Entry
+	mockEntries, err := os.OpenFile("./fixtures/daylio.csv", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	defer mockEntries.Close()
+	require.NoError(t, csv.UnmarshalFile(mockEntries, &entries))
+	return entries
+}
+
+func mustConvertEntries(t *testing.T, entries []daylio.Entry) []ConvertedEntry {
+	return mustConvertEntriesWith(t, entries, ConvertOptions{})
+}
+
+func mustConvertEntriesWith(t *testing.T, entries []daylio.Entry, opts ConvertOptions) []ConvertedEntry {
+	converted, err := convertEntries(context.Background(), entries, types.DefaultDayOneGenerators(), opts)
+	require.NoError(t, err)
+	return converted
+}
+
+func mustReadHomeLocation(t *testing.T) *types.DayOneEntryLocation {
+	locJSON, err := os.ReadFile("./fixtures/home_location.json")
+	require.NoError(t, err)
+	var home types.DayOneEntryLocation
+	require.NoError(t, json.Unmarshal(locJSON, &home))
+	return &home
+}
+
+func TestConvertToMarkdownPerEntry(t *testing.T) {
+	entries := mustGetMockDaylioEntries(t)
+	got, err := convertToMarkdownDocuments(mustConvertEntries(t, entries), MarkdownLayoutPerEntry)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, filepath.Join("2023", "12", "2023-12-17-0800.md"), got[0].Path)
+	want := `---
+date: "2023-12-17T08:00:00Z"
+mood: good
+mood_score: 4
+activities:
+  - activity 1
+  - activity 2
+  - activity 3
+---
+
+# note title
+
+note text 1
+`
+	assert.Equal(t, want, got[0].Content)
+}
+
+func TestConvertToMarkdownPerDay(t *testing.T) {
+	entries := []daylio.Entry{
+		{FullDate: "2023-12-17", Time: "08:00", Mood: "good", Activities: "activity 1", Note: "morning"},
+		{FullDate: "2023-12-17", Time: "20:00", Mood: "bad", Activities: "activity 1 | activity 2", NoteTitle: "evening", Note: "night"},
+	}
+	got, err := convertToMarkdownDocuments(mustConvertEntries(t, entries), MarkdownLayoutPerDay)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, filepath.Join("2023", "12", "2023-12-17.md"), got[0].Path)
+	want := `---
+date: "2023-12-17"
+moods:
+  - good
+  - bad
+mood_score: 3
+activities:
+  - activity 1
+  - activity 2
+---
+
+# 2023-12-17
+
+## Note (08:00, good)
+
+morning
+
+## evening (20:00, bad)
+
+night
+`
+	assert.Equal(t, want, got[0].Content)
+}
+
+func TestConvertToMarkdownWithHomeLocation(t *testing.T) {
+	entries := []daylio.Entry{
+		{FullDate: "2023-12-17", Time: "08:00", Mood: "rad", ActivitiesList: []string{"home", "mood: rad"}},
+	}
+	converted := mustConvertEntriesWith(t, entries, ConvertOptions{HomeLocation: mustReadHomeLocation(t)})
+	got, err := convertToMarkdownDocuments(converted, MarkdownLayoutPerEntry)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Contains(t, got[0].Content, `activities:
+  - home
+location:
+  latitude: 10
+  longitude: -10
+`)
+}
+
+func TestConvertToMarkdownDuplicateTimes(t *testing.T) {
+	entries := []daylio.Entry{
+		{FullDate: "2023-12-17", Time: "08:00", Mood: "good"},
+		{FullDate: "2023-12-17", Time: "08:00", Mood: "bad"},
+	}
+	got, err := convertToMarkdownDocuments(mustConvertEntries(t, entries), MarkdownLayoutPerEntry)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, filepath.Join("2023", "12", "2023-12-17-0800-2.md"), got[1].Path)
+}
+
+func TestWritingMarkdownNeverOverwrites(t *testing.T) {
+	dir := t.TempDir()
+	docs := []MarkdownDocument{{Path: filepath.Join("2023", "12", "2023-12-17-0800.md"), Content: "first"}}
+	_, err := writeMarkdownExports(dir, docs)
+	require.NoError(t, err)
+	docs[0].Content = "second"
+	got, err := writeMarkdownExports(dir, docs)
+	require.NoError(t, err)
+	folder := filepath.Join(dir, DEFAULT_MARKDOWN_DIRECTORY, "2023", "12")
+	assert.Equal(t, []string{filepath.Join(folder, "2023-12-17-0800-2.md")}, got.Written)
+	first, err := os.ReadFile(filepath.Join(folder, "2023-12-17-0800.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(first))
+	second, err := os.ReadFile(got.Written[0])
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(second))
+}
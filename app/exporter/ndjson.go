@@ -0,0 +1,188 @@
+package exporter
+
+import (
+	"encoding/json"
+	"exporter/daylio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+const (
+	NDJSON_SINK_NAME = "ndjson"
+)
+
+// NDJSONRecord is a normalized Daylio entry meant for data analysis. One is
+// written per line.
+type NDJSONRecord struct {
+	// Timestamp is formatted as RFC 3339, i.e. "2023-12-17T08:00:00-06:00".
+	Timestamp       string           `json:"timestamp"`
+	Mood            NDJSONMood       `json:"mood"`
+	Activities      []NDJSONActivity `json:"activities"`
+	Note            NDJSONNote       `json:"note"`
+	AttachmentCount int              `json:"attachment_count"`
+}
+
+// NDJSONMood is an entry's mood.
+type NDJSONMood struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+	// Level is between 1 (awful) and 5 (rad).
+	Level int `json:"level"`
+}
+
+// NDJSONActivity is an activity and the group it's in, if known.
+type NDJSONActivity struct {
+	Name  string `json:"name"`
+	Group string `json:"group,omitempty"`
+}
+
+// NDJSONNote is an entry's note.
+type NDJSONNote struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// NDJSONExportResult provides details about an NDJSON export.
+type NDJSONExportResult struct {
+	File    string
+	Records int
+}
+
+// Files lists the NDJSON file written by the export.
+func (r *NDJSONExportResult) Files() []string {
+	return []string{r.File}
+}
+
+// NDJSONWriter writes entries as newline-delimited JSON, one at a time, so that
+// an entire export never needs to be held in memory.
+type NDJSONWriter struct {
+	enc     *json.Encoder
+	written int
+}
+
+// NewNDJSONWriter creates an NDJSONWriter that writes to w.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &NDJSONWriter{enc: enc}
+}
+
+// WriteEntry writes a single entry as one line of JSON.
+func (w *NDJSONWriter) WriteEntry(entry *ConvertedEntry) error {
+	if err := w.enc.Encode(newNDJSONRecord(entry)); err != nil {
+		return err
+	}
+	w.written++
+	return nil
+}
+
+// NDJSONSink writes converted entries into a newline-delimited JSON file. It's
+// a StreamingSink, so each line can be written as soon as its entry is
+// converted.
+type NDJSONSink struct {
+	// Directory is where the file is written. Required.
+	Directory string
+}
+
+func (s *NDJSONSink) Name() string {
+	return NDJSON_SINK_NAME
+}
+
+func (s *NDJSONSink) Write(entries []ConvertedEntry, summary *RunSummary) (SinkResult, error) {
+	w, err := s.Open()
+	if err != nil {
+		return nil, err
+	}
+	for idx := range entries {
+		if err := w.WriteEntry(&entries[idx]); err != nil {
+			w.Abort()
+			return nil, err
+		}
+	}
+	return w.Close()
+}
+
+// Open starts writing the NDJSON file. It appears once it's closed.
+func (s *NDJSONSink) Open() (EntryWriter, error) {
+	dir, err := sinkDirectory(s.Directory)
+	if err != nil {
+		return nil, err
+	}
+	f, err := createExportFile(ndjsonFileName(dir), 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &ndjsonEntryWriter{file: f, w: NewNDJSONWriter(f)}, nil
+}
+
+// ndjsonEntryWriter writes an NDJSON file as entries are converted.
+type ndjsonEntryWriter struct {
+	file *exportFileWriter
+	w    *NDJSONWriter
+}
+
+// WriteEntry leaves out synthetic entries.
+func (e *ndjsonEntryWriter) WriteEntry(entry *ConvertedEntry) error {
+	if entry.Synthetic {
+		return nil
+	}
+	if err := e.w.WriteEntry(entry); err != nil {
+		return &WriteError{Path: e.file.name, Err: err}
+	}
+	return nil
+}
+
+func (e *ndjsonEntryWriter) Close() (SinkResult, error) {
+	name, err := e.file.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return &NDJSONExportResult{File: name, Records: e.w.written}, nil
+}
+
+func (e *ndjsonEntryWriter) Abort() {
+	e.file.Discard()
+}
+
+func ndjsonFileName(dir string) string {
+	return filepath.Join(dir, fmt.Sprintf("entries-%s.ndjson", time.Now().Format("20060102")))
+}
+
+func newNDJSONRecord(entry *ConvertedEntry) NDJSONRecord {
+	src := entry.Source
+	ts := localCreationTime(entry)
+	moodID := src.MoodID
+	if moodID == 0 {
+		moodID = daylio.MoodIDFromName(src.Mood)
+	}
+	return NDJSONRecord{
+		Timestamp: ts.Format(time.RFC3339),
+		Mood: NDJSONMood{
+			ID:    moodID,
+			Label: src.Mood,
+			Level: daylio.MoodScore(src.Mood),
+		},
+		Activities: ndjsonActivities(&src),
+		Note: NDJSONNote{
+			Title: src.NoteTitle,
+			Body:  src.Note,
+		},
+		AttachmentCount: len(entry.Attachments),
+	}
+}
+
+func ndjsonActivities(entry *daylio.Entry) []NDJSONActivity {
+	out := []NDJSONActivity{}
+	if len(entry.ActivityDetails) > 0 {
+		for _, a := range entry.ActivityDetails {
+			out = append(out, NDJSONActivity{Name: a.Name, Group: a.Group})
+		}
+		return out
+	}
+	for _, a := range entry.ActivityNames() {
+		out = append(out, NDJSONActivity{Name: a})
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package exporter
+
+import (
+	"bytes"
+	"exporter/daylio"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritingNDJSONFromBackupEntry(t *testing.T) {
+	entries := mustConvertEntries(t, []daylio.Entry{
+		{
+			FullDate:       "2023-12-17",
+			Time:           "14:00",
+			Mood:           "rad",
+			MoodID:         1,
+			ActivitiesList: []string{"friends", "mood: rad"},
+			ActivityDetails: []daylio.Activity{
+				{Name: "friends", Group: "Social"},
+			},
+			TimeZoneOffset: -21600000,
+			NoteTitle:      "title",
+			Note:           "<b>note</b>",
+		},
+	})
+	entries[0].Attachments = []Attachment{{Name: "photo.jpg"}}
+	var buf bytes.Buffer
+	w := NewNDJSONWriter(&buf)
+	require.NoError(t, w.WriteEntry(&entries[0]))
+	want := `{"timestamp":"2023-12-17T08:00:00-06:00","mood":{"id":1,"label":"rad","level":5},` +
+		`"activities":[{"name":"friends","group":"Social"}],"note":{"title":"title","body":"<b>note</b>"},` +
+		`"attachment_count":1}` + "\n"
+	assert.Equal(t, want, buf.String())
+}
+
+func TestWritingNDJSONFromCSVEntries(t *testing.T) {
+	entries := mustConvertEntries(t, mustGetMockDaylioEntries(t))
+	var buf bytes.Buffer
+	w := NewNDJSONWriter(&buf)
+	for idx := range entries {
+		require.NoError(t, w.WriteEntry(&entries[idx]))
+	}
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 3)
+	want := `{"timestamp":"2023-12-15T08:00:00Z","mood":{"id":2,"label":"good","level":4},` +
+		`"activities":[{"name":"activity 1"}],"note":{"title":"","body":"note text 3"},` +
+		`"attachment_count":0}`
+	assert.Equal(t, want, string(lines[2]))
+}
+
+func TestStreamingNDJSONSinkWritesOnlyOnceClosed(t *testing.T) {
+	dir := t.TempDir()
+	entries := mustConvertEntries(t, mustGetMockDaylioEntries(t))
+	entries = append(entries, ConvertedEntry{Synthetic: true})
+	w, err := (&NDJSONSink{Directory: dir}).Open()
+	require.NoError(t, err)
+	for idx := range entries {
+		require.NoError(t, w.WriteEntry(&entries[idx]))
+	}
+	written, err := filepath.Glob(filepath.Join(dir, "*.ndjson"))
+	require.NoError(t, err)
+	assert.Empty(t, written)
+	out, err := w.Close()
+	require.NoError(t, err)
+	result := out.(*NDJSONExportResult)
+	assert.Equal(t, 3, result.Records)
+	data, err := os.ReadFile(result.File)
+	require.NoError(t, err)
+	assert.Len(t, bytes.Split(bytes.TrimSpace(data), []byte("\n")), 3)
+}
+
+func TestAbortingStreamingNDJSONSinkLeavesNothing(t *testing.T) {
+	dir := t.TempDir()
+	entries := mustConvertEntries(t, mustGetMockDaylioEntries(t))
+	w, err := (&NDJSONSink{Directory: dir}).Open()
+	require.NoError(t, err)
+	require.NoError(t, w.WriteEntry(&entries[0]))
+	w.Abort()
+	left, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, left)
+}
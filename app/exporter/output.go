@@ -0,0 +1,210 @@
+package exporter
+
+import (
+	"bufio"
+	"errors"
+	"exporter/daylio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MAX_EXPORT_FILE_SUFFIX is the most exports with the same name that can be
+// written to a folder, i.e. "export-20231217-999.zip".
+const MAX_EXPORT_FILE_SUFFIX = 999
+
+// writeExportFile writes an export so that it either appears complete under
+// its final name or not at all, even if the exporter crashes midway.
+//
+// Everything is written to a temporary file within the same folder, which is
+// flushed and synced to disk before it's linked into place. Earlier exports
+// are never overwritten: when the name is taken, "-2", "-3", and so on are
+// added before its extension, i.e. "export-20231217-2.zip.enc". It returns the
+// name that was used.
+func writeExportFile(name string, perm os.FileMode, write func(w io.Writer) error) (string, error) {
+	written, err := writeExportFileAtomically(name, perm, write)
+	if err != nil {
+		return "", &WriteError{Path: name, Err: err}
+	}
+	return written, nil
+}
+
+func writeExportFileAtomically(name string, perm os.FileMode, write func(w io.Writer) error) (written string, err error) {
+	tmp, err := createTempExportFile(name)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		// The temporary file is either linked into place or failed, so it's
+		// never needed afterwards.
+		if rmErr := os.Remove(tmp.Name()); rmErr != nil && !os.IsNotExist(rmErr) && err == nil {
+			err = rmErr
+		}
+	}()
+	if err := writeAndSync(tmp, perm, write); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	return linkTempExportFile(tmp, name)
+}
+
+// exportFileWriter writes an export a piece at a time, i.e. while entries are
+// still being converted. Like writeExportFile, the export either appears
+// complete once it's committed, or not at all.
+type exportFileWriter struct {
+	name string
+	perm os.FileMode
+	tmp  *os.File
+	buf  *bufio.Writer
+}
+
+// createExportFile starts writing an export. Commit or Discard it once
+// everything has been written.
+func createExportFile(name string, perm os.FileMode) (*exportFileWriter, error) {
+	tmp, err := createTempExportFile(name)
+	if err != nil {
+		return nil, &WriteError{Path: name, Err: err}
+	}
+	return &exportFileWriter{name: name, perm: perm, tmp: tmp, buf: bufio.NewWriter(tmp)}, nil
+}
+
+func (w *exportFileWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Commit gives the export its final name, like writeExportFile does, and
+// returns the name that was used.
+func (w *exportFileWriter) Commit() (string, error) {
+	defer w.Discard()
+	if err := flushAndSync(w.tmp, w.buf, w.perm); err != nil {
+		return "", &WriteError{Path: w.name, Err: err}
+	}
+	written, err := linkTempExportFile(w.tmp, w.name)
+	if err != nil {
+		return "", &WriteError{Path: w.name, Err: err}
+	}
+	return written, nil
+}
+
+// Discard throws away everything written so far. It does nothing once the
+// export has been committed.
+func (w *exportFileWriter) Discard() {
+	w.tmp.Close()
+	os.Remove(w.tmp.Name())
+}
+
+func createTempExportFile(name string) (*os.File, error) {
+	dir, base := filepath.Split(name)
+	if dir == "" {
+		dir = "."
+	}
+	return os.CreateTemp(dir, "."+base+"-*.tmp")
+}
+
+// linkTempExportFile closes a finished temporary file and links it into
+// place. The temporary file is left for the caller to remove.
+func linkTempExportFile(tmp *os.File, name string) (string, error) {
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	written, err := linkExportFile(tmp.Name(), name)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Dir(name)
+	if err := syncDirectory(dir); err != nil {
+		return "", err
+	}
+	return written, nil
+}
+
+// exportFile is the part of *os.File used while writing exports.
+type exportFile interface {
+	io.Writer
+	Chmod(mode os.FileMode) error
+	Sync() error
+}
+
+func writeAndSync(f exportFile, perm os.FileMode, write func(w io.Writer) error) error {
+	buf := bufio.NewWriter(f)
+	if err := write(buf); err != nil {
+		return err
+	}
+	return flushAndSync(f, buf, perm)
+}
+
+func flushAndSync(f exportFile, buf *bufio.Writer, perm os.FileMode) error {
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if err := chmodExportFile(f, perm); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// linkExportFile gives a finished file the first name that isn't taken. Hard
+// links fail when their name exists, which makes this safe from races with
+// other exports, unlike renaming.
+func linkExportFile(tmp string, name string) (string, error) {
+	for n := 1; n <= MAX_EXPORT_FILE_SUFFIX; n++ {
+		candidate := exportFileCandidate(name, n)
+		err := os.Link(tmp, candidate)
+		if err == nil {
+			return candidate, nil
+		}
+		if os.IsExist(err) {
+			continue
+		}
+		// Some file systems, like FAT, don't support hard links.
+		if _, statErr := os.Lstat(candidate); statErr == nil {
+			continue
+		} else if !errors.Is(statErr, os.ErrNotExist) {
+			return "", statErr
+		}
+		if err := os.Rename(tmp, candidate); err != nil {
+			return "", err
+		}
+		return candidate, nil
+	}
+	return "", fmt.Errorf("Too many exports named %s already exist", name)
+}
+
+// createExclusiveExportFile creates a file with the first name that isn't
+// taken, adding suffixes like linkExportFile does. Unlike writeExportFile, the
+// file is written in place, which suits exports of many small files.
+func createExclusiveExportFile(name string, perm os.FileMode) (*os.File, error) {
+	for n := 1; n <= MAX_EXPORT_FILE_SUFFIX; n++ {
+		f, err := os.OpenFile(exportFileCandidate(name, n), os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if os.IsExist(err) {
+			continue
+		}
+		return f, err
+	}
+	return nil, fmt.Errorf("Too many exports named %s already exist", name)
+}
+
+// exportFileCandidate is the nth name tried for an export, i.e.
+// "export-20231217-2.zip.enc" when n is 2.
+func exportFileCandidate(name string, n int) string {
+	if n <= 1 {
+		return name
+	}
+	dir, base := filepath.Split(name)
+	stem, ext := base, ""
+	if idx := strings.Index(base, "."); idx > 0 {
+		stem, ext = base[:idx], base[idx:]
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%d%s", stem, n, ext))
+}
+
+// WriteDaylioCSV writes entries as a CSV that Daylio can import. Like other
+// exports, an earlier file with the same name is never overwritten, and the
+// file never appears half-written. It returns the name that was used.
+func WriteDaylioCSV(name string, entries []daylio.Entry) (string, error) {
+	return writeExportFile(name, 0o644, func(w io.Writer) error {
+		return daylio.WriteEntriesToCSV(w, entries)
+	})
+}
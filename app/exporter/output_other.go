@@ -0,0 +1,22 @@
+//go:build !windows
+
+package exporter
+
+import "os"
+
+func chmodExportFile(f exportFile, perm os.FileMode) error {
+	return f.Chmod(perm)
+}
+
+// syncDirectory makes sure that a new name within a folder survives a crash.
+func syncDirectory(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
@@ -0,0 +1,181 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"exporter/daylio"
+	"exporter/types"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDiskFull = errors.New("disk full")
+
+// failingWriter accepts a number of bytes, then fails like a full disk would.
+type failingWriter struct {
+	remaining int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.remaining {
+		n := w.remaining
+		w.remaining = 0
+		return n, errDiskFull
+	}
+	w.remaining -= len(p)
+	return len(p), nil
+}
+
+func mustListDirectory(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func mockDayOneExport() *types.DayOneExport {
+	return types.NewDayOneExport([]types.DayOneEntry{{Text: "hello", Tags: []string{"tag"}}})
+}
+
+func TestWriteDayOneZipPropagatesWriteErrors(t *testing.T) {
+	attachments := []Attachment{{Name: "photo.png", Data: bytes.Repeat([]byte{1}, 1000)}}
+	var full bytes.Buffer
+	require.NoError(t, writeDayOneZip(&full, "Journal", mockDayOneExport(), attachments))
+	// Fail at every point, including while the central directory is written
+	// by zip.Close.
+	for limit := 0; limit < full.Len(); limit++ {
+		err := writeDayOneZip(&failingWriter{remaining: limit}, "Journal", mockDayOneExport(), attachments)
+		require.ErrorIs(t, err, errDiskFull, "failing after %d of %d bytes", limit, full.Len())
+	}
+}
+
+func TestWriteExportFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "export-20231217.zip.enc")
+	want := []string{
+		name,
+		filepath.Join(dir, "export-20231217-2.zip.enc"),
+		filepath.Join(dir, "export-20231217-3.zip.enc"),
+	}
+	for idx, w := range want {
+		got, err := writeExportFile(name, 0o600, func(out io.Writer) error {
+			_, err := io.WriteString(out, w)
+			return err
+		})
+		require.NoError(t, err)
+		assert.Equal(t, w, got, "export %d", idx+1)
+		data, err := os.ReadFile(got)
+		require.NoError(t, err)
+		assert.Equal(t, w, string(data))
+	}
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, name, string(data), "the first export must be left alone")
+	assert.ElementsMatch(t, []string{
+		"export-20231217.zip.enc",
+		"export-20231217-2.zip.enc",
+		"export-20231217-3.zip.enc",
+	}, mustListDirectory(t, dir), "temporary files must be cleaned up")
+}
+
+func TestWriteExportFileFailure(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "export-20231217.zip")
+	_, err := writeExportFile(name, 0o644, func(w io.Writer) error {
+		if _, err := w.Write([]byte("half of a ZIP file")); err != nil {
+			return err
+		}
+		return errDiskFull
+	})
+	require.ErrorIs(t, err, errDiskFull)
+	assert.Empty(t, mustListDirectory(t, dir), "nothing should be left behind")
+}
+
+// mockExportFile is a file whose writes or syncs fail.
+type mockExportFile struct {
+	failingWriter
+	syncErr error
+	synced  bool
+}
+
+func (f *mockExportFile) Chmod(mode os.FileMode) error {
+	return nil
+}
+
+func (f *mockExportFile) Sync() error {
+	f.synced = true
+	return f.syncErr
+}
+
+func TestWriteAndSyncFlushFailure(t *testing.T) {
+	// Small writes sit in a buffer, so they only fail once it's flushed.
+	f := &mockExportFile{failingWriter: failingWriter{remaining: 4}}
+	err := writeAndSync(f, 0o644, func(w io.Writer) error {
+		_, err := io.WriteString(w, "more than four bytes")
+		return err
+	})
+	assert.ErrorIs(t, err, errDiskFull)
+	assert.False(t, f.synced, "failed files must not be synced")
+}
+
+func TestWriteAndSyncSyncFailure(t *testing.T) {
+	f := &mockExportFile{failingWriter: failingWriter{remaining: 100}, syncErr: errDiskFull}
+	err := writeAndSync(f, 0o644, func(w io.Writer) error {
+		_, err := io.WriteString(w, "data")
+		return err
+	})
+	assert.ErrorIs(t, err, errDiskFull)
+}
+
+func TestWriteAndSync(t *testing.T) {
+	f := &mockExportFile{failingWriter: failingWriter{remaining: 100}}
+	require.NoError(t, writeAndSync(f, 0o644, func(w io.Writer) error {
+		_, err := io.WriteString(w, "data")
+		return err
+	}))
+	assert.True(t, f.synced)
+	assert.Equal(t, 96, f.remaining)
+}
+
+func TestWriteExportFileInMissingDirectory(t *testing.T) {
+	_, err := writeExportFile(filepath.Join(t.TempDir(), "missing", "export.zip"), 0o644, func(w io.Writer) error {
+		return nil
+	})
+	assert.Error(t, err)
+}
+
+func TestWriteDayOneExportsLeavesNoPartialFiles(t *testing.T) {
+	dir := t.TempDir()
+	r, err := writeDayOneExports(exportZipFileName(dir), DEFAULT_DESTINATION_JOURNAL, mockDayOneExport(), nil)
+	require.NoError(t, err)
+	zr, err := zip.OpenReader(r.ZipFile)
+	require.NoError(t, err)
+	defer zr.Close()
+	require.Len(t, zr.File, 1)
+	assert.Equal(t, []string{filepath.Base(r.ZipFile)}, mustListDirectory(t, dir))
+}
+
+func TestWriteDaylioCSVNeverOverwrites(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "daylio-20231217.csv")
+	require.NoError(t, os.WriteFile(name, []byte("earlier"), 0o644))
+	got, err := WriteDaylioCSV(name, []daylio.Entry{{FullDate: "2023-12-17", Time: "08:00", Mood: "good"}})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "daylio-20231217-2.csv"), got)
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "earlier", string(data))
+	entries, err := daylio.GetEntriesFromFile(got)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "good", entries[0].Mood)
+}
@@ -0,0 +1,14 @@
+package exporter
+
+import "os"
+
+// chmodExportFile is skipped since Windows doesn't support file modes.
+func chmodExportFile(f exportFile, perm os.FileMode) error {
+	return nil
+}
+
+// syncDirectory is skipped since Windows doesn't support syncing folders, and
+// doesn't need to.
+func syncDirectory(dir string) error {
+	return nil
+}
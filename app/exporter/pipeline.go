@@ -0,0 +1,230 @@
+package exporter
+
+import (
+	"context"
+	"exporter/daylio"
+	"exporter/types"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// PipelineOptions configures how entries are converted.
+type PipelineOptions struct {
+	// Workers is how many entries are converted at once within each stage.
+	// Defaults to the number of CPUs.
+	Workers int
+	// Progress reports how far along the conversion is, if provided.
+	Progress *ProgressReporter
+	// Writers are given every entry, in order, as soon as it's converted.
+	// The first error stops the conversion.
+	Writers []EntryWriter
+}
+
+// pipelineItem is an entry making its way through the conversion pipeline.
+// Each stage fills in more of it.
+type pipelineItem struct {
+	idx        int
+	source     daylio.Entry
+	activities []string
+	location   types.DayOneEntryLocation
+	weather    types.DayOneWeather
+	timestamps dayOneTimestamps
+	dayOne     types.DayOneEntry
+}
+
+// entryReader calls fn with every entry, in order, and stops at the first
+// error, like daylio.ReadBackup does.
+type entryReader func(fn func(*daylio.Entry) error) error
+
+// pipelineStage does its part of converting an item. Stages run concurrently,
+// so they must not share state between items.
+type pipelineStage func(item *pipelineItem) error
+
+// convertEntries converts Daylio entries into Day One entries in stages:
+//
+//	parse → transform → enrich → render → write
+//
+// Entries are parsed in order, then transformed (activities, locations, and
+// timestamps), enriched (location history and weather), and rendered (text
+// and rich text) by a bounded pool of workers per stage, then written out in
+// their original order regardless of which finished first. The first error,
+// or cancelling ctx, stops every stage.
+func convertEntries(ctx context.Context, entries []daylio.Entry, generators types.DayOneGenerators, opts ConvertOptions) ([]ConvertedEntry, error) {
+	outs := make([]ConvertedEntry, 0, len(entries))
+	read := func(fn func(*daylio.Entry) error) error {
+		for idx := range entries {
+			if err := fn(&entries[idx]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err := runPipeline(ctx, read, len(entries), generators, opts, func(entry *ConvertedEntry) {
+		outs = append(outs, *entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outs, nil
+}
+
+// runPipeline converts entries as read gives them, like convertEntries, and
+// gives each one to keep, then to opts.Pipeline.Writers, in order. total is
+// how many entries there are, or 0 when that isn't known until they've all
+// been read.
+func runPipeline(ctx context.Context, read entryReader, total int, generators types.DayOneGenerators, opts ConvertOptions, keep func(entry *ConvertedEntry)) error {
+	timeZone := entryTimeZone(&opts)
+	device := entryDevice(&opts)
+	// Daylio CSV times are on the clock where entries were written, which is
+	// needed to find them within location history and to place sunrise and
+	// sunset. Backup times are already in UTC.
+	zone, err := time.LoadLocation(timeZone)
+	if err != nil {
+		zone = time.UTC
+	}
+	workers := opts.Pipeline.Workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	transform := func(item *pipelineItem) error {
+		item.activities = entryActivities(&item.source)
+		item.location = generateLocationFromDaylioActivities(item.activities, opts.HomeLocation)
+		ts, err := createTimestamps(&item.source, generators.Timestamper)
+		if err != nil {
+			return err
+		}
+		item.timestamps = ts
+		return nil
+	}
+	enrich := func(item *pipelineItem) error {
+		if opts.LocationHistory != nil && item.location == (types.DayOneEntryLocation{}) {
+			at := time.Time(item.timestamps.Created)
+			if item.source.TimeZoneOffset == 0 {
+				at = time.Date(at.Year(), at.Month(), at.Day(), at.Hour(), at.Minute(), at.Second(), 0, zone)
+			}
+			if loc, ok := opts.LocationHistory.Lookup(at); ok {
+				item.location = loc
+			}
+		}
+		if opts.Weather != nil {
+			// Weather is recorded by local day, while backups date entries
+			// in UTC.
+			item.weather, _ = opts.Weather.Lookup(item.source.LocalDate(), &item.location, zone)
+		}
+		return nil
+	}
+	render := func(item *pipelineItem) error {
+		rt, err := generateDayOneRichText(&item.source, device, generators.UUIDGenerator)
+		if err != nil {
+			return err
+		}
+		dayOneEntry := types.NewEmptyDayOneEntry(device)
+		dayOneEntry.RichText = rt
+		dayOneEntry.UUID = generators.IDGenerator.CreateID()
+		dayOneEntry.Tags = item.activities
+		dayOneEntry.Location = item.location
+		dayOneEntry.Weather = item.weather
+		dayOneEntry.TimeZone = timeZone
+		dayOneEntry.CreationDate = item.timestamps.Created
+		dayOneEntry.ModifiedDate = item.timestamps.Modified
+		dayOneEntry.Text = createDayOneText(&item.source)
+		item.dayOne = *dayOneEntry
+		return nil
+	}
+	parsed := parseStage(ctx, cancel, read)
+	transformed := runPipelineStage(ctx, cancel, workers, parsed, transform)
+	enriched := runPipelineStage(ctx, cancel, workers, transformed, enrich)
+	rendered := runPipelineStage(ctx, cancel, workers, enriched, render)
+	writeStage(ctx, cancel, rendered, total, &opts.Pipeline, keep)
+	return context.Cause(ctx)
+}
+
+// parseStage reads entries in order. Reading stops once the pipeline is
+// cancelled, and a failure to read cancels it.
+func parseStage(ctx context.Context, cancel context.CancelCauseFunc, read entryReader) <-chan *pipelineItem {
+	out := make(chan *pipelineItem)
+	go func() {
+		defer close(out)
+		idx := 0
+		err := read(func(entry *daylio.Entry) error {
+			select {
+			case out <- &pipelineItem{idx: idx, source: *entry}:
+				idx++
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			cancel(err)
+		}
+	}()
+	return out
+}
+
+// runPipelineStage runs a stage with a pool of workers until its input runs
+// out or the pipeline is cancelled. Items come out in whichever order they
+// finish.
+func runPipelineStage(ctx context.Context, cancel context.CancelCauseFunc, workers int, in <-chan *pipelineItem, stage pipelineStage) <-chan *pipelineItem {
+	out := make(chan *pipelineItem)
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range in {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := stage(item); err != nil {
+					cancel(&daylio.EntryError{Index: item.idx, Date: item.source.FullDate, Err: err})
+					continue
+				}
+				select {
+				case out <- item:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// writeStage puts items back in their original order and gives them to keep,
+// then to the pipeline's writers. Items that finish early wait until every
+// item before them has been written.
+func writeStage(ctx context.Context, cancel context.CancelCauseFunc, in <-chan *pipelineItem, total int, opts *PipelineOptions, keep func(entry *ConvertedEntry)) {
+	written := 0
+	pending := map[int]*pipelineItem{}
+	opts.Progress.Start(total)
+	defer opts.Progress.Finish()
+	for item := range in {
+		pending[item.idx] = item
+		for {
+			next, ok := pending[written]
+			if !ok {
+				break
+			}
+			delete(pending, next.idx)
+			written++
+			entry := ConvertedEntry{Source: next.source, DayOne: next.dayOne}
+			keep(&entry)
+			opts.Progress.Add(1)
+			for _, w := range opts.Writers {
+				if ctx.Err() != nil {
+					break
+				}
+				if err := w.WriteEntry(&entry); err != nil {
+					cancel(err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"exporter/daylio"
+	"exporter/types"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateDaylioEntries(n int) []daylio.Entry {
+	entries := []daylio.Entry{}
+	for idx := 0; idx < n; idx++ {
+		entries = append(entries, daylio.Entry{
+			FullDate:       fmt.Sprintf("2023-%02d-%02d", idx%12+1, idx%28+1),
+			Time:           "08:00",
+			Mood:           "good",
+			ActivitiesList: []string{"home"},
+			Note:           fmt.Sprintf("note text %d", idx),
+		})
+	}
+	return entries
+}
+
+func TestConvertingEntriesKeepsTheirOrder(t *testing.T) {
+	entries := generateDaylioEntries(500)
+	for _, workers := range []int{1, 8} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			got, err := convertEntries(context.Background(), entries, types.DefaultDayOneGenerators(), ConvertOptions{Pipeline: PipelineOptions{Workers: workers}})
+			require.NoError(t, err)
+			require.Len(t, got, len(entries))
+			for idx := range entries {
+				assert.Equal(t, entries[idx].Note, got[idx].Source.Note)
+				assert.Equal(t, "Note\n\n"+entries[idx].Note, got[idx].DayOne.Text)
+			}
+		})
+	}
+}
+
+func TestConvertingEntriesAppliesOptions(t *testing.T) {
+	got, err := convertEntries(context.Background(), generateDaylioEntries(3), types.DefaultDayOneGenerators(), ConvertOptions{
+		HomeLocation: &types.DayOneEntryLocation{PlaceName: "Home"},
+		TimeZone:     "America/Chicago",
+	})
+	require.NoError(t, err)
+	for _, c := range got {
+		assert.Equal(t, "Home", c.DayOne.Location.PlaceName)
+		assert.Equal(t, "America/Chicago", c.DayOne.TimeZone)
+	}
+}
+
+func TestConvertingEntriesUsesDeviceProfile(t *testing.T) {
+	for _, device := range []types.DeviceProfile{{}, types.IPhoneDeviceProfile, types.DaylioDeviceProfile} {
+		want := device
+		if want == (types.DeviceProfile{}) {
+			want = types.MacDeviceProfile
+		}
+		t.Run(want.Name, func(t *testing.T) {
+			got, err := convertEntries(context.Background(), generateDaylioEntries(1), types.DefaultDayOneGenerators(), ConvertOptions{Device: device})
+			require.NoError(t, err)
+			e := got[0].DayOne
+			assert.Equal(t, want.Device, e.CreationDevice)
+			assert.Equal(t, want.DeviceType, e.CreationDeviceType)
+			assert.Equal(t, want.DeviceModel, e.CreationDeviceModel)
+			assert.Equal(t, want.OSName, e.CreationOSName)
+			assert.Equal(t, want.OSVersion, e.CreationOSVersion)
+			assert.Contains(t, e.RichText, fmt.Sprintf(`"created":{"platform":"%s","version":%d}`, want.Platform, want.PlatformVersion))
+		})
+	}
+}
+
+func TestConvertingEntriesIgnoresEnvironment(t *testing.T) {
+	t.Setenv("HOME_ADDRESS_JSON", `{"placeName": "Home"}`)
+	t.Setenv("TZ", "America/Chicago")
+	got, err := convertEntries(context.Background(), generateDaylioEntries(1), types.DefaultDayOneGenerators(), ConvertOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, got[0].DayOne.Location)
+	assert.Equal(t, "UTC", got[0].DayOne.TimeZone)
+}
+
+func TestConvertingEntriesStopsAtFirstError(t *testing.T) {
+	entries := generateDaylioEntries(100)
+	entries[42].FullDate = "not a date"
+	_, err := convertEntries(context.Background(), entries, types.DefaultDayOneGenerators(), ConvertOptions{Pipeline: PipelineOptions{Workers: 4}})
+	assert.ErrorContains(t, err, "not a date")
+}
+
+func TestConvertingEntriesCanBeCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := convertEntries(ctx, generateDaylioEntries(100), types.DefaultDayOneGenerators(), ConvertOptions{Pipeline: PipelineOptions{Workers: 4}})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// cancellingTimestamper cancels the conversion partway through.
+type cancellingTimestamper struct {
+	cancel context.CancelFunc
+	after  int64
+	calls  atomic.Int64
+}
+
+func (g *cancellingTimestamper) CreateModifiedTime() (time.Time, error) {
+	if g.calls.Add(1) == g.after {
+		g.cancel()
+	}
+	return time.Time{}, nil
+}
+
+func TestConvertingEntriesCanBeCancelledPartway(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	generators := types.DefaultDayOneGenerators()
+	timestamper := &cancellingTimestamper{cancel: cancel, after: 10}
+	generators.Timestamper = timestamper
+	_, err := convertEntries(ctx, generateDaylioEntries(10000), generators, ConvertOptions{Pipeline: PipelineOptions{Workers: 4}})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, timestamper.calls.Load(), int64(10000))
+}
+
+// recordingWriter records the entries it's given, failing after a number of
+// them when told to.
+type recordingWriter struct {
+	dates  []string
+	failAt int
+}
+
+func (w *recordingWriter) WriteEntry(entry *ConvertedEntry) error {
+	if w.failAt > 0 && len(w.dates) == w.failAt {
+		return errors.New("disk full")
+	}
+	w.dates = append(w.dates, entry.Source.FullDate)
+	return nil
+}
+
+func (w *recordingWriter) Close() (SinkResult, error) { return nil, nil }
+
+func (w *recordingWriter) Abort() {}
+
+func TestConvertingEntriesWritesEachEntryInOrder(t *testing.T) {
+	entries := generateDaylioEntries(50)
+	w := &recordingWriter{}
+	got, err := convertEntries(context.Background(), entries, types.DefaultDayOneGenerators(), ConvertOptions{
+		Pipeline: PipelineOptions{Workers: 4, Writers: []EntryWriter{w}},
+	})
+	require.NoError(t, err)
+	require.Len(t, w.dates, len(got))
+	for idx := range got {
+		assert.Equal(t, got[idx].Source.FullDate, w.dates[idx])
+	}
+}
+
+func TestConvertingEntriesStopsWhenAWriterFails(t *testing.T) {
+	w := &recordingWriter{failAt: 5}
+	_, err := convertEntries(context.Background(), generateDaylioEntries(100), types.DefaultDayOneGenerators(), ConvertOptions{
+		Pipeline: PipelineOptions{Workers: 4, Writers: []EntryWriter{w}},
+	})
+	assert.ErrorContains(t, err, "disk full")
+	assert.Len(t, w.dates, 5)
+}
+
+func TestConvertingEntriesAddsWeather(t *testing.T) {
+	entries := generateDaylioEntries(2)
+	entries[0].FullDate, entries[1].FullDate = "2023-12-17", "2023-12-18"
+	got, err := convertEntries(context.Background(), entries, types.DefaultDayOneGenerators(), ConvertOptions{
+		TimeZone: "America/Chicago",
+		Weather:  mustReadWeatherHistory(t, testWeatherCSV, WeatherUnitsStandard),
+	})
+	require.NoError(t, err)
+	weather := got[0].DayOne.Weather
+	assert.Equal(t, "Partly Cloudy", weather.ConditionsDescription)
+	require.NotNil(t, weather.SunriseDate)
+	assert.Equal(t, "2023-12-17T13:12:00Z", weather.SunriseDate.Format("2006-01-02T15:04:05Z"), "sunrise is on the journal's clock")
+	out, err := json.Marshal(got[1].DayOne)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"weather":{}`, "entries without weather are left alone")
+}
+
+func TestConvertingEntriesAddsWeatherOnTheLocalDay(t *testing.T) {
+	// Written at 20:00 in Chicago on the 17th, which a backup records as
+	// 02:00 UTC on the 18th.
+	entries := []daylio.Entry{{FullDate: "2023-12-18", Time: "02:00", Mood: "good", TimeZoneOffset: -6 * 60 * 60 * 1000}}
+	got, err := convertEntries(context.Background(), entries, types.DefaultDayOneGenerators(), ConvertOptions{
+		TimeZone: "America/Chicago",
+		Weather:  mustReadWeatherHistory(t, testWeatherCSV, WeatherUnitsStandard),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Partly Cloudy", got[0].DayOne.Weather.ConditionsDescription)
+}
+
+func TestConvertingEntriesAddsLocationHistory(t *testing.T) {
+	entries := generateDaylioEntries(2)
+	entries[0].FullDate, entries[0].Time = "2023-12-17", "08:10"
+	entries[1].FullDate, entries[1].Time = "2023-12-17", "08:20"
+	entries[1].ActivitiesList = []string{"work"}
+	got, err := convertEntries(context.Background(), entries, types.DefaultDayOneGenerators(), ConvertOptions{
+		HomeLocation:    &types.DayOneEntryLocation{PlaceName: "Home"},
+		TimeZone:        "America/Chicago",
+		LocationHistory: mustReadLocationHistory(t, testSemanticJSON),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Home", got[0].DayOne.Location.PlaceName, "activity rules keep priority")
+	assert.Equal(t, "Millennium Park", got[1].DayOne.Location.PlaceName, "08:20 in Chicago is 14:20 UTC")
+}
+
+func TestConvertingBackupEntriesAddsLocationHistory(t *testing.T) {
+	// Backups record when entries were written in UTC, along with the offset
+	// of where they were written, so their times aren't moved again.
+	entries := []daylio.Entry{{FullDate: "2023-12-17", Time: "14:20", Mood: "good", TimeZoneOffset: -6 * 60 * 60 * 1000}}
+	got, err := convertEntries(context.Background(), entries, types.DefaultDayOneGenerators(), ConvertOptions{
+		TimeZone:        "America/Chicago",
+		LocationHistory: mustReadLocationHistory(t, testSemanticJSON),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Millennium Park", got[0].DayOne.Location.PlaceName, "14:20 UTC is 08:20 in Chicago")
+}
+
+func TestStreamingEntriesKeepsNone(t *testing.T) {
+	entries := generateDaylioEntries(20)
+	entries[0].Note = "secret note"
+	entries[1].ActivitiesList = []string{"work"}
+	redactor, err := daylio.NewRedactor(&daylio.RedactionConfig{Phrases: []string{"secret"}})
+	require.NoError(t, err)
+	read := func(fn func(*daylio.Entry) error) error {
+		for idx := range entries {
+			if err := fn(&entries[idx]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	w := &recordingWriter{}
+	summary, err := StreamEntries(context.Background(), "backup.daylio", read, ConvertOptions{
+		Filter:   daylio.Filter{ExcludeActivities: []string{"work"}},
+		Redactor: redactor,
+		Pipeline: PipelineOptions{Workers: 4, Writers: []EntryWriter{w}},
+	}, types.DefaultDayOneGenerators())
+	require.NoError(t, err)
+	assert.Len(t, w.dates, 19)
+	assert.Equal(t, 19, summary.EntryCount)
+	assert.Equal(t, []string{"backup.daylio"}, summary.Sources)
+	assert.Equal(t, 1, summary.Redactions.Entries)
+
+	_, err = StreamEntries(context.Background(), "backup.daylio", read, ConvertOptions{Summaries: true}, types.DefaultDayOneGenerators())
+	assert.ErrorIs(t, err, ErrInvalidOption)
+	_, err = StreamEntries(context.Background(), "backup.daylio", func(fn func(*daylio.Entry) error) error {
+		return errors.New("corrupt")
+	}, ConvertOptions{}, types.DefaultDayOneGenerators())
+	assert.EqualError(t, err, "corrupt")
+}
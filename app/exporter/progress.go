@@ -0,0 +1,78 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// PROGRESS_INTERVAL is how often progress is reported at most.
+const PROGRESS_INTERVAL = 250 * time.Millisecond
+
+// ProgressReporter prints how many entries have been converted, how fast,
+// and roughly how long is left, on a single line that's rewritten as it goes.
+// A nil ProgressReporter reports nothing.
+type ProgressReporter struct {
+	w          io.Writer
+	now        func() time.Time
+	total      int
+	done       int
+	startedAt  time.Time
+	reportedAt time.Time
+}
+
+// NewProgressReporter reports progress to w, usually a terminal.
+func NewProgressReporter(w io.Writer) *ProgressReporter {
+	return &ProgressReporter{w: w, now: time.Now}
+}
+
+// Start begins reporting progress towards total entries, or just how many
+// have been converted when total is 0 because it isn't known.
+func (p *ProgressReporter) Start(total int) {
+	if p == nil {
+		return
+	}
+	p.total = total
+	p.done = 0
+	p.startedAt = p.now()
+	p.reportedAt = time.Time{}
+}
+
+// Add records that n more entries were converted.
+func (p *ProgressReporter) Add(n int) {
+	if p == nil {
+		return
+	}
+	p.done += n
+	if now := p.now(); now.Sub(p.reportedAt) >= PROGRESS_INTERVAL {
+		p.report(now)
+	}
+}
+
+// Finish reports the final count and ends the line.
+func (p *ProgressReporter) Finish() {
+	if p == nil {
+		return
+	}
+	p.report(p.now())
+	fmt.Fprintln(p.w)
+}
+
+func (p *ProgressReporter) report(now time.Time) {
+	p.reportedAt = now
+	line := fmt.Sprintf("Converted %d entries", p.done)
+	if p.total > 0 {
+		line = fmt.Sprintf("Converted %d of %d entries", p.done, p.total)
+	}
+	if elapsed := now.Sub(p.startedAt); elapsed > 0 && p.done > 0 {
+		rate := float64(p.done) / elapsed.Seconds()
+		if p.total > 0 {
+			left := time.Duration(float64(p.total-p.done) / rate * float64(time.Second))
+			line += fmt.Sprintf(" (%.0f entries/s, about %s left)", rate, left.Round(time.Second))
+		} else {
+			line += fmt.Sprintf(" (%.0f entries/s)", rate)
+		}
+	}
+	// Clear whatever's left of a longer, earlier line.
+	fmt.Fprintf(p.w, "\r%s\033[K", line)
+}
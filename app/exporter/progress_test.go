@@ -0,0 +1,52 @@
+package exporter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockClock struct{ now time.Time }
+
+func (c *mockClock) Now() time.Time { return c.now }
+
+func TestReportingProgress(t *testing.T) {
+	var buf bytes.Buffer
+	clock := mockClock{now: time.Date(2023, 12, 17, 8, 0, 0, 0, time.UTC)}
+	p := NewProgressReporter(&buf)
+	p.now = clock.Now
+	p.Start(1000)
+	clock.now = clock.now.Add(2 * time.Second)
+	p.Add(200)
+	assert.Equal(t, "\rConverted 200 of 1000 entries (100 entries/s, about 8s left)\033[K", buf.String())
+	buf.Reset()
+	// Reports are throttled.
+	clock.now = clock.now.Add(PROGRESS_INTERVAL / 2)
+	p.Add(1)
+	assert.Empty(t, buf.String())
+	p.Finish()
+	assert.Contains(t, buf.String(), "Converted 201 of 1000 entries")
+	assert.True(t, bytes.HasSuffix(buf.Bytes(), []byte("\n")))
+}
+
+func TestReportingProgressWithoutATotal(t *testing.T) {
+	var buf bytes.Buffer
+	clock := mockClock{now: time.Date(2023, 12, 17, 8, 0, 0, 0, time.UTC)}
+	p := NewProgressReporter(&buf)
+	p.now = clock.Now
+	p.Start(0)
+	clock.now = clock.now.Add(2 * time.Second)
+	p.Add(200)
+	assert.Equal(t, "\rConverted 200 entries (100 entries/s)\033[K", buf.String())
+}
+
+func TestReportingProgressWithoutReporter(t *testing.T) {
+	var p *ProgressReporter
+	assert.NotPanics(t, func() {
+		p.Start(10)
+		p.Add(1)
+		p.Finish()
+	})
+}
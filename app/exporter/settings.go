@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"encoding/json"
+	"exporter/types"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// This file has everything within exporter that reads environment variables
+// or creates the export directory. Conversion itself doesn't, so that it can
+// run where there's no file system, like in a web browser.
+
+// ExportSettings configures where exports are written and which Day One
+// journal they're imported into.
+type ExportSettings struct {
+	// Directory is where exports are written. Missing directories are
+	// created, including nested ones. Defaults to EXPORT_DIRECTORY, then
+	// "./exports".
+	Directory string
+	// JournalName names the Day One journal that entries are imported into.
+	// Defaults to JOURNAL_NAME, then "From Daylio".
+	JournalName string
+}
+
+// Initialize sets up an export job, returning s with its defaults filled in
+// once the export directory exists.
+func Initialize(s ExportSettings) (ExportSettings, error) {
+	log.Info("Starting Daylio to Day One export")
+	setLogLevel()
+	resolved, err := resolveExportSettings(s)
+	if err != nil {
+		return resolved, &SettingsError{Setting: "JournalName", Err: err}
+	}
+	if err := createExportDirectoryIfMissing(resolved.Directory); err != nil {
+		return resolved, &SettingsError{Setting: "Directory", Err: err}
+	}
+	return resolved, nil
+}
+
+func resolveExportSettings(s ExportSettings) (ExportSettings, error) {
+	if s.Directory == "" {
+		s.Directory = os.Getenv("EXPORT_DIRECTORY")
+	}
+	if s.Directory == "" {
+		s.Directory = DEFAULT_EXPORT_DIRECTORY
+	}
+	s.Directory = filepath.Clean(s.Directory)
+	if s.JournalName == "" {
+		s.JournalName = os.Getenv("JOURNAL_NAME")
+	}
+	if s.JournalName == "" {
+		s.JournalName = DEFAULT_DESTINATION_JOURNAL
+	}
+	name := SanitizeJournalName(s.JournalName)
+	if name == "" {
+		return s, fmt.Errorf("Not a valid journal name: '%s'", s.JournalName)
+	}
+	if name != s.JournalName {
+		log.Warnf("Journal name contains characters that can't be used in file names; using '%s' instead", name)
+	}
+	s.JournalName = name
+	return s, nil
+}
+
+// ConvertOptionsFromEnv sets up the quirks configured by environment
+// variables: HOME_ADDRESS_JSON (unless NO_AUTO_HOME_LOCATION is set) and TZ.
+func ConvertOptionsFromEnv() (ConvertOptions, error) {
+	home, err := HomeLocationFromEnv()
+	if err != nil {
+		return ConvertOptions{}, err
+	}
+	return ConvertOptions{HomeLocation: home, TimeZone: types.LocalTimeZone()}, nil
+}
+
+// HomeLocationFromEnv reads HOME_ADDRESS_JSON. It's nil when the home location
+// quirk is disabled with NO_AUTO_HOME_LOCATION or no address is set.
+func HomeLocationFromEnv() (*types.DayOneEntryLocation, error) {
+	if os.Getenv("NO_AUTO_HOME_LOCATION") != "" {
+		return nil, nil
+	}
+	if os.Getenv("HOME_ADDRESS_JSON") == "" {
+		return nil, nil
+	}
+	var out types.DayOneEntryLocation
+	if err := json.Unmarshal([]byte(os.Getenv("HOME_ADDRESS_JSON")), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func createExportDirectoryIfMissing(dir string) error {
+	info, err := os.Stat(dir)
+	if err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("Export directory is not a directory: %s", dir)
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+	log.Debugf("Creating export directory: %s", dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return &WriteError{Path: dir, Err: err}
+	}
+	return nil
+}
+
+func setLogLevel() {
+	if os.Getenv("LOG_LEVEL") == "" {
+		return
+	}
+	level, err := log.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		log.Warningf("Invalid log level, using default: %s", os.Getenv("LOG_LEVEL"))
+	}
+	log.SetLevel(level)
+}
@@ -0,0 +1,214 @@
+package exporter
+
+import (
+	"errors"
+	"exporter/daylio"
+	"exporter/types"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConvertedEntry is a Daylio entry along with everything derived from it
+// during conversion. Sinks pick whichever representation suits them.
+type ConvertedEntry struct {
+	// Source is the entry as read from Daylio.
+	Source daylio.Entry
+	// DayOne is the entry after conversion, with quirks like locations
+	// applied.
+	DayOne types.DayOneEntry
+	// Attachments are files that belong to this entry, like photos.
+	Attachments []Attachment
+	// Synthetic entries, like "Year in Review" entries, were generated by the
+	// exporter instead of being read from Daylio. Sinks meant for Daylio data,
+	// like NDJSON, leave them out.
+	Synthetic bool
+}
+
+// Attachment is a file attached to an entry.
+type Attachment struct {
+	// Name is the file's name within the Day One "photos" folder, which is
+	// its MD5 and extension.
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// RunSummary describes a conversion run.
+type RunSummary struct {
+	// Sources are the backups or CSV files that entries were read from.
+	Sources    []string
+	StartedAt  time.Time
+	EntryCount int
+	// FirstEntry and LastEntry are the times of the oldest and newest entries.
+	FirstEntry time.Time
+	LastEntry  time.Time
+	// Merge describes where entries came from when several files were
+	// provided.
+	Merge *daylio.MergeReport
+	// Redactions audits what was redacted, if a redactor was provided.
+	Redactions *daylio.RedactionReport
+}
+
+// SinkResult describes what a sink wrote.
+type SinkResult interface {
+	// Files lists the files written by the sink.
+	Files() []string
+}
+
+// Sink writes converted entries somewhere, like a Day One JSON ZIP or a
+// folder of Markdown files.
+type Sink interface {
+	// Name is the name used to choose this sink on the command line.
+	Name() string
+	// Write writes converted entries.
+	Write(entries []ConvertedEntry, summary *RunSummary) (SinkResult, error)
+}
+
+// StreamingSink is a sink that can also write entries while they're being
+// converted, so that what it writes never needs to be held in memory. See
+// PipelineOptions.Writers.
+type StreamingSink interface {
+	Sink
+	// Open starts writing entries one at a time.
+	Open() (EntryWriter, error)
+}
+
+// EntryWriter writes entries for a StreamingSink, in order, as they're
+// converted.
+type EntryWriter interface {
+	WriteEntry(entry *ConvertedEntry) error
+	// Close finishes writing once every entry has been written.
+	Close() (SinkResult, error)
+	// Abort throws away everything written, i.e. when the conversion fails.
+	Abort()
+}
+
+// SinkOptions configures sinks created by NewSinks.
+type SinkOptions struct {
+	MarkdownLayout MarkdownLayout
+	ICalAllDay     bool
+	// Passphrase encrypts the Day One ZIP file when it isn't empty.
+	Passphrase string
+	// Directory is where sinks write to. Required.
+	Directory string
+	// JournalName names the Day One journal. Defaults to "From Daylio".
+	JournalName string
+}
+
+type sinkFactory func(opts *SinkOptions) Sink
+
+var sinkFactories = map[string]sinkFactory{
+	DAY_ONE_SINK_NAME: func(opts *SinkOptions) Sink {
+		return &DayOneSink{Passphrase: opts.Passphrase, Directory: opts.Directory, JournalName: opts.JournalName}
+	},
+	MARKDOWN_SINK_NAME: func(opts *SinkOptions) Sink {
+		return &MarkdownSink{Layout: opts.MarkdownLayout, Directory: opts.Directory}
+	},
+	NDJSON_SINK_NAME: func(opts *SinkOptions) Sink {
+		return &NDJSONSink{Directory: opts.Directory}
+	},
+	ICAL_SINK_NAME: func(opts *SinkOptions) Sink {
+		return &ICalSink{AllDay: opts.ICalAllDay, Directory: opts.Directory}
+	},
+}
+
+// SinkNames lists the names of every available sink.
+func SinkNames() []string {
+	names := []string{}
+	for name := range sinkFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewSinks creates sinks from a list of names, i.e. "dayone" and "markdown".
+// Empty names are ignored, so that a list typed as "dayone, markdown," can be
+// split on commas as it is.
+func NewSinks(names []string, opts SinkOptions) ([]Sink, error) {
+	sinks := []Sink{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		factory, ok := sinkFactories[name]
+		if !ok {
+			return nil, invalidOption(fmt.Errorf("Not a valid export format: '%s'; choose from: %s",
+				name, strings.Join(SinkNames(), ", ")))
+		}
+		seen[name] = true
+		sinks = append(sinks, factory(&opts))
+	}
+	if len(sinks) == 0 {
+		return nil, invalidOption(fmt.Errorf("At least one export format is required"))
+	}
+	if _, err := sinkDirectory(opts.Directory); err != nil {
+		return nil, err
+	}
+	return sinks, nil
+}
+
+// WriteToSinks writes converted entries to every sink, in order.
+func WriteToSinks(entries []ConvertedEntry, summary *RunSummary, sinks []Sink) ([]SinkResult, error) {
+	results := []SinkResult{}
+	for _, sink := range sinks {
+		r, err := sink.Write(entries, summary)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// sinkDirectory is where a sink configured with dir writes to. Sinks never
+// fall back to the export directory, so that they can be used without
+// Initialize.
+func sinkDirectory(dir string) (string, error) {
+	if dir == "" {
+		return "", invalidOption(errors.New("Sinks need a directory to write to"))
+	}
+	return dir, nil
+}
+
+// daylioEntriesOnly leaves out synthetic entries.
+func daylioEntriesOnly(entries []ConvertedEntry) []ConvertedEntry {
+	out := []ConvertedEntry{}
+	for _, e := range entries {
+		if !e.Synthetic {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func newRunSummary(sources []string, startedAt time.Time, entries []ConvertedEntry) *RunSummary {
+	s := RunSummary{Sources: sources, StartedAt: startedAt}
+	s.Recount(entries)
+	return &s
+}
+
+// Recount describes entries instead, i.e. once some were left out by a
+// review.
+func (s *RunSummary) Recount(entries []ConvertedEntry) {
+	s.EntryCount, s.FirstEntry, s.LastEntry = 0, time.Time{}, time.Time{}
+	for idx := range entries {
+		s.count(&entries[idx])
+	}
+}
+
+// count adds an entry to the summary.
+func (s *RunSummary) count(e *ConvertedEntry) {
+	s.EntryCount++
+	created := time.Time(e.DayOne.CreationDate)
+	if s.FirstEntry.IsZero() || created.Before(s.FirstEntry) {
+		s.FirstEntry = created
+	}
+	if created.After(s.LastEntry) {
+		s.LastEntry = created
+	}
+}
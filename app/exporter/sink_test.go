@@ -0,0 +1,95 @@
+package exporter
+
+import (
+	"errors"
+	"exporter/daylio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSinkResult struct{ files []string }
+
+func (r *mockSinkResult) Files() []string {
+	return r.files
+}
+
+type mockSink struct {
+	name    string
+	err     error
+	entries []ConvertedEntry
+	summary *RunSummary
+}
+
+func (s *mockSink) Name() string {
+	return s.name
+}
+
+func (s *mockSink) Write(entries []ConvertedEntry, summary *RunSummary) (SinkResult, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.entries = entries
+	s.summary = summary
+	return &mockSinkResult{files: []string{s.name + ".out"}}, nil
+}
+
+func TestNewSinks(t *testing.T) {
+	sinks, err := NewSinks([]string{"dayone", " Markdown", "dayone", ""}, SinkOptions{MarkdownLayout: MarkdownLayoutPerDay, Directory: "exports"})
+	require.NoError(t, err)
+	require.Len(t, sinks, 2)
+	assert.Equal(t, "dayone", sinks[0].Name())
+	assert.Equal(t, &MarkdownSink{Layout: MarkdownLayoutPerDay, Directory: "exports"}, sinks[1])
+}
+
+func TestSinksNeedADirectory(t *testing.T) {
+	_, err := NewSinks([]string{"dayone"}, SinkOptions{})
+	assert.ErrorIs(t, err, ErrInvalidOption)
+	entries := mustConvertEntries(t, mustGetMockDaylioEntries(t))
+	for _, sink := range []Sink{&DayOneSink{}, &MarkdownSink{}, &NDJSONSink{}, &ICalSink{}} {
+		_, err := sink.Write(entries, &RunSummary{})
+		assert.EqualError(t, err, "Sinks need a directory to write to", sink.Name())
+	}
+}
+
+func TestNewSinksUnknownSink(t *testing.T) {
+	_, err := NewSinks([]string{"dayone", "fax"}, SinkOptions{})
+	assert.ErrorContains(t, err, "Not a valid export format: 'fax'")
+}
+
+func TestWriteToSinks(t *testing.T) {
+	entries := mustConvertEntries(t, []daylio.Entry{
+		{FullDate: "2023-12-17", Time: "08:00", Mood: "good"},
+		{FullDate: "2023-12-15", Time: "21:30", Mood: "bad"},
+	})
+	summary := newRunSummary([]string{"backup.daylio"}, time.Now(), entries)
+	assert.Equal(t, mustGetZuluTime("2023-12-15T21:30:00Z"), summary.FirstEntry)
+	assert.Equal(t, mustGetZuluTime("2023-12-17T08:00:00Z"), summary.LastEntry)
+	first, second := &mockSink{name: "first"}, &mockSink{name: "second"}
+	results, err := WriteToSinks(entries, summary, []Sink{first, second})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, []string{"second.out"}, results[1].Files())
+	assert.Equal(t, entries, first.entries)
+	assert.Equal(t, summary, second.summary)
+}
+
+func TestRecountingRunSummary(t *testing.T) {
+	entries := mustConvertEntries(t, []daylio.Entry{
+		{FullDate: "2023-12-17", Time: "08:00", Mood: "good"},
+		{FullDate: "2023-12-15", Time: "21:30", Mood: "bad"},
+	})
+	summary := newRunSummary([]string{"backup.daylio"}, time.Now(), entries)
+	summary.Recount(entries[:1])
+	assert.Equal(t, 1, summary.EntryCount)
+	assert.Equal(t, mustGetZuluTime("2023-12-17T08:00:00Z"), summary.FirstEntry)
+	assert.Equal(t, mustGetZuluTime("2023-12-17T08:00:00Z"), summary.LastEntry)
+	assert.Equal(t, []string{"backup.daylio"}, summary.Sources)
+}
+
+func TestWriteToSinksFailure(t *testing.T) {
+	_, err := WriteToSinks(nil, &RunSummary{}, []Sink{&mockSink{name: "broken", err: errors.New("disk full")}})
+	assert.EqualError(t, err, "broken: disk full")
+}
@@ -0,0 +1,227 @@
+package exporter
+
+import (
+	"exporter/daylio"
+	"exporter/dayone"
+	"exporter/stats"
+	"exporter/types"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SUMMARY_TAG = dayone.SUMMARY_TAG
+	// SUMMARY_TOP_ACTIVITIES is how many activities are listed in a summary.
+	SUMMARY_TOP_ACTIVITIES = 5
+)
+
+// summaryPeriod is a month or a year of converted entries.
+type summaryPeriod struct {
+	title   string
+	start   time.Time
+	end     time.Time
+	entries []ConvertedEntry
+}
+
+// days is how many days the period lasts.
+func (p *summaryPeriod) days() int {
+	return int(p.end.Sub(p.start).Hours()/24 + 0.5)
+}
+
+// createSummaryEntries builds a synthetic Day One entry for every month and
+// every year that has entries. Months come before the year they belong to.
+// Entries belong to the day they were written on where they were written.
+func createSummaryEntries(entries []ConvertedEntry, device *types.DeviceProfile, generators types.DayOneGenerators) ([]ConvertedEntry, error) {
+	outs := []ConvertedEntry{}
+	for _, p := range summaryPeriods(entries) {
+		e, err := createSummaryEntry(&p, device, generators)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, e)
+	}
+	return outs, nil
+}
+
+func summaryPeriods(entries []ConvertedEntry) []summaryPeriod {
+	byKey := map[string]*summaryPeriod{}
+	for _, e := range entries {
+		if e.Synthetic {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", e.Source.LocalDate())
+		if err != nil {
+			continue
+		}
+		month := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		year := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		for key, p := range map[string]summaryPeriod{
+			month.Format("2006-01"): {title: month.Format("January 2006"), start: month, end: month.AddDate(0, 1, 0)},
+			year.Format("2006"):     {title: year.Format("2006"), start: year, end: year.AddDate(1, 0, 0)},
+		} {
+			if _, ok := byKey[key]; !ok {
+				p := p
+				byKey[key] = &p
+			}
+			byKey[key].entries = append(byKey[key].entries, e)
+		}
+	}
+	out := []summaryPeriod{}
+	for _, p := range byKey {
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].end.Equal(out[j].end) {
+			return out[i].end.Before(out[j].end)
+		}
+		return out[i].start.After(out[j].start)
+	})
+	return out
+}
+
+func createSummaryEntry(p *summaryPeriod, device *types.DeviceProfile, generators types.DayOneGenerators) (ConvertedEntry, error) {
+	title := "Daylio Summary: " + p.title
+	body := summaryText(p)
+	uuid, err := generators.UUIDGenerator.GenerateUUID()
+	if err != nil {
+		return ConvertedEntry{}, err
+	}
+	rt, err := marshalDayOneRichText(
+		device,
+		types.DayOneRichTextObject{
+			Text: title + "\n",
+			Attributes: &types.DayOneRichTextObjectAttributes{
+				Line: types.DayOneRichTextLineObject{Header: 1, Identifier: uuid},
+			},
+		},
+		types.DayOneRichTextObject{Text: "\n" + body},
+	)
+	if err != nil {
+		return ConvertedEntry{}, err
+	}
+	modified, err := generators.Timestamper.CreateModifiedTime()
+	if err != nil {
+		return ConvertedEntry{}, err
+	}
+	dayOneEntry := types.NewEmptyDayOneEntry(device)
+	dayOneEntry.UUID = generators.IDGenerator.CreateID()
+	dayOneEntry.Tags = []string{SUMMARY_TAG}
+	// Summaries go at the very end of their period.
+	dayOneEntry.CreationDate = types.DayOneDateTime(p.end.Add(-time.Minute))
+	dayOneEntry.ModifiedDate = types.DayOneDateTime(modified)
+	dayOneEntry.Text = title + "\n\n" + body
+	dayOneEntry.RichText = rt
+	return ConvertedEntry{DayOne: *dayOneEntry, Synthetic: true}, nil
+}
+
+func summaryText(p *summaryPeriod) string {
+	sources := []daylio.Entry{}
+	firstEntryOn := map[string]string{}
+	firstEntryAt := map[string]time.Time{}
+	for _, e := range p.entries {
+		sources = append(sources, e.Source)
+		date := e.Source.LocalDate()
+		t := time.Time(e.DayOne.CreationDate)
+		if first, ok := firstEntryAt[date]; !ok || t.Before(first) {
+			firstEntryAt[date] = t
+			firstEntryOn[date] = e.DayOne.UUID
+		}
+	}
+	lines := []string{"## Moods", ""}
+	lines = append(lines, moodDistribution(sources)...)
+
+	r, err := stats.Compute(sources)
+	if err == nil && len(r.Activities) > 0 {
+		lines = append(lines, "", "## Top Activities", "")
+		for idx := 0; idx < len(r.Activities) && idx < SUMMARY_TOP_ACTIVITIES; idx++ {
+			a := r.Activities[idx]
+			lines = append(lines, fmt.Sprintf("- %s: %d", a.Name, a.Entries))
+		}
+	}
+
+	if best, worst, ok := bestAndWorstDays(p, sources); ok {
+		lines = append(lines, "", "## Best and Worst Days", "",
+			fmt.Sprintf("- Best: %s (%.2f)", dayLink(best.date, firstEntryOn[best.date]), best.mood),
+			fmt.Sprintf("- Worst: %s (%.2f)", dayLink(worst.date, firstEntryOn[worst.date]), worst.mood),
+		)
+	}
+
+	logged := map[string]bool{}
+	for idx := range sources {
+		logged[sources[idx].LocalDate()] = true
+	}
+	lines = append(lines, "", "## Consistency", "",
+		fmt.Sprintf("Logged %d entries on %d of %d days (%.0f%%).",
+			len(sources), len(logged), p.days(), 100*float64(len(logged))/float64(p.days())),
+	)
+	if err == nil && r.LongestStreak.Days > 0 {
+		lines = append(lines, fmt.Sprintf("Longest streak: %d days, from %s to %s.",
+			r.LongestStreak.Days, r.LongestStreak.Start, r.LongestStreak.End))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// moodDistribution lists how often each mood was logged, from best to worst.
+func moodDistribution(entries []daylio.Entry) []string {
+	counts := map[string]int{}
+	for _, e := range entries {
+		counts[e.Mood]++
+	}
+	moods := []string{}
+	for m := range counts {
+		moods = append(moods, m)
+	}
+	sort.Slice(moods, func(i, j int) bool {
+		si, sj := daylio.MoodScore(moods[i]), daylio.MoodScore(moods[j])
+		if si != sj {
+			return si > sj
+		}
+		return moods[i] < moods[j]
+	})
+	out := []string{}
+	for _, m := range moods {
+		out = append(out, fmt.Sprintf("- %s: %d (%.0f%%)", m, counts[m], 100*float64(counts[m])/float64(len(entries))))
+	}
+	return out
+}
+
+type dayMood struct {
+	date string
+	mood float64
+}
+
+// bestAndWorstDays picks the days with the highest and lowest average moods,
+// preferring earlier days on ties.
+func bestAndWorstDays(p *summaryPeriod, entries []daylio.Entry) (dayMood, dayMood, bool) {
+	days := stats.ComputeYearInPixels(entries, p.start.Year()).Days
+	if len(days) == 0 {
+		return dayMood{}, dayMood{}, false
+	}
+	sorted := []dayMood{}
+	for date, mood := range days {
+		sorted = append(sorted, dayMood{date, mood})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].date < sorted[j].date
+	})
+	best, worst := sorted[0], sorted[0]
+	for _, d := range sorted[1:] {
+		if d.mood > best.mood {
+			best = d
+		}
+		if d.mood < worst.mood {
+			worst = d
+		}
+	}
+	return best, worst, true
+}
+
+// dayLink links to the first entry of a day within Day One.
+func dayLink(date string, uuid string) string {
+	if uuid == "" {
+		return date
+	}
+	return fmt.Sprintf("[%s](dayone://view?entryId=%s)", date, uuid)
+}
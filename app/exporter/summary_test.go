@@ -0,0 +1,83 @@
+package exporter
+
+import (
+	"exporter/daylio"
+	"exporter/types"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSummaryEntries(t *testing.T) {
+	entries := mustConvertEntries(t, []daylio.Entry{
+		{FullDate: "2024-01-02", Time: "09:00", Mood: "rad", Activities: "friends"},
+		{FullDate: "2023-12-17", Time: "20:00", Mood: "good", Activities: "friends | work"},
+		{FullDate: "2023-12-17", Time: "08:00", Mood: "awful", Activities: "work"},
+		{FullDate: "2023-12-16", Time: "08:00", Mood: "rad", Activities: "friends"},
+		{FullDate: "2023-11-30", Time: "08:00", Mood: "bad"},
+	})
+	got, err := createSummaryEntries(entries, &types.MacDeviceProfile, types.DefaultDayOneGenerators())
+	require.NoError(t, err)
+	titles := []string{}
+	for _, e := range got {
+		assert.True(t, e.Synthetic)
+		assert.Equal(t, []string{SUMMARY_TAG}, e.DayOne.Tags)
+		titles = append(titles, strings.SplitN(e.DayOne.Text, "\n", 2)[0])
+	}
+	assert.Equal(t, []string{
+		"Daylio Summary: November 2023",
+		"Daylio Summary: December 2023",
+		"Daylio Summary: 2023",
+		"Daylio Summary: January 2024",
+		"Daylio Summary: 2024",
+	}, titles)
+
+	december := got[1].DayOne
+	assert.Equal(t, time.Date(2023, time.December, 31, 23, 59, 0, 0, time.UTC), time.Time(december.CreationDate))
+	want := `Daylio Summary: December 2023
+
+## Moods
+
+- rad: 1 (33%)
+- good: 1 (33%)
+- awful: 1 (33%)
+
+## Top Activities
+
+- friends: 2
+- work: 2
+
+## Best and Worst Days
+
+- Best: [2023-12-16](dayone://view?entryId=` + entries[3].DayOne.UUID + `) (5.00)
+- Worst: [2023-12-17](dayone://view?entryId=` + entries[2].DayOne.UUID + `) (2.50)
+
+## Consistency
+
+Logged 3 entries on 2 of 31 days (6%).
+Longest streak: 2 days, from 2023-12-16 to 2023-12-17.`
+	assert.Equal(t, want, december.Text)
+	assert.Contains(t, got[2].DayOne.Text, "on 3 of 365 days")
+}
+
+func TestCreateSummaryEntriesSkipsSyntheticEntries(t *testing.T) {
+	got, err := createSummaryEntries([]ConvertedEntry{{Synthetic: true}}, &types.MacDeviceProfile, types.DefaultDayOneGenerators())
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestCreateSummaryEntriesOnTheLocalDay(t *testing.T) {
+	// 20:00 on Nov 30 in Chicago is already December in UTC.
+	entries := mustConvertEntries(t, []daylio.Entry{
+		{FullDate: "2023-12-01", Time: "02:00", Mood: "rad", TimeZoneOffset: -21600000},
+	})
+	got, err := createSummaryEntries(entries, &types.MacDeviceProfile, types.DefaultDayOneGenerators())
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.True(t, strings.HasPrefix(got[0].DayOne.Text, "Daylio Summary: November 2023\n"))
+	assert.Contains(t, got[0].DayOne.Text, "Best: [2023-11-30]")
+	assert.Contains(t, got[0].DayOne.Text, "on 1 of 30 days")
+}
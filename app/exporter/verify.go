@@ -0,0 +1,260 @@
+package exporter
+
+import (
+	"exporter/daylio"
+	"exporter/dayone"
+	"exporter/types"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MAX_TIME_ZONE_SHIFT is the furthest apart two entries can be and still be
+// considered the same entry in a different time zone.
+const MAX_TIME_ZONE_SHIFT = 14 * time.Hour
+
+// DiscrepancyKind is a kind of difference between a Daylio backup and a Day
+// One export.
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyMissing is a Daylio entry that isn't in the export.
+	DiscrepancyMissing DiscrepancyKind = "missing"
+	// DiscrepancyDuplicated is a Daylio entry that's in the export more than
+	// once.
+	DiscrepancyDuplicated DiscrepancyKind = "duplicated"
+	// DiscrepancyUnexpected is an exported entry that isn't in the backup.
+	DiscrepancyUnexpected DiscrepancyKind = "unexpected"
+	// DiscrepancyText is an entry whose text changed.
+	DiscrepancyText DiscrepancyKind = "text"
+	// DiscrepancyTags is an entry that's missing tags.
+	DiscrepancyTags DiscrepancyKind = "tags"
+	// DiscrepancyMood is an entry whose mood changed.
+	DiscrepancyMood DiscrepancyKind = "mood"
+	// DiscrepancyTimeZone is an entry that moved by a whole time zone.
+	DiscrepancyTimeZone DiscrepancyKind = "timezone"
+)
+
+// Discrepancy is a difference between a Daylio backup and a Day One export.
+type Discrepancy struct {
+	Kind DiscrepancyKind
+	// Time is when the Daylio entry was created.
+	Time time.Time
+	// UUID is the Day One entry's UUID, if there is one.
+	UUID    string
+	Details string
+}
+
+func (d Discrepancy) String() string {
+	s := fmt.Sprintf("[%s] %s", d.Kind, d.Time.Format("2006-01-02 15:04"))
+	if d.UUID != "" {
+		s += fmt.Sprintf(" (%s)", d.UUID)
+	}
+	if d.Details != "" {
+		s += ": " + d.Details
+	}
+	return s
+}
+
+// VerificationReport lists every difference found between a Daylio backup and
+// a Day One export.
+type VerificationReport struct {
+	SourceEntries   int
+	ExportedEntries int
+	Matched         int
+	Discrepancies   []Discrepancy
+}
+
+// OK is true when nothing was lost or changed.
+func (r *VerificationReport) OK() bool {
+	return len(r.Discrepancies) == 0
+}
+
+// VerifyDayOneExport compares the entries within a Daylio backup against a Day
+// One JSON ZIP file made from it.
+func VerifyDayOneExport(providedBackupFile string, zipFile string) (*VerificationReport, error) {
+	entries, err := daylio.GetEntriesFromBackupFile(providedBackupFile)
+	if err != nil {
+		return nil, err
+	}
+	exports, err := dayone.ReadExportZip(zipFile)
+	if err != nil {
+		return nil, err
+	}
+	exported := []types.DayOneEntry{}
+	for _, export := range exports {
+		for _, e := range export.Entries {
+			if !dayone.IsGeneratedEntry(&e) {
+				exported = append(exported, e)
+			}
+		}
+	}
+	log.Infof("Verifying %d Daylio entries against %d Day One entries", len(entries), len(exported))
+	return verifyEntries(entries, exported)
+}
+
+type verifiedEntry struct {
+	entry   *types.DayOneEntry
+	matched bool
+}
+
+func verifyEntries(source []daylio.Entry, exported []types.DayOneEntry) (*VerificationReport, error) {
+	r := VerificationReport{
+		SourceEntries:   len(source),
+		ExportedEntries: len(exported),
+	}
+	byTime := map[time.Time][]*verifiedEntry{}
+	all := []*verifiedEntry{}
+	for idx := range exported {
+		v := &verifiedEntry{entry: &exported[idx]}
+		t := time.Time(exported[idx].CreationDate).UTC()
+		byTime[t] = append(byTime[t], v)
+		all = append(all, v)
+	}
+	unmatched := []*daylio.Entry{}
+	for idx := range source {
+		entry := &source[idx]
+		t, err := entryTime(entry)
+		if err != nil {
+			return nil, err
+		}
+		v := bestMatch(entry, byTime[t])
+		if v == nil {
+			unmatched = append(unmatched, entry)
+			continue
+		}
+		v.matched = true
+		r.Matched++
+		r.Discrepancies = append(r.Discrepancies, compareEntries(entry, t, v.entry)...)
+	}
+	for _, entry := range unmatched {
+		t, _ := entryTime(entry)
+		if v, shift := findTimeZoneShift(entry, t, all); v != nil {
+			v.matched = true
+			r.Matched++
+			r.Discrepancies = append(r.Discrepancies, Discrepancy{
+				Kind:    DiscrepancyTimeZone,
+				Time:    t,
+				UUID:    v.entry.UUID,
+				Details: fmt.Sprintf("exported entry is shifted by %s", shift),
+			})
+			continue
+		}
+		r.Discrepancies = append(r.Discrepancies, Discrepancy{
+			Kind:    DiscrepancyMissing,
+			Time:    t,
+			Details: fmt.Sprintf("'%s' is not in the export", summarizeText(createDayOneText(entry))),
+		})
+	}
+	for _, v := range all {
+		if v.matched {
+			continue
+		}
+		kind := DiscrepancyUnexpected
+		details := "not in the Daylio backup"
+		if isDuplicate(v, all) {
+			kind = DiscrepancyDuplicated
+			details = "exported more than once"
+		}
+		r.Discrepancies = append(r.Discrepancies, Discrepancy{
+			Kind:    kind,
+			Time:    time.Time(v.entry.CreationDate).UTC(),
+			UUID:    v.entry.UUID,
+			Details: details,
+		})
+	}
+	sort.SliceStable(r.Discrepancies, func(i, j int) bool {
+		return r.Discrepancies[i].Time.Before(r.Discrepancies[j].Time)
+	})
+	return &r, nil
+}
+
+// bestMatch finds the unmatched exported entry created at the same time as a
+// Daylio entry, preferring one with the same text, since Daylio allows several
+// entries at the same minute.
+func bestMatch(entry *daylio.Entry, candidates []*verifiedEntry) *verifiedEntry {
+	var first *verifiedEntry
+	text := createDayOneText(entry)
+	for _, c := range candidates {
+		if c.matched {
+			continue
+		}
+		if c.entry.Text == text {
+			return c
+		}
+		if first == nil {
+			first = c
+		}
+	}
+	return first
+}
+
+func findTimeZoneShift(entry *daylio.Entry, t time.Time, all []*verifiedEntry) (*verifiedEntry, time.Duration) {
+	text := createDayOneText(entry)
+	for _, v := range all {
+		if v.matched || v.entry.Text != text {
+			continue
+		}
+		shift := time.Time(v.entry.CreationDate).UTC().Sub(t)
+		if shift != 0 && shift%(15*time.Minute) == 0 && shift.Abs() <= MAX_TIME_ZONE_SHIFT {
+			return v, shift
+		}
+	}
+	return nil, 0
+}
+
+func isDuplicate(v *verifiedEntry, all []*verifiedEntry) bool {
+	for _, other := range all {
+		if other == v || !other.matched {
+			continue
+		}
+		if other.entry.Text == v.entry.Text &&
+			time.Time(other.entry.CreationDate).Equal(time.Time(v.entry.CreationDate)) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareEntries(entry *daylio.Entry, t time.Time, exported *types.DayOneEntry) []Discrepancy {
+	out := []Discrepancy{}
+	add := func(kind DiscrepancyKind, details string) {
+		out = append(out, Discrepancy{Kind: kind, Time: t, UUID: exported.UUID, Details: details})
+	}
+	if want := createDayOneText(entry); exported.Text != want {
+		add(DiscrepancyText, fmt.Sprintf("expected '%s', got '%s'", summarizeText(want), summarizeText(exported.Text)))
+	}
+	tags := map[string]bool{}
+	exportedMood := ""
+	for _, tag := range exported.Tags {
+		tags[tag] = true
+		if m, ok := strings.CutPrefix(tag, "mood: "); ok {
+			exportedMood = m
+		}
+	}
+	missing := []string{}
+	for _, a := range entry.ActivityNames() {
+		if !tags[a] {
+			missing = append(missing, a)
+		}
+	}
+	if len(missing) > 0 {
+		add(DiscrepancyTags, fmt.Sprintf("missing tags: %s", strings.Join(missing, ", ")))
+	}
+	if exportedMood != entry.Mood {
+		add(DiscrepancyMood, fmt.Sprintf("expected '%s', got '%s'", entry.Mood, exportedMood))
+	}
+	return out
+}
+
+// summarizeText shortens text so that reports stay readable.
+func summarizeText(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if r := []rune(s); len(r) > 40 {
+		return string(r[:40]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,84 @@
+package exporter
+
+import (
+	"exporter/daylio"
+	"exporter/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockBackupEntries() []daylio.Entry {
+	return []daylio.Entry{
+		{FullDate: "2023-12-17", Time: "08:00", Mood: "rad", ActivitiesList: []string{"activity 1", "mood: rad"}, Note: "note text 1"},
+		{FullDate: "2023-12-16", Time: "08:00", Mood: "good", ActivitiesList: []string{"activity 2", "mood: good"}, Note: "note text 2"},
+		{FullDate: "2023-12-15", Time: "08:00", Mood: "bad", ActivitiesList: []string{"activity 3", "mood: bad"}, Note: "note text 3"},
+	}
+}
+
+func TestVerifyingMatchingExport(t *testing.T) {
+	source := mockBackupEntries()
+	exported := dayOneEntries(mustConvertEntries(t, source))
+	got, err := verifyEntries(source, exported)
+	require.NoError(t, err)
+	assert.True(t, got.OK())
+	assert.Equal(t, 3, got.Matched)
+}
+
+func TestVerifyingExportWithDiscrepancies(t *testing.T) {
+	source := mockBackupEntries()
+	exported := dayOneEntries(mustConvertEntries(t, source))
+	exported[0].UUID = "ALTERED"
+	exported[0].Text = "Note\n\nsomething else"
+	exported[0].Tags = []string{"mood: good"}
+	exported[1].UUID = "SHIFTED"
+	exported[1].CreationDate = types.DayOneDateTime(mustGetZuluTime("2023-12-16T14:00:00Z"))
+	exported = append(exported, exported[0])
+	exported[3].UUID = "DUPLICATE"
+	exported = append(exported, types.DayOneEntry{
+		UUID:         "EXTRA",
+		Text:         "Note\n\nwho wrote this?",
+		CreationDate: types.DayOneDateTime(mustGetZuluTime("2023-12-01T08:00:00Z")),
+	})
+	exported = exported[1:]
+	got, err := verifyEntries(source, exported)
+	require.NoError(t, err)
+	assert.False(t, got.OK())
+	assert.Equal(t, 3, got.Matched)
+	kinds := map[DiscrepancyKind][]string{}
+	for _, d := range got.Discrepancies {
+		kinds[d.Kind] = append(kinds[d.Kind], d.UUID)
+	}
+	assert.Equal(t, map[DiscrepancyKind][]string{
+		DiscrepancyUnexpected: {"EXTRA"},
+		DiscrepancyTimeZone:   {"SHIFTED"},
+		DiscrepancyText:       {"DUPLICATE"},
+		DiscrepancyTags:       {"DUPLICATE"},
+		DiscrepancyMood:       {"DUPLICATE"},
+	}, kinds)
+	for _, d := range got.Discrepancies {
+		if d.Kind == DiscrepancyTimeZone {
+			assert.Equal(t, "[timezone] 2023-12-16 08:00 (SHIFTED): exported entry is shifted by 6h0m0s", d.String())
+		}
+	}
+}
+
+func TestVerifyingExportWithMissingAndDuplicatedEntries(t *testing.T) {
+	source := mockBackupEntries()
+	exported := dayOneEntries(mustConvertEntries(t, source))
+	exported[0].UUID = "ORIGINAL"
+	exported[1] = exported[0]
+	exported[1].UUID = "COPY"
+	got, err := verifyEntries(source, exported)
+	require.NoError(t, err)
+	require.Len(t, got.Discrepancies, 2)
+	assert.Equal(t, Discrepancy{
+		Kind:    DiscrepancyMissing,
+		Time:    time.Date(2023, 12, 16, 8, 0, 0, 0, time.UTC),
+		Details: "'Note  note text 2' is not in the export",
+	}, got.Discrepancies[0])
+	assert.Equal(t, DiscrepancyDuplicated, got.Discrepancies[1].Kind)
+	assert.Equal(t, "COPY", got.Discrepancies[1].UUID)
+}
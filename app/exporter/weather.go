@@ -0,0 +1,383 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"errors"
+	"exporter/types"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MAX_WEATHER_STATION_DISTANCE_KM is how far away weather can be recorded and
+// still be given to an entry with coordinates.
+const MAX_WEATHER_STATION_DISTANCE_KM = 100
+
+// WeatherUnits are the units of NOAA temperature columns. NOAA daily summaries
+// are in "standard" units (Fahrenheit) unless "metric" was chosen when they
+// were ordered.
+type WeatherUnits string
+
+const (
+	WeatherUnitsStandard WeatherUnits = "standard"
+	WeatherUnitsMetric   WeatherUnits = "metric"
+)
+
+// ParseWeatherUnits parses the name of weather units, ignoring case.
+func ParseWeatherUnits(s string) (WeatherUnits, error) {
+	switch WeatherUnits(strings.ToLower(s)) {
+	case WeatherUnitsStandard:
+		return WeatherUnitsStandard, nil
+	case WeatherUnitsMetric:
+		return WeatherUnitsMetric, nil
+	default:
+		return "", invalidOption(fmt.Errorf("Not valid weather units: %s", s))
+	}
+}
+
+// WeatherRecord is the weather on a day, optionally at a location.
+type WeatherRecord struct {
+	Date string
+	// Location names where the weather was recorded, i.e. a city or a NOAA
+	// station. It's empty when the record is for anywhere.
+	Location       string
+	HasCoordinates bool
+	Latitude       float64
+	Longitude      float64
+	Weather        types.DayOneWeather
+	// localSunrise and localSunset are set when sunrise and sunset had no
+	// date, so their clock time is in the journal's time zone rather than UTC.
+	localSunrise bool
+	localSunset  bool
+}
+
+// WeatherHistory is historical weather by day, read from a CSV file with a
+// header row. These columns are understood, ignoring case; any others are
+// ignored:
+//
+//	date                         YYYY-MM-DD. Required.
+//	location, name               Where the weather was recorded.
+//	latitude, longitude          The coordinates of that location.
+//	conditions                   i.e. "Partly Cloudy".
+//	temperature_c, temperature_f The temperature during the day.
+//	sunrise, sunset              HH:MM in the journal's time zone, or RFC
+//	                             3339 times.
+//	tavg, tmax, tmin, prcp, snow As within NOAA daily summaries.
+type WeatherHistory struct {
+	days      map[string][]WeatherRecord
+	locations map[string]bool
+}
+
+// ReadWeatherHistoryFile reads historical weather from a CSV file. See
+// WeatherHistory for its columns.
+func ReadWeatherHistoryFile(path string, units WeatherUnits) (*WeatherHistory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadWeatherHistory(f, units)
+}
+
+// ReadWeatherHistory reads historical weather from CSV. See WeatherHistory
+// for its columns.
+func ReadWeatherHistory(r io.Reader, units WeatherUnits) (*WeatherHistory, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, invalidOption(fmt.Errorf("Not a valid weather file: %w", err))
+	}
+	columns := map[string]int{}
+	for idx, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = idx
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, invalidOption(errors.New("Not a valid weather file: it has no 'date' column"))
+	}
+	h := WeatherHistory{days: map[string][]WeatherRecord{}, locations: map[string]bool{}}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, invalidOption(fmt.Errorf("Not a valid weather file: %w", err))
+		}
+		line, _ := cr.FieldPos(0)
+		rec, ok, err := parseWeatherRow(row, columns, units)
+		if err != nil {
+			return nil, invalidOption(fmt.Errorf("Not a valid weather file: line %d: %w", line, err))
+		}
+		if !ok {
+			continue
+		}
+		h.days[rec.Date] = append(h.days[rec.Date], rec)
+		h.locations[rec.Location] = true
+	}
+	return &h, nil
+}
+
+// parseWeatherRow is false when a row has no weather in it.
+func parseWeatherRow(row []string, columns map[string]int, units WeatherUnits) (WeatherRecord, bool, error) {
+	value := func(name string) string {
+		if idx, ok := columns[name]; ok && idx < len(row) {
+			return strings.TrimSpace(row[idx])
+		}
+		return ""
+	}
+	number := func(name string) (*float64, error) {
+		s := value(name)
+		if s == "" {
+			return nil, nil
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Not a number for '%s': %s", name, s)
+		}
+		return &n, nil
+	}
+	date, err := time.Parse("2006-01-02", value("date"))
+	if err != nil {
+		return WeatherRecord{}, false, fmt.Errorf("Not a valid date: %s", value("date"))
+	}
+	rec := WeatherRecord{Date: date.Format("2006-01-02"), Location: value("location")}
+	if rec.Location == "" {
+		rec.Location = value("name")
+	}
+	lat, err := number("latitude")
+	if err != nil {
+		return rec, false, err
+	}
+	lon, err := number("longitude")
+	if err != nil {
+		return rec, false, err
+	}
+	if lat != nil && lon != nil {
+		rec.HasCoordinates, rec.Latitude, rec.Longitude = true, *lat, *lon
+	}
+	if rec.Weather.TemperatureCelsius, err = weatherTemperature(number, units); err != nil {
+		return rec, false, err
+	}
+	rec.Weather.ConditionsDescription = value("conditions")
+	if rec.Weather.ConditionsDescription == "" {
+		if rec.Weather.ConditionsDescription, err = noaaConditions(number); err != nil {
+			return rec, false, err
+		}
+	}
+	rec.Weather.WeatherCode = weatherCode(rec.Weather.ConditionsDescription)
+	if rec.Weather.SunriseDate, rec.localSunrise, err = weatherTime(date, value("sunrise")); err != nil {
+		return rec, false, err
+	}
+	if rec.Weather.SunsetDate, rec.localSunset, err = weatherTime(date, value("sunset")); err != nil {
+		return rec, false, err
+	}
+	return rec, rec.Weather != (types.DayOneWeather{}), nil
+}
+
+// weatherTemperature is in Celsius, preferring an explicit temperature over
+// NOAA's average, which falls back to halfway between the high and low.
+func weatherTemperature(number func(string) (*float64, error), units WeatherUnits) (*float64, error) {
+	if c, err := number("temperature_c"); c != nil || err != nil {
+		return c, err
+	}
+	if f, err := number("temperature_f"); f != nil || err != nil {
+		return fahrenheitToCelsius(f), err
+	}
+	t, err := number("tavg")
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		high, err := number("tmax")
+		if err != nil {
+			return nil, err
+		}
+		low, err := number("tmin")
+		if err != nil {
+			return nil, err
+		}
+		if high == nil || low == nil {
+			return nil, nil
+		}
+		avg := (*high + *low) / 2
+		t = &avg
+	}
+	if units == WeatherUnitsMetric {
+		return t, nil
+	}
+	return fahrenheitToCelsius(t), nil
+}
+
+func fahrenheitToCelsius(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	c := math.Round((*f-32)*5/9*10) / 10
+	return &c
+}
+
+// noaaConditions describes days with snow or rain, since NOAA daily summaries
+// don't otherwise describe conditions.
+func noaaConditions(number func(string) (*float64, error)) (string, error) {
+	snow, err := number("snow")
+	if err != nil {
+		return "", err
+	}
+	if snow != nil && *snow > 0 {
+		return "Snow", nil
+	}
+	rain, err := number("prcp")
+	if err != nil {
+		return "", err
+	}
+	if rain != nil && *rain > 0 {
+		return "Rain", nil
+	}
+	return "", nil
+}
+
+// weatherCodes are the Day One weather codes that conditions are matched
+// against, in order.
+var weatherCodes = []struct {
+	words []string
+	code  string
+}{
+	{[]string{"thunder"}, "thunderstorm"},
+	{[]string{"sleet", "freezing"}, "sleet"},
+	{[]string{"snow", "flurr"}, "snow"},
+	{[]string{"rain", "drizzle", "shower"}, "rain"},
+	{[]string{"fog", "mist", "haze"}, "fog"},
+	{[]string{"wind"}, "wind"},
+	{[]string{"partly", "mostly sunny", "mostly clear"}, "partly-cloudy-day"},
+	{[]string{"cloud", "overcast"}, "cloudy"},
+	{[]string{"clear", "sunny", "fair"}, "clear-day"},
+}
+
+// weatherCode picks the Day One icon for conditions. It's empty when none
+// fits.
+func weatherCode(conditions string) string {
+	conditions = strings.ToLower(conditions)
+	for _, c := range weatherCodes {
+		for _, word := range c.words {
+			if strings.Contains(conditions, word) {
+				return c.code
+			}
+		}
+	}
+	return ""
+}
+
+// weatherTime reads sunrise and sunset. Times without a date are on the day
+// of the record and, like Daylio entries, have no time zone until they're
+// looked up, so they're true.
+func weatherTime(date time.Time, s string) (*types.DayOneDateTime, bool, error) {
+	if s == "" {
+		return nil, false, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		out := types.DayOneDateTime(t.UTC())
+		return &out, false, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return nil, false, fmt.Errorf("Not a valid time: %s", s)
+	}
+	out := types.DayOneDateTime(date.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute))
+	return &out, true, nil
+}
+
+// weatherIn is a record's weather with sunrise and sunset that had no date
+// put on zone's clock.
+func (rec *WeatherRecord) weatherIn(zone *time.Location) types.DayOneWeather {
+	w := rec.Weather
+	if rec.localSunrise {
+		w.SunriseDate = clockTimeIn(w.SunriseDate, zone)
+	}
+	if rec.localSunset {
+		w.SunsetDate = clockTimeIn(w.SunsetDate, zone)
+	}
+	return w
+}
+
+func clockTimeIn(t *types.DayOneDateTime, zone *time.Location) *types.DayOneDateTime {
+	c := time.Time(*t)
+	out := types.DayOneDateTime(time.Date(c.Year(), c.Month(), c.Day(), c.Hour(), c.Minute(), 0, 0, zone).UTC())
+	return &out
+}
+
+// Lookup finds the weather on a day at an entry's location. Coordinates are
+// matched with the nearest record within MAX_WEATHER_STATION_DISTANCE_KM,
+// then place names are matched with record locations. Otherwise, records
+// without a location are used, as are records from a file with only one
+// location, unless that location is too far from the entry's coordinates.
+// Sunrise and sunset without a date are in zone, which defaults to UTC.
+func (h *WeatherHistory) Lookup(date string, loc *types.DayOneEntryLocation, zone *time.Location) (types.DayOneWeather, bool) {
+	if zone == nil {
+		zone = time.UTC
+	}
+	records := h.days[date]
+	if len(records) == 0 {
+		return types.DayOneWeather{}, false
+	}
+	lat, lon, hasCoordinates := locationCoordinates(loc)
+	if hasCoordinates {
+		nearest, nearestKM := -1, float64(MAX_WEATHER_STATION_DISTANCE_KM)
+		for idx, rec := range records {
+			if !rec.HasCoordinates {
+				continue
+			}
+			if km := distanceKM(lat, lon, rec.Latitude, rec.Longitude); km <= nearestKM {
+				nearest, nearestKM = idx, km
+			}
+		}
+		if nearest >= 0 {
+			return records[nearest].weatherIn(zone), true
+		}
+	}
+	for _, name := range []string{loc.PlaceName, loc.LocalityName} {
+		if name == "" {
+			continue
+		}
+		for _, rec := range records {
+			if strings.EqualFold(rec.Location, name) {
+				return rec.weatherIn(zone), true
+			}
+		}
+	}
+	for _, rec := range records {
+		if rec.Location == "" && !rec.HasCoordinates {
+			return rec.weatherIn(zone), true
+		}
+	}
+	// When both have coordinates, they were already found to be too far
+	// apart.
+	if len(h.locations) == 1 && (!hasCoordinates || !records[0].HasCoordinates) {
+		return records[0].weatherIn(zone), true
+	}
+	return types.DayOneWeather{}, false
+}
+
+// locationCoordinates are false when a location has none, which Day One
+// writes as 0, 0.
+func locationCoordinates(loc *types.DayOneEntryLocation) (float64, float64, bool) {
+	lat, lon := loc.Latitude, loc.Longitude
+	if lat == 0 && lon == 0 {
+		lat, lon = loc.Location.Region.Center.Latitude, loc.Location.Region.Center.Longitude
+	}
+	return float64(lat), float64(lon), lat != 0 || lon != 0
+}
+
+// distanceKM is the great-circle distance between two coordinates.
+func distanceKM(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKM = 6371
+	toRadians := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat, dLon := toRadians(lat2-lat1), toRadians(lon2-lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKM * math.Asin(math.Sqrt(a))
+}
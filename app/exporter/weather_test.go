@@ -0,0 +1,129 @@
+package exporter
+
+import (
+	"exporter/types"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWeatherCSV = `date,location,conditions,temperature_c,sunrise,sunset
+2023-12-17,,Partly Cloudy,4.5,07:12,16:31
+2023-12-16,,,,,
+`
+
+const testNOAACSV = `"STATION","NAME","LATITUDE","LONGITUDE","ELEVATION","DATE","PRCP","SNOW","TAVG","TMAX","TMIN"
+"USW00094846","CHICAGO OHARE INTERNATIONAL AIRPORT, IL US","41.96019","-87.93162","201.8","2023-12-17","0.12","0.0","","41","27"
+"USW00012839","MIAMI INTERNATIONAL AIRPORT, FL US","25.7881","-80.3169","8.8","2023-12-17","0.00","0.0","77","",""
+`
+
+func mustReadWeatherHistory(t *testing.T, csv string, units WeatherUnits) *WeatherHistory {
+	h, err := ReadWeatherHistory(strings.NewReader(csv), units)
+	require.NoError(t, err)
+	return h
+}
+
+func TestReadWeatherHistory(t *testing.T) {
+	h := mustReadWeatherHistory(t, testWeatherCSV, WeatherUnitsStandard)
+	got, ok := h.Lookup("2023-12-17", &types.DayOneEntryLocation{}, nil)
+	require.True(t, ok)
+	assert.Equal(t, "Partly Cloudy", got.ConditionsDescription)
+	assert.Equal(t, "partly-cloudy-day", got.WeatherCode)
+	require.NotNil(t, got.TemperatureCelsius)
+	assert.Equal(t, 4.5, *got.TemperatureCelsius)
+	require.NotNil(t, got.SunriseDate)
+	assert.Equal(t, "2023-12-17T07:12:00Z", got.SunriseDate.Format("2006-01-02T15:04:05Z"))
+	assert.Equal(t, "2023-12-17T16:31:00Z", got.SunsetDate.Format("2006-01-02T15:04:05Z"))
+
+	_, ok = h.Lookup("2023-12-16", &types.DayOneEntryLocation{}, nil)
+	assert.False(t, ok, "rows without weather are skipped")
+	_, ok = h.Lookup("2023-12-15", &types.DayOneEntryLocation{}, nil)
+	assert.False(t, ok)
+}
+
+func TestReadWeatherHistoryFromNOAA(t *testing.T) {
+	h := mustReadWeatherHistory(t, testNOAACSV, WeatherUnitsStandard)
+	chicago := types.DayOneEntryLocation{PlaceName: "Home", Latitude: 41.88, Longitude: -87.63}
+	got, ok := h.Lookup("2023-12-17", &chicago, nil)
+	require.True(t, ok)
+	assert.Equal(t, "Rain", got.ConditionsDescription)
+	assert.Equal(t, "rain", got.WeatherCode)
+	assert.Equal(t, 1.1, *got.TemperatureCelsius)
+
+	miami := types.DayOneEntryLocation{LocalityName: "miami international airport, fl us"}
+	got, ok = h.Lookup("2023-12-17", &miami, nil)
+	require.True(t, ok)
+	assert.Equal(t, 25.0, *got.TemperatureCelsius)
+	assert.Empty(t, got.ConditionsDescription)
+
+	_, ok = h.Lookup("2023-12-17", &types.DayOneEntryLocation{}, nil)
+	assert.False(t, ok, "there's no telling which location an entry without one was at")
+	_, ok = h.Lookup("2023-12-17", &types.DayOneEntryLocation{Latitude: 51.5, Longitude: -0.12}, nil)
+	assert.False(t, ok, "London is too far from either station")
+}
+
+func TestReadWeatherHistoryFromASingleFarAwayStation(t *testing.T) {
+	h := mustReadWeatherHistory(t, "DATE,NAME,LATITUDE,LONGITUDE,TMAX,TMIN\n2023-12-17,Oslo,59.9,10.7,2,-4\n", WeatherUnitsMetric)
+	_, ok := h.Lookup("2023-12-17", &types.DayOneEntryLocation{Latitude: 51.5, Longitude: -0.12}, nil)
+	assert.False(t, ok, "London is too far from Oslo")
+	_, ok = h.Lookup("2023-12-17", &types.DayOneEntryLocation{}, nil)
+	assert.True(t, ok, "entries without a location use the only station")
+}
+
+func TestReadWeatherHistoryPlacesSunriseInTimeZone(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+	h := mustReadWeatherHistory(t, testWeatherCSV, WeatherUnitsStandard)
+	got, ok := h.Lookup("2023-12-17", &types.DayOneEntryLocation{}, chicago)
+	require.True(t, ok)
+	require.NotNil(t, got.SunriseDate)
+	assert.Equal(t, "2023-12-17T13:12:00Z", got.SunriseDate.Format("2006-01-02T15:04:05Z"))
+	require.NotNil(t, got.SunsetDate)
+	assert.Equal(t, "2023-12-17T22:31:00Z", got.SunsetDate.Format("2006-01-02T15:04:05Z"))
+}
+
+func TestReadWeatherHistoryInMetricUnits(t *testing.T) {
+	h := mustReadWeatherHistory(t, "DATE,NAME,TMAX,TMIN\n2023-12-17,Oslo,2,-4\n", WeatherUnitsMetric)
+	got, ok := h.Lookup("2023-12-17", &types.DayOneEntryLocation{}, nil)
+	require.True(t, ok, "files with a single location apply everywhere")
+	assert.Equal(t, -1.0, *got.TemperatureCelsius)
+}
+
+func TestReadWeatherHistoryFailures(t *testing.T) {
+	for name, csv := range map[string]string{
+		"empty":       "",
+		"no date":     "location,conditions\nChicago,Sunny\n",
+		"bad date":    "date,conditions\n17/12/2023,Sunny\n",
+		"bad number":  "date,temperature_c\n2023-12-17,warm\n",
+		"bad sunrise": "date,sunrise\n2023-12-17,dawn\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadWeatherHistory(strings.NewReader(csv), WeatherUnitsStandard)
+			assert.ErrorIs(t, err, ErrInvalidOption)
+		})
+	}
+}
+
+func TestWeatherCode(t *testing.T) {
+	for conditions, want := range map[string]string{
+		"Sunny":               "clear-day",
+		"Mostly Cloudy":       "cloudy",
+		"Light Rain Showers":  "rain",
+		"Thunderstorms, Rain": "thunderstorm",
+		"Freezing Drizzle":    "sleet",
+		"Volcanic Ash":        "",
+	} {
+		assert.Equal(t, want, weatherCode(conditions), conditions)
+	}
+}
+
+func TestParseWeatherUnits(t *testing.T) {
+	got, err := ParseWeatherUnits("Metric")
+	require.NoError(t, err)
+	assert.Equal(t, WeatherUnitsMetric, got)
+	_, err = ParseWeatherUnits("kelvin")
+	assert.ErrorIs(t, err, ErrInvalidOption)
+}
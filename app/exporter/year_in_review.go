@@ -0,0 +1,114 @@
+package exporter
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"exporter/daylio"
+	"exporter/dayone"
+	"exporter/stats"
+	"exporter/types"
+	"fmt"
+	"image/png"
+	"strings"
+	"time"
+)
+
+const (
+	YEAR_IN_REVIEW_TAG  = dayone.YEAR_IN_REVIEW_TAG
+	YEAR_IN_PIXELS_TYPE = "png"
+)
+
+// createYearInReviewEntry builds a synthetic Day One entry for a year with a
+// Year in Pixels image attached and a short summary of that year's moods.
+func createYearInReviewEntry(entries []daylio.Entry, year int, palette stats.Palette, device *types.DeviceProfile, generators types.DayOneGenerators) (ConvertedEntry, error) {
+	pixels := stats.ComputeYearInPixels(entries, year)
+	var img bytes.Buffer
+	if err := stats.WritePNG(&img, pixels, palette); err != nil {
+		return ConvertedEntry{}, err
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(img.Bytes()))
+	if err != nil {
+		return ConvertedEntry{}, err
+	}
+	sum := md5.Sum(img.Bytes())
+	photo := types.DayOneEntryPhoto{
+		Identifier: generators.IDGenerator.CreateID(),
+		MD5:        hex.EncodeToString(sum[:]),
+		Type:       YEAR_IN_PIXELS_TYPE,
+		Width:      cfg.Width,
+		Height:     cfg.Height,
+	}
+
+	title := fmt.Sprintf("Year in Review %d", year)
+	summary := yearInReviewSummary(entries, year)
+	uuid, err := generators.UUIDGenerator.GenerateUUID()
+	if err != nil {
+		return ConvertedEntry{}, err
+	}
+	rt, err := marshalDayOneRichText(
+		device,
+		types.DayOneRichTextObject{
+			Text: title + "\n",
+			Attributes: &types.DayOneRichTextObjectAttributes{
+				Line: types.DayOneRichTextLineObject{Header: 1, Identifier: uuid},
+			},
+		},
+		types.DayOneRichTextObject{
+			EmbeddedObjects: []types.DayOneRichTextEmbeddedObject{{Type: "photo", Identifier: photo.Identifier}},
+		},
+		types.DayOneRichTextObject{Text: "\n" + summary},
+	)
+	if err != nil {
+		return ConvertedEntry{}, err
+	}
+	modified, err := generators.Timestamper.CreateModifiedTime()
+	if err != nil {
+		return ConvertedEntry{}, err
+	}
+
+	dayOneEntry := types.NewEmptyDayOneEntry(device)
+	dayOneEntry.UUID = generators.IDGenerator.CreateID()
+	dayOneEntry.Tags = []string{YEAR_IN_REVIEW_TAG}
+	dayOneEntry.CreationDate = types.DayOneDateTime(time.Date(year, time.December, 31, 23, 59, 0, 0, time.UTC))
+	dayOneEntry.ModifiedDate = types.DayOneDateTime(modified)
+	dayOneEntry.Text = fmt.Sprintf("%s\n\n![](dayone-moment://%s)\n\n%s", title, photo.Identifier, summary)
+	dayOneEntry.RichText = rt
+	dayOneEntry.Photos = []types.DayOneEntryPhoto{photo}
+	return ConvertedEntry{
+		DayOne: *dayOneEntry,
+		Attachments: []Attachment{{
+			Name:        photo.MD5 + "." + YEAR_IN_PIXELS_TYPE,
+			ContentType: "image/png",
+			Data:        img.Bytes(),
+		}},
+		Synthetic: true,
+	}, nil
+}
+
+func yearInReviewSummary(entries []daylio.Entry, year int) string {
+	prefix := fmt.Sprintf("%04d-", year)
+	inYear := []daylio.Entry{}
+	for idx := range entries {
+		if strings.HasPrefix(entries[idx].LocalDate(), prefix) {
+			inYear = append(inYear, entries[idx])
+		}
+	}
+	r, err := stats.Compute(inYear)
+	if err != nil || r.Entries == 0 {
+		return "No entries this year."
+	}
+	lines := []string{
+		fmt.Sprintf("Entries: %d on %d days", r.Entries, r.Days),
+		fmt.Sprintf("Average mood: %.2f", r.AverageMood),
+		fmt.Sprintf("Longest streak: %d days (%s to %s)", r.LongestStreak.Days, r.LongestStreak.Start, r.LongestStreak.End),
+	}
+	if len(r.Activities) > 0 {
+		top := []string{}
+		for idx := 0; idx < len(r.Activities) && idx < 5; idx++ {
+			top = append(top, r.Activities[idx].Name)
+		}
+		lines = append(lines, "Top activities: "+strings.Join(top, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"exporter/daylio"
+	"exporter/stats"
+	"exporter/types"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateYearInReviewEntry(t *testing.T) {
+	entries := mustGetMockDaylioEntries(t)
+	got, err := createYearInReviewEntry(entries, 2023, stats.DefaultPalette, &types.MacDeviceProfile, types.DefaultDayOneGenerators())
+	require.NoError(t, err)
+	assert.True(t, got.Synthetic)
+	assert.Equal(t, []string{YEAR_IN_REVIEW_TAG}, got.DayOne.Tags)
+	assert.Equal(t, time.Date(2023, time.December, 31, 23, 59, 0, 0, time.UTC), time.Time(got.DayOne.CreationDate))
+	require.Len(t, got.DayOne.Photos, 1)
+	require.Len(t, got.Attachments, 1)
+	photo, attachment := got.DayOne.Photos[0], got.Attachments[0]
+	sum := md5.Sum(attachment.Data)
+	assert.Equal(t, hex.EncodeToString(sum[:]), photo.MD5)
+	assert.Equal(t, photo.MD5+".png", attachment.Name)
+	assert.Contains(t, got.DayOne.Text, "![](dayone-moment://"+photo.Identifier+")")
+	assert.Contains(t, got.DayOne.Text, "Entries: 3 on")
+	assert.Contains(t, got.DayOne.RichText, `"identifier":"`+photo.Identifier+`"`)
+	cfg, err := png.DecodeConfig(bytes.NewReader(attachment.Data))
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Width, photo.Width)
+	assert.Equal(t, cfg.Height, photo.Height)
+}
+
+func TestCreateYearInReviewEntryWithoutEntries(t *testing.T) {
+	got, err := createYearInReviewEntry([]daylio.Entry{}, 2020, stats.DefaultPalette, &types.MacDeviceProfile, types.DefaultDayOneGenerators())
+	require.NoError(t, err)
+	assert.Contains(t, got.DayOne.Text, "No entries this year.")
+}
+
+func TestCreateYearInReviewEntryOnTheLocalDay(t *testing.T) {
+	// 20:00 on Dec 31, 2023 in Chicago is already 2024 in UTC.
+	entries := []daylio.Entry{{FullDate: "2024-01-01", Time: "02:00", Mood: "rad", TimeZoneOffset: -21600000}}
+	got, err := createYearInReviewEntry(entries, 2023, stats.DefaultPalette, &types.MacDeviceProfile, types.DefaultDayOneGenerators())
+	require.NoError(t, err)
+	assert.Contains(t, got.DayOne.Text, "Entries: 1 on 1 days")
+	got, err = createYearInReviewEntry(entries, 2024, stats.DefaultPalette, &types.MacDeviceProfile, types.DefaultDayOneGenerators())
+	require.NoError(t, err)
+	assert.Contains(t, got.DayOne.Text, "No entries this year.")
+}
+
+func TestYearInReviewIsOnlyWrittenToDayOne(t *testing.T) {
+	daylioEntries := mustGetMockDaylioEntries(t)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+	require.NoError(t, os.MkdirAll(DEFAULT_EXPORT_DIRECTORY, 0o755))
+	entries := mustConvertEntries(t, daylioEntries)
+	review, err := createYearInReviewEntry(daylioEntries, 2023, stats.DefaultPalette, &types.MacDeviceProfile, types.DefaultDayOneGenerators())
+	require.NoError(t, err)
+	entries = append(entries, review)
+
+	r, err := (&DayOneSink{Directory: DEFAULT_EXPORT_DIRECTORY}).Write(entries, nil)
+	require.NoError(t, err)
+	zr, err := zip.OpenReader(r.Files()[0])
+	require.NoError(t, err)
+	defer zr.Close()
+	names := []string{}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Contains(t, names, filepath.ToSlash(filepath.Join(DAY_ONE_PHOTOS_DIRECTORY, review.Attachments[0].Name)))
+	f, err := zr.Open(DAY_ONE_PHOTOS_DIRECTORY + "/" + review.Attachments[0].Name)
+	require.NoError(t, err)
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, review.Attachments[0].Data, data)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeICalendar(&buf, daylioEntriesOnly(entries), false))
+	assert.Len(t, validateRFC5545(t, buf.String()), 3)
+}
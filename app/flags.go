@@ -0,0 +1,226 @@
+package main
+
+import (
+	"errors"
+	"exporter/daylio"
+	"exporter/exporter"
+	"exporter/types"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	FILTER_USAGE = `	-from DATE		Only include entries on or after this date
+						(YYYY-MM-DD).
+	-to DATE		Only include entries on or before this date
+						(YYYY-MM-DD).
+	-activity NAMES		Only include entries with at least one of these
+						activities, separated by commas.
+	-exclude-activity NAMES	Leave out entries with any of these activities,
+						separated by commas.
+`
+)
+
+// OUTPUT_DIR_USAGE documents -output-dir, shared by every command that
+// writes into the export directory.
+const OUTPUT_DIR_USAGE = `	-output-dir DIR		Where to write exports. Missing folders are created.
+						Defaults to EXPORT_DIRECTORY, or "./exports".
+`
+
+// SINK_USAGE documents -format, -markdown-layout, -ical-all-day, and
+// -encrypt, shared by every command that writes an export.
+const SINK_USAGE = `	-format FORMATS		What to export to, separated by commas: "dayone"
+						(default), "markdown", "ndjson", "ical".
+	-markdown-layout LAYOUT	Write one Markdown file per "entry" (default) or
+						per "day".
+	-ical-all-day		Create all-day calendar events instead of events
+						at the time of each entry.
+	-encrypt		Encrypt the Day One JSON ZIP file with a passphrase,
+						read from EXPORT_PASSPHRASE or prompted for.
+`
+
+// DEVICE_USAGE documents -device, shared by every command that writes a Day
+// One export.
+const DEVICE_USAGE = `	-device PROFILE		What entries look like they were created on:
+						"mac" (default), "iphone", "android", "daylio"
+						to mark them as migrated from Daylio, or the path
+						to a YAML file describing a custom device. See the
+						README for the format.
+`
+
+// WEATHER_USAGE documents -weather and -weather-units, shared by every
+// command that writes a Day One export.
+const WEATHER_USAGE = `	-weather FILE		Add historical weather to entries from a CSV file,
+						i.e. NOAA daily summaries. See the README for
+						the format.
+	-weather-units UNITS	The units of NOAA temperatures within the weather
+						file: "standard" (default) or "metric".
+`
+
+// LOCATION_HISTORY_USAGE documents -location-history and -location-window,
+// shared by every command that writes a Day One export.
+const LOCATION_HISTORY_USAGE = `	-location-history PATH	Give entries the place they were written at from
+						Google Takeout location history: Records.json,
+						a "Semantic Location History" file, or a folder
+						of them. Entries with the "home" activity keep
+						HOME_ADDRESS_JSON.
+	-location-window DURATION	How far from an entry's time a place can be
+						and still be used, i.e. "1h". Defaults to "30m".
+`
+
+// parseFlags parses a command's flags, exiting with EXIT_USAGE when they
+// aren't valid. Flag sets are created with flag.ContinueOnError, since
+// flag.ExitOnError would exit with 2, which verify uses for discrepancies.
+func parseFlags(flags *flag.FlagSet, args []string) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(EXIT_USAGE)
+	}
+}
+
+// exitWithUsage prints a command's usage and exits with EXIT_USAGE, i.e. when
+// it's given the wrong number of arguments.
+func exitWithUsage(flags *flag.FlagSet) {
+	flags.Usage()
+	os.Exit(EXIT_USAGE)
+}
+
+// filterFlags are the flags shared by every command that reads entries.
+type filterFlags struct {
+	from              *string
+	to                *string
+	activities        *string
+	excludeActivities *string
+}
+
+func addFilterFlags(flags *flag.FlagSet) *filterFlags {
+	return &filterFlags{
+		from:              flags.String("from", "", ""),
+		to:                flags.String("to", "", ""),
+		activities:        flags.String("activity", "", ""),
+		excludeActivities: flags.String("exclude-activity", "", ""),
+	}
+}
+
+func (f *filterFlags) filter() (daylio.Filter, error) {
+	filter, err := daylio.ParseFilter(*f.from, *f.to, *f.activities, *f.excludeActivities)
+	return filter, usage(err)
+}
+
+// sinkFlags are the flags shared by every command that writes an export.
+type sinkFlags struct {
+	formats        *string
+	markdownLayout *string
+	icalAllDay     *bool
+	encrypt        *bool
+}
+
+func addSinkFlags(flags *flag.FlagSet) *sinkFlags {
+	return &sinkFlags{
+		formats:        flags.String("format", exporter.DAY_ONE_SINK_NAME, ""),
+		markdownLayout: flags.String("markdown-layout", string(exporter.MarkdownLayoutPerEntry), ""),
+		icalAllDay:     flags.Bool("ical-all-day", false, ""),
+		encrypt:        flags.Bool("encrypt", false, ""),
+	}
+}
+
+// sinks creates the sinks chosen with -format, writing into the export
+// directory. The passphrase is read now when -encrypt is set, so that it's
+// never asked for halfway through.
+func (f *sinkFlags) sinks(settings exporter.ExportSettings) ([]exporter.Sink, error) {
+	layout, err := exporter.ParseMarkdownLayout(*f.markdownLayout)
+	if err != nil {
+		return nil, err
+	}
+	passphrase := ""
+	if *f.encrypt {
+		if passphrase, err = readPassphrase(true); err != nil {
+			return nil, err
+		}
+	}
+	return exporter.NewSinks(strings.Split(*f.formats, ","), exporter.SinkOptions{
+		MarkdownLayout: layout,
+		ICalAllDay:     *f.icalAllDay,
+		Passphrase:     passphrase,
+		Directory:      settings.Directory,
+		JournalName:    settings.JournalName,
+	})
+}
+
+// deviceProfile resolves -device, leaving the default profile in place when
+// it isn't set.
+func deviceProfile(nameOrPath string) (types.DeviceProfile, error) {
+	if nameOrPath == "" {
+		return types.DeviceProfile{}, nil
+	}
+	p, err := exporter.ResolveDeviceProfile(nameOrPath)
+	if err != nil {
+		return p, usage(err)
+	}
+	return p, nil
+}
+
+// weatherFlags are the flags shared by every command that writes a Day One
+// export.
+type weatherFlags struct {
+	file  *string
+	units *string
+}
+
+func addWeatherFlags(flags *flag.FlagSet) *weatherFlags {
+	return &weatherFlags{
+		file:  flags.String("weather", "", ""),
+		units: flags.String("weather-units", string(exporter.WeatherUnitsStandard), ""),
+	}
+}
+
+// history reads the weather file, if one was provided.
+func (f *weatherFlags) history() (*exporter.WeatherHistory, error) {
+	units, err := exporter.ParseWeatherUnits(*f.units)
+	if err != nil {
+		return nil, usage(err)
+	}
+	if *f.file == "" {
+		return nil, nil
+	}
+	h, err := exporter.ReadWeatherHistoryFile(*f.file, units)
+	if err != nil {
+		return nil, usage(err)
+	}
+	return h, nil
+}
+
+// locationHistoryFlags are the flags shared by every command that writes a
+// Day One export.
+type locationHistoryFlags struct {
+	path   *string
+	window *time.Duration
+}
+
+func addLocationHistoryFlags(flags *flag.FlagSet) *locationHistoryFlags {
+	return &locationHistoryFlags{
+		path:   flags.String("location-history", "", ""),
+		window: flags.Duration("location-window", exporter.DEFAULT_LOCATION_WINDOW, ""),
+	}
+}
+
+// history reads the location history, if it was provided.
+func (f *locationHistoryFlags) history() (*exporter.LocationHistory, error) {
+	if *f.window <= 0 {
+		return nil, usage(fmt.Errorf("Not a valid duration for -location-window: %s", *f.window))
+	}
+	if *f.path == "" {
+		return nil, nil
+	}
+	h, err := exporter.ReadLocationHistoryFiles(*f.path)
+	if err != nil {
+		return nil, usage(err)
+	}
+	h.Window = *f.window
+	return h, nil
+}
@@ -2,23 +2,60 @@ package main
 
 import (
 	"exporter/exporter"
-	"exporter/types"
 	"os"
-	"path"
-	"path/filepath"
-
-	log "github.com/sirupsen/logrus"
 )
 
 const (
-	USAGE = `Usage: daylio-to-day-one [FILE]
+	USAGE = `Usage: daylio-to-day-one [OPTIONS] [FILE...]
+       daylio-to-day-one COMMAND [OPTIONS] [ARGS]
 Exports entries in a Daylio backup file to a Day One JSON ZIP file.
 
+COMMANDS
+
+	decrypt			Decrypts an export made with "-encrypt". Run
+						"daylio-to-day-one decrypt -h" for more.
+	to-daylio		Converts a Day One JSON ZIP file back into a CSV
+						that Daylio can import. Run "daylio-to-day-one
+						to-daylio -h" for more.
+	pixels			Draws a "Year in Pixels" image of your moods. Run
+						"daylio-to-day-one pixels -h" for more.
+	stats			Prints mood and activity statistics. Run
+						"daylio-to-day-one stats -h" for more.
+	review			Steps through converted entries to exclude,
+						retitle, or star them before writing the
+						export. Run "daylio-to-day-one review -h" for
+						more.
+	serve			Starts a local web page for converting backups
+						without a terminal. Run "daylio-to-day-one
+						serve -h" for more.
+	verify			Checks that every entry in a Daylio backup made it
+						into a Day One JSON ZIP file. Run
+						"daylio-to-day-one verify -h" for more.
+
 OPTIONS
 
 	FILE			The path to the Daylio backup file. Optional if
-						iCloud Backup is enabled within Daylio.
+						iCloud Backup is enabled within Daylio. Provide
+						several backups or CSV exports to merge them.
+	-on-conflict POLICY	What to do when merged files have different entries
+						at the same time: "keep-all" (default), "first",
+						"last", or "longest".
+` + FILTER_USAGE + OUTPUT_DIR_USAGE + SINK_USAGE + `	-journal NAME		The Day One journal to import entries into.
+						Defaults to JOURNAL_NAME, or "From Daylio".
+` + DEVICE_USAGE + LOCATION_HISTORY_USAGE + WEATHER_USAGE + `	-redact FILE		Mask names, phrases, and patterns within notes, and
+						replace the notes of entries with some activities,
+						as described by a YAML file. See the README for the
+						format.
+	-summaries		Add an entry summarizing every month and year to the
+						Day One export.
+	-year-in-review YEAR	Add a "Year in Review" entry with a Year in Pixels
+						image for that year to the Day One export.
+	-palette COLOURS	The colours of the Year in Pixels image. Run
+						"daylio-to-day-one pixels -h" for more.
+	-workers N		How many entries to convert at once. Defaults to
+						the number of CPUs.
 
+` + EXIT_CODES_USAGE + `
 GENERATING DAYLIO EXPORT FILES
 
 Do the following to generate a Daylio backup file and provide it to the Daylio to Day One Exporter:
@@ -34,22 +71,16 @@ Do the following to generate a Daylio backup file and provide it to the Daylio t
 `
 )
 
-func printSuccessMessage(r *types.DayOneExportResult) {
-	zf, err := filepath.Abs(r.ZipFile)
-	if err != nil {
-		panic(err)
-	}
-	log.Infof(`Your Day One JSON ZIP file is ready! Do the following on this computer to finish \
-importing your Daylio entries into Day One:
-
-1. Open the Day One app.
-2. Click on 'File', then 'Import', then 'JSON ZIP File'.
-3. Browse to this folder: %s
-4. Click on this file, then on Open: %s
-
-Your journal entries will appear in a new Day One journal called "%s". You can leave them there
-or move them into your desired journal.
-`, path.Dir(zf), filepath.Base(zf), r.JournalName)
+// commands are subcommands, i.e. "daylio-to-day-one to-daylio". Anything
+// else is treated as an export.
+var commands = map[string]func(args []string){
+	"to-daylio": runToDaylio,
+	"decrypt":   runDecrypt,
+	"verify":    runVerify,
+	"stats":     runStats,
+	"pixels":    runPixels,
+	"review":    runReview,
+	"serve":     runServe,
 }
 
 func main() {
@@ -57,22 +88,11 @@ func main() {
 		exporter.Version()
 		os.Exit(0)
 	}
-	if err := exporter.Initialize(); err != nil {
-		log.Errorf("Something went wrong while initializing the exporter: %s", err.Error())
-	}
-	providedBackupFile := ""
-	if len(os.Args) == 2 {
-		providedBackupFile = os.Args[1]
-	}
-	dayOneExports, err := exporter.ConvertToDayOneExportFromDaylioBackup(providedBackupFile, types.DefaultDayOneGenerators())
-	if err != nil {
-		log.Errorf("Something went wrong while performing the export: %s", err.Error())
-		os.Exit(1)
-	}
-	result, err := exporter.WriteDayOneExports(dayOneExports)
-	if err != nil {
-		log.Errorf("Something went wrong while writing the exports: %s", err.Error())
-		os.Exit(1)
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
 	}
-	printSuccessMessage(result)
+	runExport(os.Args[1:])
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"exporter/exporter"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readPassphrase reads a passphrase from EXPORT_PASSPHRASE, or prompts for it
+// without echoing it when that isn't set. Passphrases used for encryption are
+// prompted for twice so that typos don't lock anyone out of their journal.
+func readPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv(exporter.PASSPHRASE_ENV_VAR); p != "" {
+		return p, nil
+	}
+	p, err := promptPassphrase("Passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", usage(fmt.Errorf("A passphrase is required"))
+	}
+	if confirm {
+		again, err := promptPassphrase("Confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != p {
+			return "", usage(fmt.Errorf("Passphrases don't match"))
+		}
+	}
+	return p, nil
+}
+
+func promptPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	restore, err := disableEcho(os.Stdin.Fd())
+	if err == nil {
+		defer func() {
+			restore()
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", usage(fmt.Errorf("Couldn't read a passphrase; set %s instead", exporter.PASSPHRASE_ENV_VAR))
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
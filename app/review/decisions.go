@@ -0,0 +1,133 @@
+// Package review steps through converted entries before they're written, so
+// that entries can be excluded, retitled, or starred. Decisions are saved to a
+// file so that rerunning a review reapplies them.
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"exporter/exporter"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Decision is what was decided about a single entry during a review.
+type Decision struct {
+	Excluded bool `json:"excluded,omitempty"`
+	// Title replaces the entry's title when it's set.
+	Title   *string `json:"title,omitempty"`
+	Starred bool    `json:"starred,omitempty"`
+}
+
+// Decisions are every decision made during a review, by entry key. See
+// EntryKeys.
+type Decisions struct {
+	Entries map[string]Decision `json:"entries"`
+}
+
+// NewDecisions creates decisions for a review that hasn't started yet.
+func NewDecisions() *Decisions {
+	return &Decisions{Entries: map[string]Decision{}}
+}
+
+// ReadDecisionsFile reads decisions saved by an earlier review. A missing
+// file is treated like a review that hasn't started yet.
+func ReadDecisionsFile(path string) (*Decisions, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewDecisions(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d := NewDecisions()
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("Not a valid review decisions file: %s: %w", path, err)
+	}
+	if d.Entries == nil {
+		d.Entries = map[string]Decision{}
+	}
+	return d, nil
+}
+
+// WriteFile saves decisions, replacing any that were saved earlier.
+func (d *Decisions) WriteFile(path string) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Renaming means an interrupted save never leaves a half-written file.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// set records a decision, forgetting decisions that don't change anything.
+func (d *Decisions) set(key string, decision Decision) {
+	if decision == (Decision{}) {
+		delete(d.Entries, key)
+		return
+	}
+	d.Entries[key] = decision
+}
+
+// EntryKeys identifies entries across reruns by when they were created, i.e.
+// "2023-12-17T08:00:00Z". Entries created at the same time are told apart by
+// the order they're in: "2023-12-17T08:00:00Z#2".
+func EntryKeys(entries []exporter.ConvertedEntry) []string {
+	keys := []string{}
+	seen := map[string]int{}
+	for _, e := range entries {
+		key := timeOf(&e).Format(time.RFC3339)
+		if e.Synthetic {
+			key = "generated:" + key
+		}
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key = fmt.Sprintf("%s#%d", key, n)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func timeOf(e *exporter.ConvertedEntry) time.Time {
+	return time.Time(e.DayOne.CreationDate).UTC()
+}
+
+// Apply returns the entries that weren't excluded, retitled and starred as
+// decided. entries are left alone.
+func Apply(entries []exporter.ConvertedEntry, d *Decisions) ([]exporter.ConvertedEntry, error) {
+	out := []exporter.ConvertedEntry{}
+	for idx, key := range EntryKeys(entries) {
+		decision := d.Entries[key]
+		if decision.Excluded {
+			continue
+		}
+		e, err := applyDecision(entries[idx], decision)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func applyDecision(e exporter.ConvertedEntry, decision Decision) (exporter.ConvertedEntry, error) {
+	if decision.Title != nil {
+		if err := exporter.RetitleEntry(&e, *decision.Title); err != nil {
+			return e, err
+		}
+	}
+	if decision.Starred {
+		e.DayOne.Starred = true
+	}
+	return e, nil
+}
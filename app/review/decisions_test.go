@@ -0,0 +1,68 @@
+package review
+
+import (
+	"context"
+	"exporter/daylio"
+	"exporter/exporter"
+	"exporter/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustConvertEntries(t *testing.T) []exporter.ConvertedEntry {
+	t.Helper()
+	entries, _, err := exporter.ConvertSources(context.Background(), []daylio.Source{{
+		Path: "daylio.csv",
+		Entries: []daylio.Entry{
+			{FullDate: "2023-12-17", Time: "08:00", Mood: "good", Activities: "home | reading", NoteTitle: "Title", Note: "note text 1"},
+			{FullDate: "2023-12-17", Time: "08:00", Mood: "bad", Note: "note text 2"},
+			{FullDate: "2023-12-16", Time: "09:30", Mood: "rad", Activities: "friends", Note: "note text 3"},
+		},
+	}}, exporter.ConvertOptions{}, types.DefaultDayOneGenerators())
+	require.NoError(t, err)
+	return entries
+}
+
+func TestEntryKeys(t *testing.T) {
+	got := EntryKeys(mustConvertEntries(t))
+	assert.Equal(t, []string{"2023-12-17T08:00:00Z", "2023-12-17T08:00:00Z#2", "2023-12-16T09:30:00Z"}, got)
+}
+
+func TestApplyingDecisions(t *testing.T) {
+	entries := mustConvertEntries(t)
+	title := "New title"
+	d := NewDecisions()
+	d.Entries["2023-12-17T08:00:00Z"] = Decision{Title: &title, Starred: true}
+	d.Entries["2023-12-17T08:00:00Z#2"] = Decision{Excluded: true}
+	got, err := Apply(entries, d)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "New title\n\nnote text 1", got[0].DayOne.Text)
+	assert.True(t, got[0].DayOne.Starred)
+	assert.Equal(t, "note text 3", got[1].Source.Note)
+	assert.False(t, got[1].DayOne.Starred)
+	assert.Equal(t, "Title\n\nnote text 1", entries[0].DayOne.Text, "entries are left alone")
+}
+
+func TestSavingDecisions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "review", "decisions.json")
+	d, err := ReadDecisionsFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, d.Entries)
+
+	title := "New title"
+	d.Entries["2023-12-17T08:00:00Z"] = Decision{Title: &title}
+	require.NoError(t, d.WriteFile(path))
+	require.NoError(t, d.WriteFile(path), "decisions are replaced")
+	got, err := ReadDecisionsFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, d, got)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+	_, err = ReadDecisionsFile(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,290 @@
+package review
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"exporter/exporter"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PAGE_SIZE is how many entries are listed at once.
+const PAGE_SIZE = 20
+
+const HELP = `Commands:
+  n, p              Show the next or previous page of entries.
+  g DATE            Go to the first entry on or after DATE (YYYY-MM-DD).
+  o N               Open entry N to see its text and rich text.
+  x N [M...]        Exclude entries from the export. Ranges like 3-7 work too.
+  i N [M...]        Include excluded entries again.
+  t N TITLE         Change the title of entry N. Leave TITLE out to undo.
+  s N               Star or unstar entry N.
+  w                 Write the export and quit.
+  q                 Quit without writing anything. Decisions are kept.
+  h                 Show these commands.
+`
+
+// Session is an interactive review of converted entries.
+type Session struct {
+	entries   []exporter.ConvertedEntry
+	keys      []string
+	decisions *Decisions
+	// save is called after every change, so that decisions survive quitting
+	// at any point.
+	save func(d *Decisions) error
+	in   *bufio.Scanner
+	out  io.Writer
+	page int
+}
+
+// NewSession reviews entries, reading commands from in and writing to out.
+func NewSession(entries []exporter.ConvertedEntry, decisions *Decisions, save func(d *Decisions) error, in io.Reader, out io.Writer) *Session {
+	return &Session{
+		entries:   entries,
+		keys:      EntryKeys(entries),
+		decisions: decisions,
+		save:      save,
+		in:        bufio.NewScanner(in),
+		out:       out,
+	}
+}
+
+// Run lists entries and follows commands until the export should be written,
+// when it returns true, or the review is quit.
+func (s *Session) Run() (bool, error) {
+	s.list()
+	for {
+		fmt.Fprint(s.out, "> ")
+		if !s.in.Scan() {
+			fmt.Fprintln(s.out)
+			return false, s.in.Err()
+		}
+		fields := strings.Fields(s.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		write, quit, err := s.run(fields[0], fields[1:], s.in.Text())
+		if err != nil {
+			fmt.Fprintf(s.out, "%s\n", err)
+			continue
+		}
+		if quit {
+			return write, nil
+		}
+	}
+}
+
+func (s *Session) run(command string, args []string, line string) (write bool, quit bool, err error) {
+	switch command {
+	case "n":
+		if (s.page+1)*PAGE_SIZE < len(s.entries) {
+			s.page++
+		}
+		s.list()
+	case "p":
+		if s.page > 0 {
+			s.page--
+		}
+		s.list()
+	case "g":
+		if len(args) != 1 {
+			return false, false, errors.New("Usage: g DATE")
+		}
+		s.goTo(args[0])
+	case "o":
+		idx, err := s.entryArg(args)
+		if err != nil {
+			return false, false, err
+		}
+		return false, false, s.open(idx)
+	case "x", "i":
+		return false, false, s.setExcluded(args, command == "x")
+	case "t":
+		idx, err := s.entryArg(args)
+		if err != nil {
+			return false, false, err
+		}
+		return false, false, s.retitle(idx, titleArg(line))
+	case "s":
+		idx, err := s.entryArg(args)
+		if err != nil {
+			return false, false, err
+		}
+		return false, false, s.update(idx, func(d *Decision) { d.Starred = !d.Starred })
+	case "w":
+		return true, true, nil
+	case "q":
+		return false, true, nil
+	case "h", "?":
+		fmt.Fprint(s.out, HELP)
+	default:
+		return false, false, fmt.Errorf("Not a command: %s; type h for help", command)
+	}
+	return false, false, nil
+}
+
+// list shows the current page of entries. Excluded entries are marked with
+// "x" and starred entries with "*".
+func (s *Session) list() {
+	excluded := 0
+	for _, key := range s.keys {
+		if s.decisions.Entries[key].Excluded {
+			excluded++
+		}
+	}
+	start := s.page * PAGE_SIZE
+	end := min(start+PAGE_SIZE, len(s.entries))
+	fmt.Fprintf(s.out, "Entries %d-%d of %d (%d excluded)\n", min(start+1, end), end, len(s.entries), excluded)
+	for idx := start; idx < end; idx++ {
+		e, d := &s.entries[idx], s.decisions.Entries[s.keys[idx]]
+		marks := ""
+		if d.Excluded {
+			marks += "x"
+		}
+		if d.Starred {
+			marks += "*"
+		}
+		fmt.Fprintf(s.out, "%4d %-2s %s  %-6s %s\n", idx+1, marks, entryDate(e), entryMood(e), strings.Join(e.DayOne.Tags, ", "))
+	}
+	fmt.Fprintln(s.out, "Type h for help.")
+}
+
+func (s *Session) goTo(date string) {
+	for idx := range s.entries {
+		// Entries are newest first.
+		if entryDate(&s.entries[idx]) < date {
+			s.page = max(idx-1, 0) / PAGE_SIZE
+			s.list()
+			return
+		}
+	}
+	s.page = max(len(s.entries)-1, 0) / PAGE_SIZE
+	s.list()
+}
+
+// open shows an entry as it will be written, with decisions applied.
+func (s *Session) open(idx int) error {
+	e, err := applyDecision(s.entries[idx], s.decisions.Entries[s.keys[idx]])
+	if err != nil {
+		return err
+	}
+	d := s.decisions.Entries[s.keys[idx]]
+	fmt.Fprintf(s.out, "Entry %d: %s\n", idx+1, entryDate(&e))
+	fmt.Fprintf(s.out, "Mood: %s\nTags: %s\nStarred: %t\nExcluded: %t\n", entryMood(&e), strings.Join(e.DayOne.Tags, ", "), e.DayOne.Starred, d.Excluded)
+	fmt.Fprintf(s.out, "\nText:\n%s\n", e.DayOne.Text)
+	var richText bytes.Buffer
+	if err := json.Indent(&richText, []byte(e.DayOne.RichText), "", "  "); err != nil {
+		richText.WriteString(e.DayOne.RichText)
+	}
+	fmt.Fprintf(s.out, "\nRich text:\n%s\n", richText.String())
+	return nil
+}
+
+func (s *Session) setExcluded(args []string, excluded bool) error {
+	if len(args) == 0 {
+		return errors.New("Provide at least one entry number")
+	}
+	indexes := []int{}
+	for _, arg := range args {
+		from, to, err := s.entryRange(arg)
+		if err != nil {
+			return err
+		}
+		for idx := from; idx <= to; idx++ {
+			indexes = append(indexes, idx)
+		}
+	}
+	for _, idx := range indexes {
+		d := s.decisions.Entries[s.keys[idx]]
+		d.Excluded = excluded
+		s.decisions.set(s.keys[idx], d)
+	}
+	if err := s.save(s.decisions); err != nil {
+		return err
+	}
+	s.list()
+	return nil
+}
+
+func (s *Session) retitle(idx int, title *string) error {
+	if s.entries[idx].Synthetic {
+		return errors.New("Only entries from Daylio can be retitled")
+	}
+	return s.update(idx, func(d *Decision) { d.Title = title })
+}
+
+func (s *Session) update(idx int, change func(d *Decision)) error {
+	d := s.decisions.Entries[s.keys[idx]]
+	change(&d)
+	s.decisions.set(s.keys[idx], d)
+	if err := s.save(s.decisions); err != nil {
+		return err
+	}
+	return s.open(idx)
+}
+
+func (s *Session) entryArg(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, errors.New("Provide an entry number")
+	}
+	return s.entryNumber(args[0])
+}
+
+func (s *Session) entryNumber(arg string) (int, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(s.entries) {
+		return 0, fmt.Errorf("Not an entry number: %s", arg)
+	}
+	return n - 1, nil
+}
+
+func (s *Session) entryRange(arg string) (int, int, error) {
+	first, last, ok := strings.Cut(arg, "-")
+	if !ok {
+		last = first
+	}
+	from, err := s.entryNumber(first)
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := s.entryNumber(last)
+	if err != nil {
+		return 0, 0, err
+	}
+	if to < from {
+		from, to = to, from
+	}
+	return from, to, nil
+}
+
+// titleArg is everything after the entry number of a "t" command, keeping
+// its spaces. It's nil when there's nothing, which undoes retitling.
+func titleArg(line string) *string {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return nil
+	}
+	rest := strings.TrimSpace(line)
+	for _, f := range fields[:2] {
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, f))
+	}
+	return &rest
+}
+
+func entryDate(e *exporter.ConvertedEntry) string {
+	if e.Synthetic {
+		return fmt.Sprintf("%s (generated)", timeOf(e).Format("2006-01-02"))
+	}
+	return fmt.Sprintf("%s %s", e.Source.FullDate, e.Source.Time)
+}
+
+func entryMood(e *exporter.ConvertedEntry) string {
+	if e.Synthetic {
+		return "-"
+	}
+	return e.Source.Mood
+}
@@ -0,0 +1,67 @@
+package review
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runSession(t *testing.T, d *Decisions, commands ...string) (bool, string, int) {
+	t.Helper()
+	saves := 0
+	var out bytes.Buffer
+	s := NewSession(mustConvertEntries(t), d, func(*Decisions) error {
+		saves++
+		return nil
+	}, strings.NewReader(strings.Join(commands, "\n")+"\n"), &out)
+	write, err := s.Run()
+	require.NoError(t, err)
+	return write, out.String(), saves
+}
+
+func TestReviewingEntries(t *testing.T) {
+	d := NewDecisions()
+	write, out, saves := runSession(t, d, "x 2-3", "i 3", "t 1 A  better title", "s 1", "o 1", "w")
+	assert.True(t, write)
+	assert.Equal(t, 4, saves)
+	assert.Contains(t, out, "   1    2023-12-17 08:00  good   home, reading")
+	assert.Contains(t, out, "Entries 1-3 of 3 (1 excluded)")
+	assert.Contains(t, out, "Text:\nA  better title\n\nnote text 1")
+	assert.Contains(t, out, `"text": "A  better title\n\nnote text 1"`)
+	assert.Contains(t, out, "Starred: true")
+
+	require.NotNil(t, d.Entries["2023-12-17T08:00:00Z"].Title)
+	assert.Equal(t, "A  better title", *d.Entries["2023-12-17T08:00:00Z"].Title)
+	assert.True(t, d.Entries["2023-12-17T08:00:00Z#2"].Excluded)
+	assert.NotContains(t, d.Entries, "2023-12-16T09:30:00Z", "including an entry again forgets it")
+}
+
+func TestUndoingRetitling(t *testing.T) {
+	d := NewDecisions()
+	_, _, _ = runSession(t, d, "t 1 Title", "t 1", "q")
+	assert.Empty(t, d.Entries)
+}
+
+func TestQuittingWithoutWriting(t *testing.T) {
+	write, _, _ := runSession(t, NewDecisions(), "q")
+	assert.False(t, write)
+
+	write, _, _ = runSession(t, NewDecisions())
+	assert.False(t, write, "the end of input quits")
+}
+
+func TestReviewErrors(t *testing.T) {
+	_, out, saves := runSession(t, NewDecisions(), "x 9", "o", "dance", "q")
+	assert.Contains(t, out, "Not an entry number: 9")
+	assert.Contains(t, out, "Provide an entry number")
+	assert.Contains(t, out, "Not a command: dance; type h for help")
+	assert.Zero(t, saves)
+}
+
+func TestGoingToADate(t *testing.T) {
+	_, out, _ := runSession(t, NewDecisions(), "g 2023-12-16", "q")
+	assert.Equal(t, 2, strings.Count(out, "Entries 1-3 of 3"))
+}
@@ -0,0 +1,119 @@
+package stats
+
+import (
+	encCSV "encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format is a way of printing a report.
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+	FormatCSV   Format = "csv"
+)
+
+// ParseFormat turns a format name into a Format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatTable, FormatJSON, FormatCSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("Not a valid output format: %s", s)
+	}
+}
+
+// Write prints a report in the given format.
+func Write(w io.Writer, r *Report, format Format) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, r)
+	case FormatCSV:
+		return writeCSV(w, r)
+	case FormatTable, "":
+		return writeTable(w, r)
+	default:
+		return fmt.Errorf("Not a valid output format: %s", format)
+	}
+}
+
+func writeJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// writeCSV writes every section of the report into a single table with a
+// "section" column, which keeps it easy to filter in a spreadsheet.
+func writeCSV(w io.Writer, r *Report) error {
+	cw := encCSV.NewWriter(w)
+	rows := [][]string{
+		{"section", "name", "entries", "average_mood", "average_mood_without", "mood_delta"},
+		{"overall", "all", strconv.Itoa(r.Entries), formatMood(r.AverageMood), "", ""},
+		{"streak", r.LongestStreak.Start + "/" + r.LongestStreak.End, strconv.Itoa(r.LongestStreak.Days), "", "", ""},
+	}
+	for _, section := range []struct {
+		name    string
+		periods []PeriodMood
+	}{
+		{"year", r.Yearly},
+		{"month", r.Monthly},
+		{"week", r.Weekly},
+		{"weekday", r.Weekdays},
+	} {
+		for _, p := range section.periods {
+			rows = append(rows, []string{section.name, p.Period, strconv.Itoa(p.Entries), formatMood(p.AverageMood), "", ""})
+		}
+	}
+	for _, a := range r.Activities {
+		rows = append(rows, []string{
+			"activity",
+			a.Name,
+			strconv.Itoa(a.Entries),
+			formatMood(a.AverageMoodWith),
+			formatMood(a.AverageMoodWithout),
+			formatMood(a.MoodDelta),
+		})
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func writeTable(w io.Writer, r *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Entries:\t%d (%d days)\n", r.Entries, r.Days)
+	fmt.Fprintf(tw, "Average mood:\t%s\n", formatMood(r.AverageMood))
+	fmt.Fprintf(tw, "Longest streak:\t%d days (%s to %s)\n", r.LongestStreak.Days, r.LongestStreak.Start, r.LongestStreak.End)
+	for _, section := range []struct {
+		title   string
+		periods []PeriodMood
+	}{
+		{"YEAR", r.Yearly},
+		{"MONTH", r.Monthly},
+		{"WEEK", r.Weekly},
+		{"WEEKDAY", r.Weekdays},
+	} {
+		fmt.Fprintf(tw, "\n%s\tENTRIES\tAVERAGE MOOD\n", section.title)
+		for _, p := range section.periods {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", p.Period, p.Entries, formatMood(p.AverageMood))
+		}
+	}
+	fmt.Fprint(tw, "\nACTIVITY\tENTRIES\tMOOD WITH\tMOOD WITHOUT\tDELTA\n")
+	for _, a := range r.Activities {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%+.2f\n", a.Name, a.Entries,
+			formatMood(a.AverageMoodWith), formatMood(a.AverageMoodWithout), a.MoodDelta)
+	}
+	return tw.Flush()
+}
+
+func formatMood(m float64) string {
+	return strconv.FormatFloat(m, 'f', 2, 64)
+}
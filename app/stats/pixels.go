@@ -0,0 +1,183 @@
+package stats
+
+import (
+	"exporter/daylio"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// PIXEL_SIZE and PIXEL_GAP are in pixels.
+	PIXEL_SIZE = 16
+	PIXEL_GAP  = 2
+	// PIXEL_LABEL_WIDTH is the space left for month names in SVGs.
+	PIXEL_LABEL_WIDTH = 32
+)
+
+// Palette colours days by their average mood, rounded to the nearest mood.
+type Palette struct {
+	Rad   color.RGBA
+	Good  color.RGBA
+	Meh   color.RGBA
+	Bad   color.RGBA
+	Awful color.RGBA
+	// Empty is used for days without entries.
+	Empty color.RGBA
+}
+
+// DefaultPalette is close to the colours Daylio uses.
+var DefaultPalette = Palette{
+	Rad:   color.RGBA{0x1a, 0xbc, 0x9c, 0xff},
+	Good:  color.RGBA{0x9c, 0xcc, 0x65, 0xff},
+	Meh:   color.RGBA{0x42, 0xa5, 0xf5, 0xff},
+	Bad:   color.RGBA{0xff, 0xa7, 0x26, 0xff},
+	Awful: color.RGBA{0xef, 0x53, 0x50, 0xff},
+	Empty: color.RGBA{0xee, 0xee, 0xee, 0xff},
+}
+
+// ParsePalette reads a palette from hex colours separated by commas, from rad
+// to awful, with an optional colour for empty days at the end, i.e.
+// "#1abc9c,#9ccc65,#42a5f5,#ffa726,#ef5350,#eeeeee".
+func ParsePalette(s string) (Palette, error) {
+	p := DefaultPalette
+	parts := strings.Split(s, ",")
+	if len(parts) != 5 && len(parts) != 6 {
+		return p, fmt.Errorf("A palette needs 5 or 6 colours, but %d were provided", len(parts))
+	}
+	targets := []*color.RGBA{&p.Rad, &p.Good, &p.Meh, &p.Bad, &p.Awful, &p.Empty}
+	for idx, part := range parts {
+		c, err := parseHexColor(strings.TrimSpace(part))
+		if err != nil {
+			return p, err
+		}
+		*targets[idx] = c
+	}
+	return p, nil
+}
+
+func parseHexColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("Not a valid colour: %s", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("Not a valid colour: %s", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, nil
+}
+
+// Color picks the colour for an average mood between 1 and 5. Anything else is
+// treated as an empty day.
+func (p *Palette) Color(averageMood float64) color.RGBA {
+	switch int(math.Round(averageMood)) {
+	case 5:
+		return p.Rad
+	case 4:
+		return p.Good
+	case 3:
+		return p.Meh
+	case 2:
+		return p.Bad
+	case 1:
+		return p.Awful
+	default:
+		return p.Empty
+	}
+}
+
+// YearInPixels is the average mood of every day in a year that has entries.
+type YearInPixels struct {
+	Year int
+	// Days maps dates (YYYY-MM-DD) to average moods.
+	Days map[string]float64
+}
+
+// ComputeYearInPixels averages the mood of every day within a year. Entries
+// are on the day they were written on where they were written, like in
+// Compute.
+func ComputeYearInPixels(entries []daylio.Entry, year int) *YearInPixels {
+	prefix := fmt.Sprintf("%04d-", year)
+	totals := map[string]*moodTotal{}
+	for idx := range entries {
+		e := &entries[idx]
+		date := e.LocalDate()
+		score := daylio.MoodScore(e.Mood)
+		if score == 0 || !strings.HasPrefix(date, prefix) {
+			continue
+		}
+		addTo(totals, date, score)
+	}
+	p := YearInPixels{Year: year, Days: map[string]float64{}}
+	for day, t := range totals {
+		p.Days[day] = t.average()
+	}
+	return &p
+}
+
+// cells calls fn for every day of the year with its month (0-11) and day
+// (0-30), plus an average mood of 0 for days without entries.
+func (p *YearInPixels) cells(fn func(month int, day int, date string, mood float64)) {
+	for t := time.Date(p.Year, time.January, 1, 0, 0, 0, 0, time.UTC); t.Year() == p.Year; t = t.AddDate(0, 0, 1) {
+		date := t.Format("2006-01-02")
+		fn(int(t.Month())-1, t.Day()-1, date, p.Days[date])
+	}
+}
+
+// WritePNG draws one row per month and one cell per day.
+func WritePNG(w io.Writer, p *YearInPixels, palette Palette) error {
+	img := image.NewRGBA(image.Rect(0, 0, pixelGridSize(31), pixelGridSize(12)))
+	p.cells(func(month, day int, date string, mood float64) {
+		c := palette.Color(mood)
+		x0, y0 := pixelOffset(day), pixelOffset(month)
+		for y := y0; y < y0+PIXEL_SIZE; y++ {
+			for x := x0; x < x0+PIXEL_SIZE; x++ {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	})
+	return png.Encode(w, img)
+}
+
+// WriteSVG draws the same grid as WritePNG with month names and a tooltip for
+// every day.
+func WriteSVG(w io.Writer, p *YearInPixels, palette Palette) error {
+	var b strings.Builder
+	width, height := PIXEL_LABEL_WIDTH+pixelGridSize(31), pixelGridSize(12)
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(&b, "<title>Year in Pixels %d</title>\n", p.Year)
+	for m := 0; m < 12; m++ {
+		fmt.Fprintf(&b, `<text x="0" y="%d" font-family="sans-serif" font-size="11">%s</text>`+"\n",
+			pixelOffset(m)+PIXEL_SIZE-4, time.Month(m + 1).String()[:3])
+	}
+	p.cells(func(month, day int, date string, mood float64) {
+		c := palette.Color(mood)
+		label := date
+		if mood > 0 {
+			label = fmt.Sprintf("%s: %.2f", date, mood)
+		}
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="#%02x%02x%02x"><title>%s</title></rect>`+"\n",
+			PIXEL_LABEL_WIDTH+pixelOffset(day), pixelOffset(month), PIXEL_SIZE, PIXEL_SIZE,
+			c.R, c.G, c.B, html.EscapeString(label))
+	})
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func pixelOffset(idx int) int {
+	return PIXEL_GAP + idx*(PIXEL_SIZE+PIXEL_GAP)
+}
+
+func pixelGridSize(cells int) int {
+	return pixelOffset(cells)
+}
@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"bytes"
+	"exporter/daylio"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputingYearInPixels(t *testing.T) {
+	got := ComputeYearInPixels(mockEntries(), 2023)
+	assert.Equal(t, &YearInPixels{
+		Year: 2023,
+		Days: map[string]float64{
+			"2023-12-31": 2.5,
+			"2023-12-20": 1,
+		},
+	}, got)
+}
+
+func TestParsingPalette(t *testing.T) {
+	got, err := ParsePalette("#000000,#111111,#222222,#333333,#444444")
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{0x44, 0x44, 0x44, 0xff}, got.Awful)
+	assert.Equal(t, DefaultPalette.Empty, got.Empty)
+	got, err = ParsePalette("000000,111111,222222,333333,444444,ffffff")
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{0xff, 0xff, 0xff, 0xff}, got.Empty)
+	_, err = ParsePalette("#000000")
+	assert.Error(t, err)
+	_, err = ParsePalette("#000000,#111111,#222222,#333333,purple")
+	assert.EqualError(t, err, "Not a valid colour: purple")
+}
+
+func TestPaletteRoundsAverageMoods(t *testing.T) {
+	assert.Equal(t, DefaultPalette.Bad, DefaultPalette.Color(2.49))
+	assert.Equal(t, DefaultPalette.Meh, DefaultPalette.Color(2.5))
+	assert.Equal(t, DefaultPalette.Empty, DefaultPalette.Color(0))
+}
+
+func TestWritingYearInPixelsPNG(t *testing.T) {
+	p := ComputeYearInPixels(mockEntries(), 2023)
+	var buf bytes.Buffer
+	require.NoError(t, WritePNG(&buf, p, DefaultPalette))
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, pixelGridSize(31), img.Bounds().Dx())
+	assert.Equal(t, pixelGridSize(12), img.Bounds().Dy())
+	// December 20th is awful; January 1st has no entries; the gap is blank.
+	rgba := func(x, y int) color.RGBA {
+		return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+	}
+	assert.Equal(t, DefaultPalette.Awful, rgba(pixelOffset(19), pixelOffset(11)))
+	assert.Equal(t, DefaultPalette.Empty, rgba(pixelOffset(0), pixelOffset(0)))
+	assert.Equal(t, color.RGBA{}, rgba(0, 0))
+	// February 30th doesn't exist.
+	assert.Equal(t, color.RGBA{}, rgba(pixelOffset(29), pixelOffset(1)))
+}
+
+func TestWritingYearInPixelsSVG(t *testing.T) {
+	p := ComputeYearInPixels([]daylio.Entry{{FullDate: "2023-02-28", Mood: "rad"}}, 2023)
+	var buf bytes.Buffer
+	require.NoError(t, WriteSVG(&buf, p, DefaultPalette))
+	assert.Contains(t, buf.String(), `<title>Year in Pixels 2023</title>`)
+	assert.Contains(t, buf.String(), `fill="#1abc9c"><title>2023-02-28: 5.00</title></rect>`)
+	assert.NotContains(t, buf.String(), "2023-02-29")
+	assert.Equal(t, 365, bytes.Count(buf.Bytes(), []byte("<rect")))
+}
+
+func TestComputingYearInPixelsOnTheLocalDay(t *testing.T) {
+	// 20:00 on Dec 31, 2023 in Chicago is already 2024 in UTC.
+	entries := []daylio.Entry{{FullDate: "2024-01-01", Time: "02:00", Mood: "rad", TimeZoneOffset: -21600000}}
+	assert.Equal(t, map[string]float64{"2023-12-31": 5}, ComputeYearInPixels(entries, 2023).Days)
+	assert.Empty(t, ComputeYearInPixels(entries, 2024).Days)
+}
@@ -0,0 +1,203 @@
+package stats
+
+import (
+	"exporter/daylio"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Report is a summary of moods and activities across Daylio entries. Moods are
+// scored from 1 (awful) to 5 (rad).
+type Report struct {
+	Entries       int             `json:"entries"`
+	Days          int             `json:"days"`
+	AverageMood   float64         `json:"average_mood"`
+	Weekly        []PeriodMood    `json:"weekly"`
+	Monthly       []PeriodMood    `json:"monthly"`
+	Yearly        []PeriodMood    `json:"yearly"`
+	Weekdays      []PeriodMood    `json:"weekdays"`
+	Activities    []ActivityStats `json:"activities"`
+	LongestStreak Streak          `json:"longest_streak"`
+}
+
+// PeriodMood is the average mood within a period, like "2023-W50", "2023-12",
+// "2023", or "Monday".
+type PeriodMood struct {
+	Period      string  `json:"period"`
+	Entries     int     `json:"entries"`
+	AverageMood float64 `json:"average_mood"`
+}
+
+// ActivityStats describes how often an activity was logged and how mood
+// differs with and without it.
+type ActivityStats struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+	// AverageMoodWith and AverageMoodWithout are the average moods of entries
+	// with and without this activity.
+	AverageMoodWith    float64 `json:"average_mood_with"`
+	AverageMoodWithout float64 `json:"average_mood_without"`
+	// MoodDelta is AverageMoodWith minus AverageMoodWithout.
+	MoodDelta float64 `json:"mood_delta"`
+}
+
+// Streak is a run of consecutive days with at least one entry.
+type Streak struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Days  int    `json:"days"`
+}
+
+type moodTotal struct {
+	sum   int
+	count int
+}
+
+func (m *moodTotal) add(score int) {
+	m.sum += score
+	m.count++
+}
+
+func (m *moodTotal) average() float64 {
+	if m.count == 0 {
+		return 0
+	}
+	return float64(m.sum) / float64(m.count)
+}
+
+// Compute builds a report from Daylio entries. Entries with moods that can't
+// be scored are counted but left out of averages. Entries count towards the
+// day they were written on where they were written, even when that's a
+// different day in UTC.
+func Compute(entries []daylio.Entry) (*Report, error) {
+	r := Report{Entries: len(entries)}
+	var overall moodTotal
+	weeks, months, years, weekdays := map[string]*moodTotal{}, map[string]*moodTotal{}, map[string]*moodTotal{}, map[string]*moodTotal{}
+	activities := map[string]*moodTotal{}
+	days := map[string]bool{}
+	for idx := range entries {
+		e := &entries[idx]
+		date := e.LocalDate()
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", idx, err)
+		}
+		days[date] = true
+		score := daylio.MoodScore(e.Mood)
+		if score == 0 {
+			continue
+		}
+		overall.add(score)
+		year, week := t.ISOWeek()
+		addTo(weeks, fmt.Sprintf("%d-W%02d", year, week), score)
+		addTo(months, t.Format("2006-01"), score)
+		addTo(years, t.Format("2006"), score)
+		addTo(weekdays, t.Weekday().String(), score)
+		for _, a := range uniqueNames(e.ActivityNames()) {
+			addTo(activities, a, score)
+		}
+	}
+	r.Days = len(days)
+	r.AverageMood = overall.average()
+	r.Weekly = sortedPeriods(weeks)
+	r.Monthly = sortedPeriods(months)
+	r.Yearly = sortedPeriods(years)
+	r.Weekdays = weekdayPeriods(weekdays)
+	r.Activities = activityStats(activities, &overall)
+	r.LongestStreak = longestStreak(days)
+	return &r, nil
+}
+
+func addTo(totals map[string]*moodTotal, key string, score int) {
+	if _, ok := totals[key]; !ok {
+		totals[key] = &moodTotal{}
+	}
+	totals[key].add(score)
+}
+
+func uniqueNames(names []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, n := range names {
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func sortedPeriods(totals map[string]*moodTotal) []PeriodMood {
+	out := []PeriodMood{}
+	for period, t := range totals {
+		out = append(out, PeriodMood{Period: period, Entries: t.count, AverageMood: t.average()})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Period < out[j].Period
+	})
+	return out
+}
+
+func weekdayPeriods(totals map[string]*moodTotal) []PeriodMood {
+	out := []PeriodMood{}
+	for d := time.Monday; d < time.Monday+7; d++ {
+		day := (d % 7).String()
+		t, ok := totals[day]
+		if !ok {
+			t = &moodTotal{}
+		}
+		out = append(out, PeriodMood{Period: day, Entries: t.count, AverageMood: t.average()})
+	}
+	return out
+}
+
+// activityStats works out the average mood without an activity from the
+// overall total, since every scored entry either has the activity or doesn't.
+func activityStats(activities map[string]*moodTotal, overall *moodTotal) []ActivityStats {
+	out := []ActivityStats{}
+	for name, with := range activities {
+		without := moodTotal{sum: overall.sum - with.sum, count: overall.count - with.count}
+		s := ActivityStats{
+			Name:               name,
+			Entries:            with.count,
+			AverageMoodWith:    with.average(),
+			AverageMoodWithout: without.average(),
+		}
+		if without.count > 0 {
+			s.MoodDelta = s.AverageMoodWith - s.AverageMoodWithout
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Entries != out[j].Entries {
+			return out[i].Entries > out[j].Entries
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func longestStreak(days map[string]bool) Streak {
+	sorted := []string{}
+	for d := range days {
+		sorted = append(sorted, d)
+	}
+	sort.Strings(sorted)
+	var best, current Streak
+	var prev time.Time
+	for _, d := range sorted {
+		t, _ := time.Parse("2006-01-02", d)
+		if current.Days > 0 && t.Equal(prev.AddDate(0, 0, 1)) {
+			current.End = d
+			current.Days++
+		} else {
+			current = Streak{Start: d, End: d, Days: 1}
+		}
+		if current.Days > best.Days {
+			best = current
+		}
+		prev = t
+	}
+	return best
+}
@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"exporter/daylio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockEntries() []daylio.Entry {
+	return []daylio.Entry{
+		{FullDate: "2024-01-02", Mood: "rad", Activities: "gym | friends"},
+		{FullDate: "2024-01-01", Mood: "good", Activities: "gym"},
+		{FullDate: "2023-12-31", Mood: "bad", ActivitiesList: []string{"work", "mood: bad"}},
+		{FullDate: "2023-12-31", Mood: "meh", Activities: "work | work"},
+		{FullDate: "2023-12-20", Mood: "awful", Activities: ""},
+	}
+}
+
+func TestComputingStats(t *testing.T) {
+	got, err := Compute(mockEntries())
+	require.NoError(t, err)
+	assert.Equal(t, 5, got.Entries)
+	assert.Equal(t, 4, got.Days)
+	assert.InDelta(t, 3.0, got.AverageMood, 0.001)
+	assert.Equal(t, []PeriodMood{
+		{Period: "2023", Entries: 3, AverageMood: 2},
+		{Period: "2024", Entries: 2, AverageMood: 4.5},
+	}, got.Yearly)
+	assert.Equal(t, []PeriodMood{
+		{Period: "2023-12", Entries: 3, AverageMood: 2},
+		{Period: "2024-01", Entries: 2, AverageMood: 4.5},
+	}, got.Monthly)
+	// Dec 31, 2023 is a Sunday, so it's in the same ISO week as Dec 25.
+	assert.Equal(t, []PeriodMood{
+		{Period: "2023-W51", Entries: 1, AverageMood: 1},
+		{Period: "2023-W52", Entries: 2, AverageMood: 2.5},
+		{Period: "2024-W01", Entries: 2, AverageMood: 4.5},
+	}, got.Weekly)
+	assert.Equal(t, Streak{Start: "2023-12-31", End: "2024-01-02", Days: 3}, got.LongestStreak)
+	assert.Equal(t, "Monday", got.Weekdays[0].Period)
+	assert.Equal(t, PeriodMood{Period: "Sunday", Entries: 2, AverageMood: 2.5}, got.Weekdays[6])
+	require.Len(t, got.Activities, 3)
+	assert.Equal(t, "gym", got.Activities[0].Name)
+	assert.Equal(t, 2, got.Activities[0].Entries)
+	assert.InDelta(t, 4.5, got.Activities[0].AverageMoodWith, 0.001)
+	assert.InDelta(t, 2.0, got.Activities[0].AverageMoodWithout, 0.001)
+	assert.InDelta(t, 2.5, got.Activities[0].MoodDelta, 0.001)
+	assert.Equal(t, "work", got.Activities[1].Name)
+	assert.Equal(t, 2, got.Activities[1].Entries)
+}
+
+func TestComputingStatsWithBadDate(t *testing.T) {
+	_, err := Compute([]daylio.Entry{{FullDate: "yesterday"}})
+	assert.ErrorContains(t, err, "entry 0")
+}
+
+func TestWritingStats(t *testing.T) {
+	r, err := Compute(mockEntries())
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, r, FormatJSON))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, *r, decoded)
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, r, FormatCSV))
+	assert.True(t, strings.HasPrefix(buf.String(), "section,name,entries,average_mood,average_mood_without,mood_delta\n"))
+	assert.Contains(t, buf.String(), "activity,gym,2,4.50,2.00,2.50\n")
+	assert.Contains(t, buf.String(), "streak,2023-12-31/2024-01-02,3,,,\n")
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, r, FormatTable))
+	assert.Contains(t, buf.String(), "Longest streak:  3 days (2023-12-31 to 2024-01-02)")
+}
+
+func TestComputingStatsOnTheLocalDay(t *testing.T) {
+	// 20:00 on Sunday, Dec 31 in Chicago is 02:00 on Monday, Jan 1 in UTC.
+	got, err := Compute([]daylio.Entry{
+		{FullDate: "2023-12-30", Time: "20:00", Mood: "good", TimeZoneOffset: -21600000},
+		{FullDate: "2024-01-01", Time: "02:00", Mood: "rad", TimeZoneOffset: -21600000},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Streak{Start: "2023-12-30", End: "2023-12-31", Days: 2}, got.LongestStreak)
+	assert.Equal(t, PeriodMood{Period: "Sunday", Entries: 1, AverageMood: 5}, got.Weekdays[6])
+	assert.Equal(t, []PeriodMood{{Period: "2023", Entries: 2, AverageMood: 4.5}}, got.Yearly)
+}
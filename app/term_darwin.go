@@ -0,0 +1,18 @@
+package main
+
+import "golang.org/x/sys/unix"
+
+// disableEcho stops a terminal from showing what's typed into it until
+// restore is called.
+func disableEcho(fd uintptr) (func(), error) {
+	termios, err := unix.IoctlGetTermios(int(fd), unix.TIOCGETA)
+	if err != nil {
+		return nil, err
+	}
+	noEcho := *termios
+	noEcho.Lflag &^= unix.ECHO
+	if err := unix.IoctlSetTermios(int(fd), unix.TIOCSETA, &noEcho); err != nil {
+		return nil, err
+	}
+	return func() { unix.IoctlSetTermios(int(fd), unix.TIOCSETA, termios) }, nil
+}
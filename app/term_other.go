@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import "fmt"
+
+// disableEcho isn't supported here, so passphrases should be provided with
+// EXPORT_PASSPHRASE instead.
+func disableEcho(fd uintptr) (func(), error) {
+	return nil, fmt.Errorf("Hiding passphrases isn't supported on this platform")
+}
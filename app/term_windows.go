@@ -0,0 +1,16 @@
+package main
+
+import "golang.org/x/sys/windows"
+
+// disableEcho stops a console from showing what's typed into it until
+// restore is called.
+func disableEcho(fd uintptr) (func(), error) {
+	var mode uint32
+	if err := windows.GetConsoleMode(windows.Handle(fd), &mode); err != nil {
+		return nil, err
+	}
+	if err := windows.SetConsoleMode(windows.Handle(fd), mode&^windows.ENABLE_ECHO_INPUT); err != nil {
+		return nil, err
+	}
+	return func() { windows.SetConsoleMode(windows.Handle(fd), mode) }, nil
+}
@@ -13,6 +13,13 @@ import (
 type DayOneExportResult struct {
 	ZipFile     string
 	JournalName string
+	// Encrypted is true when ZipFile needs to be decrypted before importing.
+	Encrypted bool
+}
+
+// Files lists the ZIP file written by the export.
+func (r *DayOneExportResult) Files() []string {
+	return []string{r.ZipFile}
 }
 
 // DayOneExport represents an export of a Day One journal (with entries) sans
@@ -35,14 +42,27 @@ type DayOneEntry struct {
 	Duration            int                 `json:"duration"`
 	CreationDeviceModel string              `json:"creationDeviceModel"`
 	// UUID is not a real UUID.
-	UUID           string                 `json:"uuid"`
-	IsAllDay       bool                   `json:"isAllDay"`
-	Weather        map[string]interface{} `json:"weather"`
-	ModifiedDate   DayOneDateTime         `json:"modifiedDate"`
-	RichText       string                 `json:"richText"`
-	Text           string                 `json:"text"`
-	IsPinned       bool                   `json:"isPinned"`
-	CreationDevice string                 `json:"creationDevice"`
+	UUID           string             `json:"uuid"`
+	IsAllDay       bool               `json:"isAllDay"`
+	Weather        DayOneWeather      `json:"weather"`
+	ModifiedDate   DayOneDateTime     `json:"modifiedDate"`
+	RichText       string             `json:"richText"`
+	Text           string             `json:"text"`
+	IsPinned       bool               `json:"isPinned"`
+	CreationDevice string             `json:"creationDevice"`
+	Photos         []DayOneEntryPhoto `json:"photos,omitempty"`
+}
+
+// DayOneEntryPhoto is a photo attached to an entry. The photo itself goes in
+// the "photos" folder of the export ZIP, named after its MD5 and type, and is
+// shown wherever "![](dayone-moment://IDENTIFIER)" appears in the entry's text.
+type DayOneEntryPhoto struct {
+	Identifier   string `json:"identifier"`
+	MD5          string `json:"md5"`
+	Type         string `json:"type"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	OrderInEntry int    `json:"orderInEntry"`
 }
 
 type DayOneRichTextObjectData struct {
@@ -62,8 +82,16 @@ type DayOneRichTextObjectCreatedProperties struct {
 }
 
 type DayOneRichTextObject struct {
-	Text       string                         `json:"text"`
-	Attributes DayOneRichTextObjectAttributes `json:"attributes"`
+	Text            string                          `json:"text,omitempty"`
+	Attributes      *DayOneRichTextObjectAttributes `json:"attributes,omitempty"`
+	EmbeddedObjects []DayOneRichTextEmbeddedObject  `json:"embeddedObjects,omitempty"`
+}
+
+// DayOneRichTextEmbeddedObject places an attachment, like a photo, within an
+// entry's rich text.
+type DayOneRichTextEmbeddedObject struct {
+	Type       string `json:"type"`
+	Identifier string `json:"identifier"`
 }
 
 type DayOneRichTextObjectAttributes struct {
@@ -75,6 +103,16 @@ type DayOneRichTextLineObject struct {
 	Identifier uuid.UUID `json:"identifier"`
 }
 
+// DayOneWeather is the weather when an entry was created. Anything unknown is
+// left out, so an empty DayOneWeather is written as "{}".
+type DayOneWeather struct {
+	ConditionsDescription string          `json:"conditionsDescription,omitempty"`
+	WeatherCode           string          `json:"weatherCode,omitempty"`
+	TemperatureCelsius    *float64        `json:"temperatureCelsius,omitempty"`
+	SunriseDate           *DayOneDateTime `json:"sunriseDate,omitempty"`
+	SunsetDate            *DayOneDateTime `json:"sunsetDate,omitempty"`
+}
+
 // DayOneEntryLocation provides location data for a post.
 type DayOneEntryLocation struct {
 	Location           DayOneEntryLocationDetails `json:"location"`
@@ -132,19 +170,18 @@ type DayOneMetadata struct {
 }
 
 // NewEmptyDayOneEntry generates an empty DayOne entry that looks like it was
-// created from a MacBook using the Day One macOS app.
-func NewEmptyDayOneEntry() *DayOneEntry {
+// created on a device. Its time zone is left for the caller to fill in.
+func NewEmptyDayOneEntry(device *DeviceProfile) *DayOneEntry {
 	return &DayOneEntry{
 		Starred:             false,
-		CreationDeviceType:  "Laptop",
-		CreationOSName:      "macOS",
-		CreationOSVersion:   "14.1.2",
-		CreationDeviceModel: "Mac14,2",
-		TimeZone:            thisTZ(),
+		CreationDeviceType:  device.DeviceType,
+		CreationOSName:      device.OSName,
+		CreationOSVersion:   device.OSVersion,
+		CreationDeviceModel: device.DeviceModel,
 		IsAllDay:            false,
-		Weather:             map[string]interface{}{},
+		Weather:             DayOneWeather{},
 		IsPinned:            false,
-		CreationDevice:      "MacBook"}
+		CreationDevice:      device.Device}
 }
 
 // NewDayOneExport generates a v1.0 DayOne export.
@@ -155,7 +192,9 @@ func NewDayOneExport(entries []DayOneEntry) *DayOneExport {
 	}
 }
 
-func thisTZ() string {
+// LocalTimeZone is this computer's time zone: TZ if it's set, or the name of
+// the current zone otherwise.
+func LocalTimeZone() string {
 	if os.Getenv("TZ") != "" {
 		return os.Getenv("TZ")
 	}
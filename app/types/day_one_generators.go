@@ -25,7 +25,8 @@ type DayOneEntryModifiedTimestamper interface {
 }
 
 // DayOneGenerators is used to store references to ID and timestamp generators
-// used to create Day One entries from Daylio entries.
+// used to create Day One entries from Daylio entries. Entries are converted
+// concurrently, so generators must be safe to use from several goroutines.
 type DayOneGenerators struct {
 	UUIDGenerator DayOneEntryUUIDGenerator
 	IDGenerator   DayOneIDGenerator
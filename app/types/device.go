@@ -0,0 +1,74 @@
+package types
+
+// DeviceProfile is the device Day One entries look like they were created
+// on, i.e. an iPhone running the Day One iOS app.
+type DeviceProfile struct {
+	// Name identifies the profile, i.e. "iphone".
+	Name        string `yaml:"name" json:"name"`
+	Device      string `yaml:"device" json:"device"`
+	DeviceType  string `yaml:"deviceType" json:"deviceType"`
+	DeviceModel string `yaml:"deviceModel" json:"deviceModel"`
+	OSName      string `yaml:"osName" json:"osName"`
+	OSVersion   string `yaml:"osVersion" json:"osVersion"`
+	// Platform and PlatformVersion are recorded within the rich text of
+	// entries as the Day One app that created them.
+	Platform        string `yaml:"platform" json:"platform"`
+	PlatformVersion int    `yaml:"platformVersion" json:"platformVersion"`
+}
+
+var (
+	// MacDeviceProfile is a MacBook using the Day One macOS app. It's the
+	// default.
+	MacDeviceProfile = DeviceProfile{
+		Name:            "mac",
+		Device:          "MacBook",
+		DeviceType:      "Laptop",
+		DeviceModel:     "Mac14,2",
+		OSName:          "macOS",
+		OSVersion:       "14.1.2",
+		Platform:        "com.bloombuilt.dayone-mac",
+		PlatformVersion: 1527,
+	}
+	// IPhoneDeviceProfile is an iPhone using the Day One iOS app.
+	IPhoneDeviceProfile = DeviceProfile{
+		Name:            "iphone",
+		Device:          "iPhone",
+		DeviceType:      "iPhone",
+		DeviceModel:     "iPhone15,2",
+		OSName:          "iOS",
+		OSVersion:       "17.2",
+		Platform:        "com.bloombuilt.dayone-ios",
+		PlatformVersion: 1527,
+	}
+	// AndroidDeviceProfile is an Android phone using the Day One Android app.
+	AndroidDeviceProfile = DeviceProfile{
+		Name:            "android",
+		Device:          "Pixel 7",
+		DeviceType:      "Android",
+		DeviceModel:     "Pixel 7",
+		OSName:          "Android",
+		OSVersion:       "14",
+		Platform:        "com.bloombuilt.dayone-android",
+		PlatformVersion: 1527,
+	}
+	// DaylioDeviceProfile marks entries as coming from the Daylio migration
+	// rather than pretending they were written within Day One.
+	DaylioDeviceProfile = DeviceProfile{
+		Name:            "daylio",
+		Device:          "Daylio",
+		DeviceType:      "Daylio Import",
+		DeviceModel:     "daylio-to-day-one",
+		OSName:          "Daylio",
+		OSVersion:       "",
+		Platform:        "net.daylio",
+		PlatformVersion: 1,
+	}
+)
+
+// DeviceProfiles are the built-in device profiles by name.
+var DeviceProfiles = map[string]DeviceProfile{
+	MacDeviceProfile.Name:     MacDeviceProfile,
+	IPhoneDeviceProfile.Name:  IPhoneDeviceProfile,
+	AndroidDeviceProfile.Name: AndroidDeviceProfile,
+	DaylioDeviceProfile.Name:  DaylioDeviceProfile,
+}
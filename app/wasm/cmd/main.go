@@ -0,0 +1,65 @@
+//go:build js && wasm
+
+// Command cmd is the WebAssembly build of the exporter. It adds a
+// daylioToDayOne function to the page:
+//
+//	const { zip, report } = await daylioToDayOne(bytes, "backup.daylio", { journalName: "From Daylio" });
+//
+// bytes is a Uint8Array with a Daylio backup or CSV export, zip is a
+// Uint8Array with the Day One JSON ZIP file, and report is a JSON string.
+// The options are those of wasm.Options.
+package main
+
+import (
+	"context"
+	"errors"
+	"exporter/wasm"
+	"syscall/js"
+)
+
+func main() {
+	js.Global().Set("daylioToDayOne", js.FuncOf(daylioToDayOne))
+	// Keep running so that the page can call daylioToDayOne.
+	select {}
+}
+
+// daylioToDayOne returns a promise, since conversion mustn't block the
+// browser's event loop.
+func daylioToDayOne(this js.Value, args []js.Value) any {
+	return newPromise(func() (any, error) {
+		if len(args) < 2 {
+			return nil, errors.New("daylioToDayOne needs the file's bytes and name")
+		}
+		data := make([]byte, args[0].Get("length").Int())
+		js.CopyBytesToGo(data, args[0])
+		options := ""
+		if len(args) > 2 && args[2].Truthy() {
+			options = js.Global().Get("JSON").Call("stringify", args[2]).String()
+		}
+		zip, report, err := wasm.Convert(context.Background(), args[1].String(), data, options)
+		if err != nil {
+			return nil, err
+		}
+		out := js.Global().Get("Uint8Array").New(len(zip))
+		js.CopyBytesToJS(out, zip)
+		return map[string]any{"zip": out, "report": string(report)}, nil
+	})
+}
+
+func newPromise(run func() (any, error)) js.Value {
+	var handler js.Func
+	handler = js.FuncOf(func(this js.Value, args []js.Value) any {
+		resolve, reject := args[0], args[1]
+		go func() {
+			defer handler.Release()
+			result, err := run()
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New(err.Error()))
+				return
+			}
+			resolve.Invoke(result)
+		}()
+		return nil
+	})
+	return js.Global().Get("Promise").New(handler)
+}
@@ -0,0 +1,112 @@
+// Package wasm converts Daylio backups within a web browser. It's the part of
+// the WebAssembly build that doesn't depend on JavaScript, so that it can be
+// tested like any other package; see wasm/cmd for the rest.
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"exporter/converter"
+	"exporter/daylio"
+	"exporter/exporter"
+	"exporter/types"
+	"fmt"
+	"time"
+)
+
+// Options are the options that web pages convert with, as JSON. Every field
+// is optional.
+type Options struct {
+	JournalName string `json:"journalName"`
+	// TimeZone is recorded on every entry, i.e. "America/Chicago". Defaults
+	// to "UTC".
+	TimeZone string `json:"timeZone"`
+	// From and To only include entries between these dates (YYYY-MM-DD).
+	From              string   `json:"from"`
+	To                string   `json:"to"`
+	Activities        []string `json:"activities"`
+	ExcludeActivities []string `json:"excludeActivities"`
+	AloneTimeScoring  bool     `json:"aloneTimeScoring"`
+	Summaries         bool     `json:"summaries"`
+	// HomeLocation is given to entries with the "home" activity, in the same
+	// format as HOME_ADDRESS_JSON.
+	HomeLocation *types.DayOneEntryLocation `json:"homeLocation"`
+	// Device is the name of a built-in device profile, i.e. "iphone".
+	// Defaults to "mac".
+	Device string `json:"device"`
+}
+
+// Report describes a finished conversion.
+type Report struct {
+	Version     string     `json:"version"`
+	JournalName string     `json:"journal_name"`
+	EntryCount  int        `json:"entry_count"`
+	FirstEntry  *time.Time `json:"first_entry,omitempty"`
+	LastEntry   *time.Time `json:"last_entry,omitempty"`
+}
+
+// Convert converts a Daylio backup or, if its name ends with ".csv", a Daylio
+// CSV export into a Day One JSON ZIP file. It returns the ZIP file along with
+// a JSON report.
+func Convert(ctx context.Context, name string, data []byte, optionsJSON string) ([]byte, []byte, error) {
+	var opts Options
+	if optionsJSON != "" {
+		if err := json.Unmarshal([]byte(optionsJSON), &opts); err != nil {
+			return nil, nil, fmt.Errorf("Not valid options: %w", err)
+		}
+	}
+	filter, err := opts.filter()
+	if err != nil {
+		return nil, nil, err
+	}
+	var device types.DeviceProfile
+	if opts.Device != "" {
+		if device, err = exporter.ParseDeviceProfile(opts.Device); err != nil {
+			return nil, nil, err
+		}
+	}
+	result, err := converter.Convert(ctx, converter.Options{
+		Inputs:           []converter.Input{converter.BytesInput(name, data)},
+		Filter:           filter,
+		TimeZone:         opts.TimeZone,
+		JournalName:      opts.JournalName,
+		HomeLocation:     opts.HomeLocation,
+		Device:           device,
+		AloneTimeScoring: opts.AloneTimeScoring,
+		Summaries:        opts.Summaries,
+		// Browsers run WebAssembly on a single thread.
+		Workers: 1,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var zip bytes.Buffer
+	if err := result.WriteDayOneZip(&zip); err != nil {
+		return nil, nil, err
+	}
+	report, err := json.Marshal(newReport(result))
+	if err != nil {
+		return nil, nil, err
+	}
+	return zip.Bytes(), report, nil
+}
+
+func (o *Options) filter() (daylio.Filter, error) {
+	filter, err := daylio.ParseFilter(o.From, o.To, "", "")
+	filter.Activities, filter.ExcludeActivities = o.Activities, o.ExcludeActivities
+	return filter, err
+}
+
+func newReport(result *converter.Result) *Report {
+	r := Report{
+		Version:     exporter.VERSION,
+		JournalName: result.JournalName,
+		EntryCount:  result.Summary.EntryCount,
+	}
+	if !result.Summary.FirstEntry.IsZero() {
+		first, last := result.Summary.FirstEntry.UTC(), result.Summary.LastEntry.UTC()
+		r.FirstEntry, r.LastEntry = &first, &last
+	}
+	return &r
+}
@@ -0,0 +1,56 @@
+package wasm
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"exporter/daylio"
+	"exporter/exporter"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCSV = `full_date,date,weekday,time,mood,activities,note_title,note
+2023-12-17,Dec 17,Sunday,08:00,good,home | reading,note title,note text 1
+2023-12-16,Dec 16,Saturday,09:30,rad,friends,,note text 2
+`
+
+func TestConverting(t *testing.T) {
+	zipData, reportJSON, err := Convert(context.Background(), "daylio.csv", []byte(testCSV),
+		`{"journalName": "Family", "from": "2023-12-17", "homeLocation": {"placeName": "Home"}}`)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 1)
+	assert.Equal(t, "Family.json", zr.File[0].Name)
+
+	var report Report
+	require.NoError(t, json.Unmarshal(reportJSON, &report))
+	assert.Equal(t, "Family", report.JournalName)
+	assert.Equal(t, 1, report.EntryCount)
+	require.NotNil(t, report.FirstEntry)
+	assert.Equal(t, "2023-12-17", report.FirstEntry.Format("2006-01-02"))
+}
+
+func TestConvertingWithoutOptions(t *testing.T) {
+	_, reportJSON, err := Convert(context.Background(), "daylio.csv", []byte(testCSV), "")
+	require.NoError(t, err)
+	assert.Contains(t, string(reportJSON), `"entry_count":2`)
+}
+
+func TestConvertingErrors(t *testing.T) {
+	_, _, err := Convert(context.Background(), "daylio.csv", []byte(testCSV), `{"from": "soon"}`)
+	assert.EqualError(t, err, "Not a valid date for 'from': soon")
+
+	_, _, err = Convert(context.Background(), "daylio.csv", []byte(testCSV), `{"device": "toaster"}`)
+	assert.ErrorIs(t, err, exporter.ErrInvalidOption)
+
+	_, _, err = Convert(context.Background(), "daylio.csv", []byte(testCSV), `not json`)
+	assert.Error(t, err)
+
+	_, _, err = Convert(context.Background(), "backup.daylio", []byte("not a zip"), "")
+	assert.ErrorIs(t, err, daylio.ErrCorruptBackup)
+}
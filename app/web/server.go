@@ -0,0 +1,270 @@
+// Package web is a single-page web UI for converting Daylio backups, served
+// by "daylio-to-day-one serve". Uploads are converted in memory with the
+// converter package; nothing is written to disk or sent anywhere else.
+package web
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"exporter/converter"
+	"exporter/daylio"
+	"exporter/exporter"
+	"exporter/types"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MAX_UPLOAD_SIZE is the most that can be uploaded in a single request.
+const MAX_UPLOAD_SIZE = 256 << 20
+
+// MAX_PREVIEW_ENTRIES is how many converted entries a preview includes.
+const MAX_PREVIEW_ENTRIES = 100
+
+//go:embed index.html
+var indexHTML []byte
+
+// Preview describes a conversion without its Day One ZIP file.
+type Preview struct {
+	JournalName string         `json:"journalName"`
+	EntryCount  int            `json:"entryCount"`
+	Sources     []string       `json:"sources"`
+	Entries     []PreviewEntry `json:"entries"`
+}
+
+// PreviewEntry is a converted entry as shown within the preview.
+type PreviewEntry struct {
+	Date string   `json:"date"`
+	Time string   `json:"time"`
+	Mood string   `json:"mood"`
+	Tags []string `json:"tags"`
+	Text string   `json:"text"`
+}
+
+// NewHandler serves the page at "/", previews conversions at "/preview", and
+// downloads Day One ZIP files from "/download". Both take the same
+// multipart form: one or more "file" uploads along with the options read by
+// formOptions.
+func NewHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", serveIndex)
+	mux.HandleFunc("/preview", serveConversion(writePreview))
+	mux.HandleFunc("/download", serveConversion(writeDownload))
+	return localOnly(mux)
+}
+
+// IsLoopbackAddress is true when addr, i.e. "127.0.0.1:8080", can only be
+// reached from this computer.
+func IsLoopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// localOnly rejects requests for other hosts, so that web pages can't reach
+// the server by pointing their own domain at 127.0.0.1.
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsLoopbackAddress(r.Host) && !IsLoopbackAddress(r.Host+":80") {
+			http.Error(w, "Only local requests are allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'unsafe-inline'; script-src 'unsafe-inline'")
+	w.Write(indexHTML)
+}
+
+func serveConversion(write func(w http.ResponseWriter, result *converter.Result) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, errors.New("Only POST requests are allowed"))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, MAX_UPLOAD_SIZE)
+		form, err := readUploadForm(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("Not a valid upload: %w", err))
+			return
+		}
+		opts, err := formOptions(form)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		result, err := converter.Convert(r.Context(), opts)
+		if err != nil {
+			writeError(w, errorStatus(err), err)
+			return
+		}
+		if err := write(w, result); err != nil {
+			log.Errorf("Something went wrong while sending the conversion: %s", err.Error())
+		}
+	}
+}
+
+func writePreview(w http.ResponseWriter, result *converter.Result) error {
+	p := Preview{
+		JournalName: result.JournalName,
+		EntryCount:  len(result.Entries),
+		Sources:     result.Summary.Sources,
+		Entries:     []PreviewEntry{},
+	}
+	for _, e := range result.Entries[:min(len(result.Entries), MAX_PREVIEW_ENTRIES)] {
+		p.Entries = append(p.Entries, PreviewEntry{
+			Date: e.Source.FullDate,
+			Time: e.Source.Time,
+			Mood: e.Source.Mood,
+			Tags: e.DayOne.Tags,
+			Text: e.DayOne.Text,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(&p)
+}
+
+func writeDownload(w http.ResponseWriter, result *converter.Result) error {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%s.zip"`, time.Now().Format("20060102")))
+	return result.WriteDayOneZip(w)
+}
+
+// uploadForm is a multipart form that was read into memory.
+type uploadForm struct {
+	values map[string][]string
+	files  []converter.Input
+}
+
+// readUploadForm reads every part of an upload into memory, unlike
+// ParseMultipartForm, which writes large files to temporary files. The
+// request body limits how much is read.
+func readUploadForm(r *http.Request) (*uploadForm, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	form := uploadForm{values: map[string][]string{}}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return &form, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(part)
+		part.Close()
+		if err != nil {
+			return nil, err
+		}
+		switch name := part.FormName(); {
+		case part.FileName() == "":
+			form.values[name] = append(form.values[name], string(data))
+		case name == "file":
+			form.files = append(form.files, converter.BytesInput(part.FileName(), data))
+		}
+	}
+}
+
+// formOptions turns an upload into conversion options.
+func formOptions(form *uploadForm) (converter.Options, error) {
+	opts := converter.Options{
+		JournalName:      formValue(form, "journal"),
+		TimeZone:         formValue(form, "timezone"),
+		AloneTimeScoring: formValue(form, "alone-time-scoring") != "",
+		Summaries:        formValue(form, "summaries") != "",
+	}
+	opts.Inputs = form.files
+	if len(opts.Inputs) == 0 {
+		return opts, converter.ErrNoInputs
+	}
+	if opts.TimeZone != "" {
+		if _, err := time.LoadLocation(opts.TimeZone); err != nil {
+			return opts, fmt.Errorf("Not a valid time zone: %s", opts.TimeZone)
+		}
+	}
+	if policy := formValue(form, "on-conflict"); policy != "" {
+		p, err := daylio.ParseConflictPolicy(policy)
+		if err != nil {
+			return opts, err
+		}
+		opts.ConflictPolicy = p
+	}
+	filter, err := formFilter(form)
+	if err != nil {
+		return opts, err
+	}
+	opts.Filter = filter
+	if device := formValue(form, "device"); device != "" {
+		p, err := exporter.ParseDeviceProfile(device)
+		if err != nil {
+			return opts, err
+		}
+		opts.Device = p
+	}
+	if home := formValue(form, "home-location"); home != "" {
+		var loc types.DayOneEntryLocation
+		if err := json.Unmarshal([]byte(home), &loc); err != nil {
+			return opts, fmt.Errorf("Not a valid home location: %w", err)
+		}
+		opts.HomeLocation = &loc
+	}
+	return opts, nil
+}
+
+func formFilter(form *uploadForm) (daylio.Filter, error) {
+	return daylio.ParseFilter(formValue(form, "from"), formValue(form, "to"),
+		formValue(form, "activity"), formValue(form, "exclude-activity"))
+}
+
+func formValue(form *uploadForm, key string) string {
+	if values := form.values[key]; len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// errorStatus is 400 for problems with the upload or its options, and 500
+// for anything else.
+func errorStatus(err error) int {
+	var inputErr *converter.InputError
+	var optionsErr *converter.OptionsError
+	var entryErr *daylio.EntryError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	case errors.As(err, &inputErr), errors.As(err, &optionsErr), errors.As(err, &entryErr),
+		errors.Is(err, converter.ErrNoInputs), errors.Is(err, daylio.ErrInvalidOption):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
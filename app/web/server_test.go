@@ -0,0 +1,138 @@
+package web
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCSV = `full_date,date,weekday,time,mood,activities,note_title,note
+2023-12-17,Dec 17,Sunday,08:00,good,home | reading,note title,note text 1
+2023-12-16,Dec 16,Saturday,09:30,rad,friends,,note text 2
+`
+
+// newUploadRequest creates a form upload like the page sends.
+func newUploadRequest(t *testing.T, path string, fields map[string]string, files map[string]string) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, content := range files {
+		w, err := mw.CreateFormFile("file", name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	for k, v := range fields {
+		require.NoError(t, mw.WriteField(k, v))
+	}
+	require.NoError(t, mw.Close())
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Host = "127.0.0.1:8080"
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func serve(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	NewHandler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServingThePage(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "localhost:8080"
+	rec := serve(req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Daylio to Day One")
+}
+
+func TestPreviewingAConversion(t *testing.T) {
+	rec := serve(newUploadRequest(t, "/preview", map[string]string{
+		"journal":       "Family",
+		"timezone":      "America/Chicago",
+		"from":          "2023-12-17",
+		"home-location": `{"placeName": "Home"}`,
+	}, map[string]string{"daylio.csv": testCSV}))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var got Preview
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, "Family", got.JournalName)
+	assert.Equal(t, 1, got.EntryCount)
+	require.Len(t, got.Entries, 1)
+	assert.Equal(t, "2023-12-17", got.Entries[0].Date)
+	assert.Equal(t, "note title\n\nnote text 1", got.Entries[0].Text)
+}
+
+func TestDownloadingAConversion(t *testing.T) {
+	rec := serve(newUploadRequest(t, "/download", map[string]string{"journal": "Family"},
+		map[string]string{"daylio.csv": testCSV}))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 1)
+	assert.Equal(t, "Family.json", zr.File[0].Name)
+}
+
+func TestConversionErrors(t *testing.T) {
+	for name, req := range map[string]*http.Request{
+		"no files":       newUploadRequest(t, "/preview", nil, nil),
+		"bad date":       newUploadRequest(t, "/preview", map[string]string{"from": "soon"}, map[string]string{"daylio.csv": testCSV}),
+		"bad time zone":  newUploadRequest(t, "/preview", map[string]string{"timezone": "Mars/Olympus"}, map[string]string{"daylio.csv": testCSV}),
+		"bad policy":     newUploadRequest(t, "/preview", map[string]string{"on-conflict": "newest"}, map[string]string{"daylio.csv": testCSV}),
+		"bad device":     newUploadRequest(t, "/preview", map[string]string{"device": "toaster"}, map[string]string{"daylio.csv": testCSV}),
+		"corrupt backup": newUploadRequest(t, "/download", nil, map[string]string{"backup.daylio": "not a zip"}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := serve(req)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.NotEmpty(t, body["error"])
+		})
+	}
+}
+
+func TestLargeUploadsAreNeverWrittenToDisk(t *testing.T) {
+	// ParseMultipartForm would write anything over 32MB to a temporary file,
+	// which fails when the temporary directory doesn't exist. Temporary files
+	// are removed afterwards, so checking for leftovers isn't enough.
+	tmp := filepath.Join(t.TempDir(), "tmp")
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv("TMP", tmp)
+	big := strings.Repeat("x", 33<<20)
+	rec := serve(newUploadRequest(t, "/download", nil, map[string]string{"backup.daylio": big}))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "Not a valid upload")
+	assert.Contains(t, rec.Body.String(), "backup.daylio")
+	assert.Equal(t, tmp, os.TempDir())
+	assert.NoDirExists(t, tmp)
+}
+
+func TestOnlyLocalRequestsAreAllowed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "evil.example.com"
+	assert.Equal(t, http.StatusForbidden, serve(req).Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/preview", nil)
+	req.Host = "127.0.0.1:8080"
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(req).Code)
+}
+
+func TestIsLoopbackAddress(t *testing.T) {
+	assert.True(t, IsLoopbackAddress("127.0.0.1:8080"))
+	assert.True(t, IsLoopbackAddress("localhost:0"))
+	assert.True(t, IsLoopbackAddress("[::1]:8080"))
+	assert.False(t, IsLoopbackAddress("0.0.0.0:8080"))
+	assert.False(t, IsLoopbackAddress(":8080"))
+	assert.False(t, IsLoopbackAddress("192.168.1.2:8080"))
+}